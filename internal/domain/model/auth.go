@@ -0,0 +1,31 @@
+package model
+
+import (
+	"net/http"
+)
+
+// BasicAuthCredentials matches the credentials of an Authorization: Basic header.
+type BasicAuthCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (endpoint Endpoint) BasicAuthMatcher(headers map[string][]string) bool {
+	expected := endpoint.Request.BasicAuthCredentials
+	if expected == nil {
+		return true
+	}
+	username, password, ok := basicAuth(headers)
+	return ok && username == expected.Username && password == expected.Password
+}
+
+// basicAuth returns the credentials of the first Authorization header, if it uses the Basic scheme.
+func basicAuth(headers map[string][]string) (username, password string, ok bool) {
+	return (&http.Request{Header: headers}).BasicAuth()
+}
+
+// BasicAuthUsername returns the user name of an Authorization: Basic header, or empty if there is none.
+func BasicAuthUsername(headers map[string][]string) string {
+	username, _, _ := basicAuth(headers)
+	return username
+}
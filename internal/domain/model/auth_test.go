@@ -0,0 +1,59 @@
+package model_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_BasicAuthMatcher(t *testing.T) {
+	basic := func(credentials string) []string {
+		return []string{"Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))}
+	}
+	tests := []struct {
+		name     string
+		expected *model.BasicAuthCredentials
+		headers  map[string][]string
+		want     bool
+	}{
+		{
+			name:     "no credentials defined",
+			expected: nil,
+			headers:  map[string][]string{},
+			want:     true,
+		},
+		{
+			name:     "credentials match",
+			expected: &model.BasicAuthCredentials{Username: "alice", Password: "s3cr:et"},
+			headers:  map[string][]string{"Authorization": basic("alice:s3cr:et")},
+			want:     true,
+		},
+		{
+			name:     "wrong password",
+			expected: &model.BasicAuthCredentials{Username: "alice", Password: "secret"},
+			headers:  map[string][]string{"Authorization": basic("alice:wrong")},
+			want:     false,
+		},
+		{
+			name:     "bearer token",
+			expected: &model.BasicAuthCredentials{Username: "alice", Password: "secret"},
+			headers:  map[string][]string{"Authorization": {"Bearer abc"}},
+			want:     false,
+		},
+		{
+			name:     "no authorization header",
+			expected: &model.BasicAuthCredentials{Username: "alice", Password: "secret"},
+			headers:  map[string][]string{},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{BasicAuthCredentials: tt.expected}}
+			if got := e.BasicAuthMatcher(tt.headers); got != tt.want {
+				t.Errorf("BasicAuthMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
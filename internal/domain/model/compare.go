@@ -0,0 +1,170 @@
+package model
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateTimeLayouts are tried in order when parsing a date-time without actualFormat.
+// Values without a time zone are read as UTC.
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC850,
+	time.ANSIC,
+}
+
+// relativeDateTime is "now" followed by any number of offsets such as -1d or +2h.
+var relativeDateTime = regexp.MustCompile(`^now((?:\s*[+-]\s*[0-9]+\s*[smhdwMy])*)$`)
+
+// dateTimeOffsets is a sequence of offsets such as +1d-2h, without the leading now.
+var dateTimeOffsets = regexp.MustCompile(`^(?:\s*[+-]\s*[0-9]+\s*[smhdwMy])*\s*$`)
+
+var relativeOffset = regexp.MustCompile(`([+-])\s*([0-9]+)\s*([smhdwMy])`)
+
+// matchIgnoreCase applies equalToIgnoreCase and containsIgnoreCase.
+func (m Matcher) matchIgnoreCase(value string) bool {
+	if m.EqualToIgnoreCase != "" && !strings.EqualFold(value, m.EqualToIgnoreCase) {
+		return false
+	}
+	if m.ContainsIgnoreCase != "" && !strings.Contains(strings.ToLower(value), strings.ToLower(m.ContainsIgnoreCase)) {
+		return false
+	}
+	return true
+}
+
+// matchNumber applies greaterThan, lessThan and between. A value that is not a number never matches them.
+func (m Matcher) matchNumber(value string) bool {
+	if m.GreaterThan == nil && m.LessThan == nil && m.Between == nil {
+		return true
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return false
+	}
+	if m.GreaterThan != nil && !(n > *m.GreaterThan) {
+		return false
+	}
+	if m.LessThan != nil && !(n < *m.LessThan) {
+		return false
+	}
+	if m.Between != nil {
+		if len(m.Between) != 2 {
+			slog.Error(fmt.Sprintf("between must have a lower and an upper bound: %v", m.Between))
+			return false
+		}
+		if n < m.Between[0] || n > m.Between[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchDateTime applies before, after and equalToDateTime. A value that is not a date-time never matches them.
+func (m Matcher) matchDateTime(value string) bool {
+	if m.Before == "" && m.After == "" && m.EqualToDateTime == "" {
+		return true
+	}
+	actual, err := parseDateTime(strings.TrimSpace(value), m.ActualFormat)
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	for _, c := range []struct {
+		expected string
+		ok       func(time.Time) bool
+	}{
+		{m.Before, actual.Before},
+		{m.After, actual.After},
+		{m.EqualToDateTime, actual.Equal},
+	} {
+		if c.expected == "" {
+			continue
+		}
+		expected, err := expectedDateTime(c.expected, now)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Invalid date-time %s: %s", c.expected, err))
+			return false
+		}
+		if !c.ok(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// expectedDateTime parses the date-time of a matcher, which may be relative to now.
+func expectedDateTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	sub := relativeDateTime.FindStringSubmatch(s)
+	if sub == nil {
+		return parseDateTime(s, "")
+	}
+	return AddDateTimeOffsets(now, sub[1])
+}
+
+// ParseDateTime parses a date-time the way the date-time matchers read expected values:
+// now followed by offsets such as now+1d-2h, a plain integer as Unix time in seconds, or one of dateTimeLayouts.
+func ParseDateTime(s string, now time.Time) (time.Time, error) {
+	return expectedDateTime(s, now)
+}
+
+// AddDateTimeOffsets moves t by offsets such as +1d or -1M+2h.
+// Units are s, m (minutes), h, d, w, M (months) and y.
+func AddDateTimeOffsets(t time.Time, offsets string) (time.Time, error) {
+	if !dateTimeOffsets.MatchString(offsets) {
+		return time.Time{}, fmt.Errorf("invalid date-time offset: %s", offsets)
+	}
+	for _, o := range relativeOffset.FindAllStringSubmatch(offsets, -1) {
+		n, err := strconv.Atoi(o[2])
+		if err != nil {
+			return time.Time{}, err
+		}
+		if o[1] == "-" {
+			n = -n
+		}
+		switch o[3] {
+		case "s":
+			t = t.Add(time.Duration(n) * time.Second)
+		case "m":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "h":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "M":
+			t = t.AddDate(0, n, 0)
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+	return t, nil
+}
+
+// parseDateTime parses s with the given Go layout, or with the first of dateTimeLayouts that fits.
+// Without a layout, a plain integer is read as Unix time in seconds, as in the exp claim of a JWT.
+func parseDateTime(s, layout string) (time.Time, error) {
+	if layout != "" {
+		return time.Parse(layout, s)
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	for _, l := range dateTimeLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported date-time format: %s", s)
+}
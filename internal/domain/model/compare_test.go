@@ -0,0 +1,183 @@
+package model_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func Test_QueryMatcherComparison(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		matcher model.Matcher
+		value   string
+		want    bool
+	}{
+		{
+			name:    "equalToIgnoreCase match",
+			matcher: model.Matcher{EqualToIgnoreCase: "ACTIVE"},
+			value:   "Active",
+			want:    true,
+		},
+		{
+			name:    "equalToIgnoreCase does not match",
+			matcher: model.Matcher{EqualToIgnoreCase: "ACTIVE"},
+			value:   "inactive",
+			want:    false,
+		},
+		{
+			name:    "containsIgnoreCase match",
+			matcher: model.Matcher{ContainsIgnoreCase: "json"},
+			value:   "application/JSON; charset=utf-8",
+			want:    true,
+		},
+		{
+			name:    "containsIgnoreCase does not match",
+			matcher: model.Matcher{ContainsIgnoreCase: "json"},
+			value:   "text/plain",
+			want:    false,
+		},
+		{
+			name:    "greaterThan match",
+			matcher: model.Matcher{GreaterThan: float(0)},
+			value:   "1",
+			want:    true,
+		},
+		{
+			name:    "greaterThan is exclusive",
+			matcher: model.Matcher{GreaterThan: float(0)},
+			value:   "0",
+			want:    false,
+		},
+		{
+			name:    "lessThan match",
+			matcher: model.Matcher{LessThan: float(100)},
+			value:   "99.5",
+			want:    true,
+		},
+		{
+			name:    "lessThan does not match",
+			matcher: model.Matcher{LessThan: float(100)},
+			value:   "100",
+			want:    false,
+		},
+		{
+			name:    "between is inclusive",
+			matcher: model.Matcher{Between: []float64{1, 100}},
+			value:   "100",
+			want:    true,
+		},
+		{
+			name:    "between does not match",
+			matcher: model.Matcher{Between: []float64{1, 100}},
+			value:   "101",
+			want:    false,
+		},
+		{
+			name:    "between without both bounds",
+			matcher: model.Matcher{Between: []float64{1}},
+			value:   "1",
+			want:    false,
+		},
+		{
+			name:    "not a number",
+			matcher: model.Matcher{GreaterThan: float(0)},
+			value:   "ten",
+			want:    false,
+		},
+		{
+			name:    "before RFC3339",
+			matcher: model.Matcher{Before: "2024-07-01T00:00:00Z"},
+			value:   "2024-06-30T23:59:59+00:00",
+			want:    true,
+		},
+		{
+			name:    "before does not match",
+			matcher: model.Matcher{Before: "2024-07-01T00:00:00Z"},
+			value:   "2024-07-01T09:00:00+09:00",
+			want:    false,
+		},
+		{
+			name:    "after date only",
+			matcher: model.Matcher{After: "2024-01-01"},
+			value:   "2024-01-02",
+			want:    true,
+		},
+		{
+			name:    "after and before as a range",
+			matcher: model.Matcher{After: "2024-01-01", Before: "2024-02-01"},
+			value:   "2024-02-15",
+			want:    false,
+		},
+		{
+			name:    "equalToDateTime across time zones",
+			matcher: model.Matcher{EqualToDateTime: "2024-07-01T00:00:00Z"},
+			value:   "2024-07-01T09:00:00+09:00",
+			want:    true,
+		},
+		{
+			name:    "equalToDateTime HTTP date",
+			matcher: model.Matcher{EqualToDateTime: "2024-07-01T00:00:00Z"},
+			value:   "Mon, 01 Jul 2024 00:00:00 GMT",
+			want:    true,
+		},
+		{
+			name:    "after relative to now",
+			matcher: model.Matcher{After: "now-1d"},
+			value:   now.Add(-time.Hour).Format(time.RFC3339),
+			want:    true,
+		},
+		{
+			name:    "after relative to now does not match",
+			matcher: model.Matcher{After: "now-1d"},
+			value:   now.AddDate(0, 0, -2).Format(time.RFC3339),
+			want:    false,
+		},
+		{
+			name:    "before relative to now with several offsets",
+			matcher: model.Matcher{Before: "now+1d-2h"},
+			value:   now.Add(20 * time.Hour).Format(time.RFC3339),
+			want:    true,
+		},
+		{
+			name:    "actualFormat",
+			matcher: model.Matcher{Before: "2024-07-01", ActualFormat: "02/01/2006"},
+			value:   "30/06/2024",
+			want:    true,
+		},
+		{
+			name:    "Unix time in seconds",
+			matcher: model.Matcher{After: "2024-06-30T23:59:59Z", Before: "2024-07-01T00:00:01Z"},
+			value:   "1719792000",
+			want:    true,
+		},
+		{
+			name:    "not a date-time",
+			matcher: model.Matcher{Before: "now"},
+			value:   "yesterday",
+			want:    false,
+		},
+		{
+			name:    "invalid expected date-time",
+			matcher: model.Matcher{Before: "tomorrow"},
+			value:   "2024-07-01",
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{QueryParameters: map[string]model.Matcher{"v": tt.matcher}}}
+			q := url.Values{"v": {tt.value}}
+			if got, _ := e.QueryMatcher(q, q); got != tt.want {
+				t.Errorf("QueryMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
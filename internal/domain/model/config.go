@@ -1,9 +1,11 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/url"
+	"reflect"
 	"regexp"
 	"slices"
 	"strings"
@@ -11,37 +13,145 @@ import (
 
 // define the structure of the JSON configuration file
 type Matcher struct {
-	EqualTo        any `json:"equalTo"`
-	Matches        any `json:"matches"`
-	DoesNotMatch   any `json:"doesNotMatch"`
-	Contains       any `json:"contains"`
-	DoesNotContain any `json:"doesNotContain"`
+	EqualTo        any `json:"equalTo,omitempty"`
+	Matches        any `json:"matches,omitempty"`
+	DoesNotMatch   any `json:"doesNotMatch,omitempty"`
+	Contains       any `json:"contains,omitempty"`
+	DoesNotContain any `json:"doesNotContain,omitempty"`
+
+	EqualToIgnoreCase  string `json:"equalToIgnoreCase,omitempty"`
+	ContainsIgnoreCase string `json:"containsIgnoreCase,omitempty"`
+
+	// 値を数値として比較する
+	GreaterThan *float64  `json:"greaterThan,omitempty"`
+	LessThan    *float64  `json:"lessThan,omitempty"`
+	Between     []float64 `json:"between,omitempty"` // [下限, 上限]。両端を含む
+
+	// 値を日時として比較する。"now-1d"のように現在時刻からの相対値も指定できる
+	Before          string `json:"before,omitempty"`
+	After           string `json:"after,omitempty"`
+	EqualToDateTime string `json:"equalToDateTime,omitempty"`
+	ActualFormat    string `json:"actualFormat,omitempty"` // リクエストの値のレイアウト (Goの形式)。未指定の場合はRFC3339などを順に試す
+
+	MatchesJSONPath []JSONPathMatcher `json:"matchesJsonPath,omitempty"` // すべての条件を満たす場合にマッチする
+
+	// JSONとして比較する。JSON文字列またはJSONの値で指定する
+	EqualToJSON         any  `json:"equalToJson,omitempty"`
+	IgnoreArrayOrder    bool `json:"ignoreArrayOrder,omitempty"`
+	IgnoreExtraElements bool `json:"ignoreExtraElements,omitempty"`
+
+	MatchesJSONSchema any `json:"matchesJsonSchema,omitempty"` // インラインのJSON Schema、またはスキーマファイルのパス
+
+	MatchesXPath    []XPathMatcher    `json:"matchesXPath,omitempty"`    // すべての条件を満たす場合にマッチする
+	XPathNamespaces map[string]string `json:"xPathNamespaces,omitempty"` // プレフィックス -> 名前空間URI
+	EqualToXML      string            `json:"equalToXml,omitempty"`      // 空白、名前空間のプレフィックス、属性の順序を無視して比較する
+
+	// 繰り返し指定されたクエリパラメータやヘッダーのすべての値に適用する
+	HasExactly []Matcher     `json:"hasExactly,omitempty"` // 各値がそれぞれ異なるマッチャーを満たし、値の数がマッチャーの数と等しい
+	Includes   []Matcher     `json:"includes,omitempty"`   // 各マッチャーをいずれかの値が満たす
+	Count      *CountMatcher `json:"count,omitempty"`      // 値の数に適用する
+
+	And    []Matcher `json:"and,omitempty"`    // すべてのマッチャーを満たす場合にマッチする
+	Or     []Matcher `json:"or,omitempty"`     // いずれかのマッチャーを満たす場合にマッチする
+	Not    *Matcher  `json:"not,omitempty"`    // マッチャーを満たさない場合にマッチする
+	Absent bool      `json:"absent,omitempty"` // trueの場合は、値が存在しない場合にのみマッチする。他の条件は無視される
 }
 type Request struct {
-	URL             string `json:"url"`             // パスパラメータ、クエリパラメータを含む完全一致
-	URLPattern      string `json:"urlPattern"`      // パスパラメータ、クエリパラメータを含む正規表現での完全一致
-	URLPath         string `json:"urlPath"`         // パスパラメータを含む完全一致
-	URLPathPattern  string `json:"urlPathPattern"`  // パスパラメータを含む正規表現での完全一致
-	URLPathTemplate string `json:"urlPathTemplate"` // パスパラメータを含むテンプレートでの完全一致
-
-	Method          string             `json:"method"`
-	Headers         map[string]Matcher `json:"headers"` // HTTP header matchers
-	QueryParameters map[string]Matcher `json:"queryParameters"`
-	PathParameters  map[string]Matcher `json:"pathParameters"`
-	Body            Matcher            `json:"body"`
+	URL             string `json:"url,omitempty"`             // パスパラメータ、クエリパラメータを含む完全一致
+	URLPattern      string `json:"urlPattern,omitempty"`      // パスパラメータ、クエリパラメータを含む正規表現での完全一致
+	URLPath         string `json:"urlPath,omitempty"`         // パスパラメータを含む完全一致
+	URLPathPattern  string `json:"urlPathPattern,omitempty"`  // パスパラメータを含む正規表現での完全一致
+	URLPathTemplate string `json:"urlPathTemplate,omitempty"` // パスパラメータを含むテンプレートでの完全一致
+
+	Method          MethodMatcher      `json:"method,omitzero"`   // メソッド、"ANY"、メソッドの配列またはマッチャー。未指定の場合はすべてのメソッドにマッチする
+	Headers         map[string]Matcher `json:"headers,omitempty"` // HTTP header matchers
+	QueryParameters map[string]Matcher `json:"queryParameters,omitempty"`
+	PathParameters  map[string]Matcher `json:"pathParameters,omitempty"`
+	Cookies         map[string]Matcher `json:"cookies,omitempty"`
+	Body            Matcher            `json:"body,omitzero"`
+
+	FormParameters    map[string]Matcher `json:"formParameters,omitempty"`    // application/x-www-form-urlencodedまたはmultipart/form-dataのフィールド
+	MultipartPatterns []MultipartPattern `json:"multipartPatterns,omitempty"` // すべてのパターンを満たす場合にマッチする
+
+	Host   Matcher `json:"host,omitzero"`   // ポートを除いたHostヘッダー (小文字)
+	Scheme Matcher `json:"scheme,omitzero"` // http または https
+	Port   Matcher `json:"port,omitzero"`   // リクエストを受け付けたポート
+
+	BasicAuthCredentials *BasicAuthCredentials `json:"basicAuthCredentials,omitempty"`
+	JWT                  *JWTMatcher           `json:"jwt,omitempty"`
 }
 type Response struct {
-	Status        int               `json:"status"`
-	BodyFileName  string            `json:"bodyFileName"` // bodyFileNameが指定されている場合は、bodyは無視される
-	Body          string            `json:"body"`         // bodyFileNameが指定されていない場合は、bodyを使用する
-	Headers       map[string]string `json:"headers"`
-	Transformaers []string          `json:"transformers"`
+	Status        int                       `json:"status"`
+	BodyFileName  string                    `json:"bodyFileName,omitempty"` // bodyFileNameが指定されている場合は、bodyは無視される
+	Body          string                    `json:"body,omitempty"`         // bodyFileNameが指定されていない場合は、bodyを使用する
+	Headers       map[string]ResponseHeader `json:"headers,omitempty"`      // 値はテンプレートとして展開される
+	Transformaers []string                  `json:"transformers,omitempty"`
+
+	// bodyのテンプレートエンジン。text、json、htmlのいずれか。省略時はContent-Typeヘッダーから選ぶ
+	TemplateEngine string `json:"templateEngine,omitempty"`
+
+	// 両方が指定されている場合は合計した時間だけ遅延する。0を指定すると--delayのデフォルトの遅延も無効になる
+	FixedDelayMilliseconds *int               `json:"fixedDelayMilliseconds,omitempty"`
+	DelayDistribution      *DelayDistribution `json:"delayDistribution,omitempty"`
+
+	Fault string `json:"fault,omitempty"` // 指定されている場合は、正常なレスポンスの代わりに接続を壊す
+
+	// proxyBaseUrlが指定されている場合は、リクエストを転送してそのレスポンスを返す
+	ProxyBaseURL                  string            `json:"proxyBaseUrl,omitempty"`
+	AdditionalProxyRequestHeaders map[string]string `json:"additionalProxyRequestHeaders,omitempty"`
+	RemoveProxyRequestHeaders     []string          `json:"removeProxyRequestHeaders,omitempty"`
+	ProxyURLPrefixToRemove        string            `json:"proxyUrlPrefixToRemove,omitempty"`
+	ProxyURLPrefixReplacement     string            `json:"proxyUrlPrefixReplacement,omitempty"` // proxyUrlPrefixToRemoveで取り除いたプレフィックスの代わりに付ける
+}
+
+// ResponseHeader holds the values of a single response header.
+// In JSON it can be written either as a string or as an array of strings.
+type ResponseHeader []string
+
+func (h *ResponseHeader) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*h = ResponseHeader{single}
+		return nil
+	}
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return fmt.Errorf("header value must be a string or an array of strings: %s", data)
+	}
+	*h = ResponseHeader(multi)
+	return nil
 }
+
+func (h ResponseHeader) MarshalJSON() ([]byte, error) {
+	if len(h) == 1 {
+		return json.Marshal(h[0])
+	}
+	return json.Marshal([]string(h))
+}
+
 type Endpoint struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
+	ID          string   `json:"id"` // 未指定の場合は読み込み時に採番される
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Priority    int      `json:"priority,omitempty"` // 1が最優先。未指定の場合はDefaultPriority
 	Request     Request  `json:"request"`
 	Response    Response `json:"response"`
+
+	// scenarioNameが指定されている場合は、シナリオの状態に応じてマッチする
+	ScenarioName          string `json:"scenarioName,omitempty"`
+	RequiredScenarioState string `json:"requiredScenarioState,omitempty"` // 未指定の場合は状態に関係なくマッチする
+	NewScenarioState      string `json:"newScenarioState,omitempty"`      // マッチした場合の遷移先
+}
+
+// DefaultPriority is the priority of endpoints that do not set one.
+const DefaultPriority = 5
+
+// EffectivePriority returns the priority of the endpoint. Lower values win.
+func (endpoint Endpoint) EffectivePriority() int {
+	if endpoint.Priority <= 0 {
+		return DefaultPriority
+	}
+	return endpoint.Priority
 }
 
 func (endpoint Endpoint) PathMatcher(gotRawPath, gotPath string) (bool, map[string]string) {
@@ -101,16 +211,7 @@ func (endpoint Endpoint) PathMatcher(gotRawPath, gotPath string) (bool, map[stri
 	}
 
 	for k, v := range endpoint.Request.PathParameters {
-		switch {
-		case v.EqualTo != nil && gotPathUnits[posMap[k]] != fmt.Sprint(v.EqualTo):
-			return false, nil
-		case v.Matches != nil && !regexp.MustCompile(v.Matches.(string)).MatchString(gotPathUnits[posMap[k]]):
-			return false, nil
-		case v.DoesNotMatch != nil && regexp.MustCompile(v.DoesNotMatch.(string)).MatchString(gotPathUnits[posMap[k]]):
-			return false, nil
-		case v.Contains != nil && !strings.Contains(gotPathUnits[posMap[k]], v.Contains.(string)):
-			return false, nil
-		case v.DoesNotContain != nil && strings.Contains(gotPathUnits[posMap[k]], v.DoesNotContain.(string)):
+		if !v.matchValue(gotPathUnits[posMap[k]], true) {
 			return false, nil
 		}
 	}
@@ -123,16 +224,7 @@ func (endpoint Endpoint) PathMatcher(gotRawPath, gotPath string) (bool, map[stri
 
 func (endpoint Endpoint) QueryMatcher(gotRawQuery, gotQuery url.Values) (bool, map[string]string) {
 	for k, v := range endpoint.Request.QueryParameters {
-		switch {
-		case v.EqualTo != nil && gotRawQuery.Get(k) != fmt.Sprint(v.EqualTo):
-			return false, nil
-		case v.Matches != nil && !regexp.MustCompile(v.Matches.(string)).MatchString(gotRawQuery.Get(k)):
-			return false, nil
-		case v.DoesNotMatch != nil && regexp.MustCompile(v.DoesNotMatch.(string)).MatchString(gotRawQuery.Get(k)):
-			return false, nil
-		case v.Contains != nil && !strings.Contains(gotRawQuery.Get(k), v.Contains.(string)):
-			return false, nil
-		case v.DoesNotContain != nil && strings.Contains(gotRawQuery.Get(k), v.DoesNotContain.(string)):
+		if !v.matchValues(gotRawQuery[k]) {
 			return false, nil
 		}
 	}
@@ -143,39 +235,108 @@ func (endpoint Endpoint) QueryMatcher(gotRawQuery, gotQuery url.Values) (bool, m
 	return true, ret
 }
 
+func (endpoint Endpoint) CookieMatcher(cookies map[string][]string) bool {
+	for k, v := range endpoint.Request.Cookies {
+		if !v.matchValues(cookies[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (endpoint Endpoint) HostMatcher(host string) bool {
+	return endpoint.Request.Host.matchValue(host, host != "")
+}
+
+func (endpoint Endpoint) SchemeMatcher(scheme string) bool {
+	return endpoint.Request.Scheme.matchValue(scheme, scheme != "")
+}
+
+func (endpoint Endpoint) PortMatcher(port string) bool {
+	return endpoint.Request.Port.matchValue(port, port != "")
+}
+
 func (endpoint Endpoint) BodyMatcher(body string) bool {
+	return endpoint.Request.Body.matchValue(body, body != "")
+}
+
+// matchValue reports whether the value satisfies every condition of the matcher.
+// present is false when the value is missing from the request, in which case value is empty.
+func (m Matcher) matchValue(value string, present bool) bool {
+	if !present {
+		return m.matchValues(nil)
+	}
+	return m.matchValues([]string{value})
+}
+
+// matchValues reports whether the values of a possibly repeated parameter satisfy every condition of the matcher.
+// hasExactly, includes and count look at all values, the other conditions at the first one.
+func (m Matcher) matchValues(values []string) bool {
+	if m.Absent {
+		return len(values) == 0
+	}
+	value := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
 	switch {
-	case endpoint.Request.Body.EqualTo != nil && body != fmt.Sprint(endpoint.Request.Body.EqualTo):
+	case m.EqualTo != nil && value != fmt.Sprint(m.EqualTo):
+		return false
+	case m.Matches != nil && !compiledRegexp(m.Matches.(string)).MatchString(value):
+		return false
+	case m.DoesNotMatch != nil && compiledRegexp(m.DoesNotMatch.(string)).MatchString(value):
+		return false
+	case m.Contains != nil && !strings.Contains(value, m.Contains.(string)):
+		return false
+	case m.DoesNotContain != nil && strings.Contains(value, m.DoesNotContain.(string)):
+		return false
+	}
+	if !m.matchIgnoreCase(value) || !m.matchNumber(value) || !m.matchDateTime(value) {
+		return false
+	}
+	if m.EqualToJSON != nil && !m.matchEqualToJSON(value) {
 		return false
-	case endpoint.Request.Body.Matches != nil && !regexp.MustCompile(endpoint.Request.Body.Matches.(string)).MatchString(body):
+	}
+	if m.MatchesJSONSchema != nil && !m.matchJSONSchema(value) {
 		return false
-	case endpoint.Request.Body.DoesNotMatch != nil && regexp.MustCompile(endpoint.Request.Body.DoesNotMatch.(string)).MatchString(body):
+	}
+	for _, jp := range m.MatchesJSONPath {
+		if !jp.match(value) {
+			return false
+		}
+	}
+	if m.EqualToXML != "" && !m.matchEqualToXML(value) {
 		return false
-	case endpoint.Request.Body.Contains != nil && !strings.Contains(body, endpoint.Request.Body.Contains.(string)):
+	}
+	for _, xp := range m.MatchesXPath {
+		if !xp.match(value, m.XPathNamespaces) {
+			return false
+		}
+	}
+	if !m.matchMultiValue(values) {
 		return false
-	case endpoint.Request.Body.DoesNotContain != nil && strings.Contains(body, endpoint.Request.Body.DoesNotContain.(string)):
+	}
+	for _, sub := range m.And {
+		if !sub.matchValues(values) {
+			return false
+		}
+	}
+	if len(m.Or) > 0 && !slices.ContainsFunc(m.Or, func(sub Matcher) bool { return sub.matchValues(values) }) {
+		return false
+	}
+	if m.Not != nil && m.Not.matchValues(values) {
 		return false
 	}
 	return true
 }
 
+func (m Matcher) isZero() bool {
+	return reflect.ValueOf(m).IsZero()
+}
+
 func (endpoint Endpoint) HeaderMatcher(headers map[string][]string) (bool, map[string][]string) {
 	for k, v := range endpoint.Request.Headers {
-		headerVal := ""
-		if values, exists := headers[k]; exists && len(values) > 0 {
-			headerVal = values[0]
-		}
-
-		switch {
-		case v.EqualTo != nil && headerVal != fmt.Sprint(v.EqualTo):
-			return false, nil
-		case v.Matches != nil && !regexp.MustCompile(v.Matches.(string)).MatchString(headerVal):
-			return false, nil
-		case v.DoesNotMatch != nil && regexp.MustCompile(v.DoesNotMatch.(string)).MatchString(headerVal):
-			return false, nil
-		case v.Contains != nil && !strings.Contains(headerVal, v.Contains.(string)):
-			return false, nil
-		case v.DoesNotContain != nil && strings.Contains(headerVal, v.DoesNotContain.(string)):
+		if !v.matchValues(headers[k]) {
 			return false, nil
 		}
 	}
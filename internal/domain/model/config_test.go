@@ -1,6 +1,7 @@
 package model_test
 
 import (
+	"encoding/json"
 	"net/url"
 	"testing"
 
@@ -313,6 +314,44 @@ func Test_PathMatcher(t *testing.T) {
 			want:    false,
 			wantMap: nil,
 		},
+		{
+			name: "url path template or match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						URLPathTemplate: "/items/{id}",
+						PathParameters: map[string]model.Matcher{
+							"id": {
+								Or: []model.Matcher{{Matches: "^[0-9]+$"}, {EqualTo: "latest"}},
+							},
+						},
+					},
+				},
+				gotPath: "/items/latest",
+			},
+			want: true,
+			wantMap: map[string]string{
+				"id": "latest",
+			},
+		},
+		{
+			name: "url path template not does not match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						URLPathTemplate: "/items/{id}",
+						PathParameters: map[string]model.Matcher{
+							"id": {
+								Not: &model.Matcher{EqualTo: "0"},
+							},
+						},
+					},
+				},
+				gotPath: "/items/0",
+			},
+			want:    false,
+			wantMap: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -597,6 +636,100 @@ func Test_QueryMatcher(t *testing.T) {
 			want:    false,
 			wantMap: nil,
 		},
+		{
+			name: "query absent match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						QueryParameters: map[string]model.Matcher{
+							"debug": {Absent: true},
+						},
+					},
+				},
+				gotRawQuery: url.Values{},
+				gotQuery:    url.Values{},
+			},
+			want:    true,
+			wantMap: map[string]string{},
+		},
+		{
+			name: "query absent does not match empty value",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						QueryParameters: map[string]model.Matcher{
+							"debug": {Absent: true},
+						},
+					},
+				},
+				gotRawQuery: url.Values{
+					"debug": []string{""},
+				},
+				gotQuery: url.Values{
+					"debug": []string{""},
+				},
+			},
+			want:    false,
+			wantMap: nil,
+		},
+		{
+			name: "query or match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						QueryParameters: map[string]model.Matcher{
+							"sort": {Or: []model.Matcher{{EqualTo: "asc"}, {EqualTo: "desc"}}},
+						},
+					},
+				},
+				gotRawQuery: url.Values{
+					"sort": []string{"desc"},
+				},
+				gotQuery: url.Values{
+					"sort": []string{"desc"},
+				},
+			},
+			want: true,
+			wantMap: map[string]string{
+				"sort": "desc",
+			},
+		},
+		{
+			name: "query or does not match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						QueryParameters: map[string]model.Matcher{
+							"sort": {Or: []model.Matcher{{EqualTo: "asc"}, {EqualTo: "desc"}}},
+						},
+					},
+				},
+				gotRawQuery: url.Values{
+					"sort": []string{"random"},
+				},
+				gotQuery: url.Values{
+					"sort": []string{"random"},
+				},
+			},
+			want:    false,
+			wantMap: nil,
+		},
+		{
+			name: "query or with absent",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						QueryParameters: map[string]model.Matcher{
+							"page": {Or: []model.Matcher{{Absent: true}, {Matches: "^[0-9]+$"}}},
+						},
+					},
+				},
+				gotRawQuery: url.Values{},
+				gotQuery:    url.Values{},
+			},
+			want:    true,
+			wantMap: map[string]string{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -882,6 +1015,93 @@ func Test_HeaderMatcher(t *testing.T) {
 			want:    false,
 			wantMap: nil,
 		},
+		{
+			name: "header absent match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Headers: map[string]model.Matcher{
+							"Authorization": {Absent: true},
+						},
+					},
+				},
+				headers: map[string][]string{
+					"Accept": {"*/*"},
+				},
+			},
+			want: true,
+			wantMap: map[string][]string{
+				"Accept": {"*/*"},
+			},
+		},
+		{
+			name: "header absent does not match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Headers: map[string]model.Matcher{
+							"Authorization": {Absent: true},
+						},
+					},
+				},
+				headers: map[string][]string{
+					"Authorization": {"Bearer token123"},
+				},
+			},
+			want:    false,
+			wantMap: nil,
+		},
+		{
+			name: "header not absent requires the header",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Headers: map[string]model.Matcher{
+							"Authorization": {Not: &model.Matcher{Absent: true}},
+						},
+					},
+				},
+				headers: map[string][]string{},
+			},
+			want:    false,
+			wantMap: nil,
+		},
+		{
+			name: "header and match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Headers: map[string]model.Matcher{
+							"User-Agent": {And: []model.Matcher{{Matches: "^Mozilla/"}, {Matches: "Firefox/[0-9]+"}}},
+						},
+					},
+				},
+				headers: map[string][]string{
+					"User-Agent": {"Mozilla/5.0 Firefox/128"},
+				},
+			},
+			want: true,
+			wantMap: map[string][]string{
+				"User-Agent": {"Mozilla/5.0 Firefox/128"},
+			},
+		},
+		{
+			name: "header and does not match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Headers: map[string]model.Matcher{
+							"User-Agent": {And: []model.Matcher{{Matches: "^Mozilla/"}, {Matches: "Firefox/[0-9]+"}}},
+						},
+					},
+				},
+				headers: map[string][]string{
+					"User-Agent": {"Mozilla/5.0 Chrome/126"},
+				},
+			},
+			want:    false,
+			wantMap: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1044,6 +1264,62 @@ func Test_BodyMatcher(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "body not match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Body: model.Matcher{
+							Not: &model.Matcher{Or: []model.Matcher{{Contains: "password"}, {Contains: "secret"}}},
+						},
+					},
+				},
+				body: "this is a sample",
+			},
+			want: true,
+		},
+		{
+			name: "body not does not match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Body: model.Matcher{
+							Not: &model.Matcher{Or: []model.Matcher{{Contains: "password"}, {Contains: "secret"}}},
+						},
+					},
+				},
+				body: "my secret",
+			},
+			want: false,
+		},
+		{
+			name: "body absent match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Body: model.Matcher{
+							Absent: true,
+						},
+					},
+				},
+				body: "",
+			},
+			want: true,
+		},
+		{
+			name: "body absent does not match",
+			args: args{
+				endpoint: model.Endpoint{
+					Request: model.Request{
+						Body: model.Matcher{
+							Absent: true,
+						},
+					},
+				},
+				body: "test",
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1053,3 +1329,103 @@ func Test_BodyMatcher(t *testing.T) {
 		})
 	}
 }
+
+func Test_ResponseHeaderUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    model.ResponseHeader
+		wantErr bool
+	}{
+		{
+			name: "single string",
+			data: `"application/json"`,
+			want: model.ResponseHeader{"application/json"},
+		},
+		{
+			name: "array of strings",
+			data: `["a=1", "b=2"]`,
+			want: model.ResponseHeader{"a=1", "b=2"},
+		},
+		{
+			name:    "number is rejected",
+			data:    `1`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got model.ResponseHeader
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("diff: %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_CookieMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookies map[string]model.Matcher
+		got     map[string][]string
+		want    bool
+	}{
+		{
+			name:    "cookie equalTo match",
+			cookies: map[string]model.Matcher{"session": {EqualTo: "abc"}},
+			got:     map[string][]string{"session": {"abc"}, "theme": {"dark"}},
+			want:    true,
+		},
+		{
+			name:    "cookie equalTo does not match",
+			cookies: map[string]model.Matcher{"session": {EqualTo: "abc"}},
+			got:     map[string][]string{"session": {"xyz"}},
+			want:    false,
+		},
+		{
+			name:    "cookie absent match",
+			cookies: map[string]model.Matcher{"session": {Absent: true}},
+			got:     map[string][]string{"theme": {"dark"}},
+			want:    true,
+		},
+		{
+			name:    "repeated cookie",
+			cookies: map[string]model.Matcher{"id": {Includes: []model.Matcher{{EqualTo: "2"}}}},
+			got:     map[string][]string{"id": {"1", "2"}},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{Cookies: tt.cookies}}
+			if got := e.CookieMatcher(tt.got); got != tt.want {
+				t.Errorf("CookieMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_HostSchemePortMatcher(t *testing.T) {
+	e := model.Endpoint{Request: model.Request{
+		Host:   model.Matcher{Matches: `^api\.`},
+		Scheme: model.Matcher{EqualTo: "https"},
+		Port:   model.Matcher{EqualTo: 8443},
+	}}
+	if !e.HostMatcher("api.example.com") || e.HostMatcher("www.example.com") {
+		t.Errorf("HostMatcher() does not follow the host matcher")
+	}
+	if !e.SchemeMatcher("https") || e.SchemeMatcher("http") {
+		t.Errorf("SchemeMatcher() does not follow the scheme matcher")
+	}
+	if !e.PortMatcher("8443") || e.PortMatcher("8080") {
+		t.Errorf("PortMatcher() does not follow the port matcher")
+	}
+	var unset model.Endpoint
+	if !unset.HostMatcher("api.example.com") || !unset.SchemeMatcher("http") || !unset.PortMatcher("") {
+		t.Errorf("unset matchers should match any request")
+	}
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	DelayDistributionUniform   = "uniform"
+	DelayDistributionLognormal = "lognormal"
+)
+
+// DelayDistribution describes a random response delay in milliseconds.
+type DelayDistribution struct {
+	Type string `json:"type"` // uniform or lognormal
+
+	// uniform: a delay between lower and upper, both inclusive
+	Lower int `json:"lower,omitempty"`
+	Upper int `json:"upper,omitempty"`
+
+	// lognormal: half of the delays are below median, sigma controls the length of the tail
+	Median   int     `json:"median,omitempty"`
+	Sigma    float64 `json:"sigma,omitempty"`
+	MaxValue int     `json:"maxValue,omitempty"` // caps the delay when set
+}
+
+// HasDelay reports whether the response defines a delay of its own, which may be zero.
+func (r Response) HasDelay() bool {
+	return r.FixedDelayMilliseconds != nil || r.DelayDistribution != nil
+}
+
+// Delay returns how long to wait before sending the response.
+// The fixed delay and a sample of the distribution are added together.
+func (r Response) Delay() time.Duration {
+	var ms int
+	if r.FixedDelayMilliseconds != nil {
+		ms = *r.FixedDelayMilliseconds
+	}
+	if r.DelayDistribution != nil {
+		ms += r.DelayDistribution.sample()
+	}
+	return time.Duration(max(ms, 0)) * time.Millisecond
+}
+
+func (d DelayDistribution) sample() int {
+	switch d.Type {
+	case DelayDistributionUniform:
+		if d.Upper <= d.Lower {
+			return d.Lower
+		}
+		return d.Lower + rand.IntN(d.Upper-d.Lower+1)
+	case DelayDistributionLognormal:
+		ms := int(math.Round(float64(d.Median) * math.Exp(d.Sigma*rand.NormFloat64())))
+		if d.MaxValue > 0 {
+			ms = min(ms, d.MaxValue)
+		}
+		return ms
+	default:
+		slog.Error(fmt.Sprintf("Unknown delay distribution type: %s", d.Type))
+		return 0
+	}
+}
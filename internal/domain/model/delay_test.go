@@ -0,0 +1,105 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_ResponseDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		response model.Response
+		min      time.Duration
+		max      time.Duration
+	}{
+		{
+			name:     "no delay",
+			response: model.Response{},
+			min:      0,
+			max:      0,
+		},
+		{
+			name:     "fixed delay",
+			response: model.Response{FixedDelayMilliseconds: milliseconds(200)},
+			min:      200 * time.Millisecond,
+			max:      200 * time.Millisecond,
+		},
+		{
+			name: "uniform distribution",
+			response: model.Response{
+				DelayDistribution: &model.DelayDistribution{Type: "uniform", Lower: 100, Upper: 150},
+			},
+			min: 100 * time.Millisecond,
+			max: 150 * time.Millisecond,
+		},
+		{
+			name: "fixed delay is added to the distribution",
+			response: model.Response{
+				FixedDelayMilliseconds: milliseconds(1000),
+				DelayDistribution:      &model.DelayDistribution{Type: "uniform", Lower: 100, Upper: 150},
+			},
+			min: 1100 * time.Millisecond,
+			max: 1150 * time.Millisecond,
+		},
+		{
+			name: "lognormal distribution without spread is the median",
+			response: model.Response{
+				DelayDistribution: &model.DelayDistribution{Type: "lognormal", Median: 80},
+			},
+			min: 80 * time.Millisecond,
+			max: 80 * time.Millisecond,
+		},
+		{
+			name: "lognormal distribution is capped by maxValue",
+			response: model.Response{
+				DelayDistribution: &model.DelayDistribution{Type: "lognormal", Median: 80, Sigma: 5, MaxValue: 100},
+			},
+			min: 0,
+			max: 100 * time.Millisecond,
+		},
+		{
+			name: "unknown distribution is ignored",
+			response: model.Response{
+				FixedDelayMilliseconds: milliseconds(10),
+				DelayDistribution:      &model.DelayDistribution{Type: "poisson", Median: 80},
+			},
+			min: 10 * time.Millisecond,
+			max: 10 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				got := tt.response.Delay()
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Delay() = %v, want between %v and %v", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func milliseconds(ms int) *int {
+	return &ms
+}
+
+func Test_ResponseHasDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		response model.Response
+		want     bool
+	}{
+		{name: "no delay", response: model.Response{}, want: false},
+		{name: "fixed delay of zero", response: model.Response{FixedDelayMilliseconds: milliseconds(0)}, want: true},
+		{name: "distribution", response: model.Response{DelayDistribution: &model.DelayDistribution{Type: "uniform"}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.response.HasDelay(); got != tt.want {
+				t.Errorf("HasDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
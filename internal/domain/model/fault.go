@@ -0,0 +1,9 @@
+package model
+
+// Faults that a stub can simulate instead of sending a response.
+const (
+	FaultConnectionResetByPeer  = "CONNECTION_RESET_BY_PEER" // close the connection with a TCP reset
+	FaultEmptyResponse          = "EMPTY_RESPONSE"           // close the connection without sending anything
+	FaultMalformedResponseChunk = "MALFORMED_RESPONSE_CHUNK" // send valid headers followed by a broken chunked body
+	FaultRandomDataThenClose    = "RANDOM_DATA_THEN_CLOSE"   // send garbage instead of an HTTP response
+)
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"net/textproto"
+	"slices"
+	"strings"
+)
+
+const (
+	MultipartMatchingAny = "ANY"
+	MultipartMatchingAll = "ALL"
+)
+
+// MultipartPattern matches the parts of a multipart/form-data body.
+type MultipartPattern struct {
+	MatchingType string             `json:"matchingType,omitempty"` // ANY (デフォルト): いずれかのパートがマッチする、ALL: すべてのパートがマッチする
+	Name         Matcher            `json:"name,omitzero"`          // Content-Dispositionのname
+	FileName     Matcher            `json:"fileName,omitzero"`      // Content-Dispositionのfilename。ファイル以外のパートでは存在しない
+	Headers      map[string]Matcher `json:"headers,omitempty"`
+	Body         Matcher            `json:"body,omitzero"`
+}
+
+// MultipartPart is a single part of a multipart/form-data request body.
+type MultipartPart struct {
+	Name     string
+	FileName string
+	Headers  map[string][]string
+	Body     string
+}
+
+func (endpoint Endpoint) FormMatcher(form map[string][]string) bool {
+	for k, v := range endpoint.Request.FormParameters {
+		if !v.matchValues(form[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (endpoint Endpoint) MultipartMatcher(parts []MultipartPart) bool {
+	for _, p := range endpoint.Request.MultipartPatterns {
+		if !p.match(parts) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p MultipartPattern) match(parts []MultipartPart) bool {
+	if strings.EqualFold(p.MatchingType, MultipartMatchingAll) {
+		return len(parts) > 0 && !slices.ContainsFunc(parts, func(part MultipartPart) bool { return !p.matchPart(part) })
+	}
+	return slices.ContainsFunc(parts, p.matchPart)
+}
+
+func (p MultipartPattern) matchPart(part MultipartPart) bool {
+	if !p.Name.matchValue(part.Name, part.Name != "") || !p.FileName.matchValue(part.FileName, part.FileName != "") {
+		return false
+	}
+	for k, v := range p.Headers {
+		if !v.matchValues(part.Headers[textproto.CanonicalMIMEHeaderKey(k)]) {
+			return false
+		}
+	}
+	return p.Body.matchValue(part.Body, part.Body != "")
+}
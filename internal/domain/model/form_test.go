@@ -0,0 +1,140 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_FormMatcher(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]model.Matcher
+		form   map[string][]string
+		want   bool
+	}{
+		{
+			name:   "form equalTo match",
+			params: map[string]model.Matcher{"grant_type": {EqualTo: "password"}},
+			form:   map[string][]string{"grant_type": {"password"}, "username": {"alice"}},
+			want:   true,
+		},
+		{
+			name:   "form equalTo does not match",
+			params: map[string]model.Matcher{"grant_type": {EqualTo: "password"}},
+			form:   map[string][]string{"grant_type": {"client_credentials"}},
+			want:   false,
+		},
+		{
+			name:   "form field absent",
+			params: map[string]model.Matcher{"scope": {Absent: true}},
+			form:   map[string][]string{"grant_type": {"password"}},
+			want:   true,
+		},
+		{
+			name:   "repeated form field",
+			params: map[string]model.Matcher{"tag": {HasExactly: []model.Matcher{{EqualTo: "a"}, {EqualTo: "b"}}}},
+			form:   map[string][]string{"tag": {"b", "a"}},
+			want:   true,
+		},
+		{
+			name:   "body is not a form",
+			params: map[string]model.Matcher{"grant_type": {EqualTo: "password"}},
+			form:   nil,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{FormParameters: tt.params}}
+			if got := e.FormMatcher(tt.form); got != tt.want {
+				t.Errorf("FormMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MultipartMatcher(t *testing.T) {
+	parts := []model.MultipartPart{
+		{
+			Name:    "metadata",
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    `{"title": "Holiday"}`,
+		},
+		{
+			Name:     "file",
+			FileName: "beach.png",
+			Headers:  map[string][]string{"Content-Type": {"image/png"}},
+			Body:     "\x89PNG...",
+		},
+	}
+	tests := []struct {
+		name     string
+		patterns []model.MultipartPattern
+		parts    []model.MultipartPart
+		want     bool
+	}{
+		{
+			name:     "part by name and body",
+			patterns: []model.MultipartPattern{{Name: model.Matcher{EqualTo: "metadata"}, Body: model.Matcher{EqualToJSON: `{"title": "Holiday"}`}}},
+			parts:    parts,
+			want:     true,
+		},
+		{
+			name:     "part body does not match",
+			patterns: []model.MultipartPattern{{Name: model.Matcher{EqualTo: "metadata"}, Body: model.Matcher{Contains: "Work"}}},
+			parts:    parts,
+			want:     false,
+		},
+		{
+			name: "file upload by file name and header",
+			patterns: []model.MultipartPattern{{
+				FileName: model.Matcher{Matches: `\.png$`},
+				Headers:  map[string]model.Matcher{"content-type": {EqualTo: "image/png"}},
+			}},
+			parts: parts,
+			want:  true,
+		},
+		{
+			name:     "part without a file name",
+			patterns: []model.MultipartPattern{{Name: model.Matcher{EqualTo: "metadata"}, FileName: model.Matcher{Not: &model.Matcher{Absent: true}}}},
+			parts:    parts,
+			want:     false,
+		},
+		{
+			name:     "ALL requires every part to match",
+			patterns: []model.MultipartPattern{{MatchingType: model.MultipartMatchingAll, Headers: map[string]model.Matcher{"Content-Type": {EqualTo: "application/json"}}}},
+			parts:    parts,
+			want:     false,
+		},
+		{
+			name:     "ALL match",
+			patterns: []model.MultipartPattern{{MatchingType: model.MultipartMatchingAll, Name: model.Matcher{Matches: "^(metadata|file)$"}}},
+			parts:    parts,
+			want:     true,
+		},
+		{
+			name: "every pattern must hold",
+			patterns: []model.MultipartPattern{
+				{Name: model.Matcher{EqualTo: "metadata"}},
+				{Name: model.Matcher{EqualTo: "thumbnail"}},
+			},
+			parts: parts,
+			want:  false,
+		},
+		{
+			name:     "body is not multipart",
+			patterns: []model.MultipartPattern{{MatchingType: model.MultipartMatchingAll}},
+			parts:    nil,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{MultipartPatterns: tt.patterns}}
+			if got := e.MultipartMatcher(tt.parts); got != tt.want {
+				t.Errorf("MultipartMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"net/url"
+	"time"
+)
+
+// LoggedRequest is a request recorded in the request journal.
+type LoggedRequest struct {
+	ID                string              `json:"id"`
+	Method            string              `json:"method"`
+	URL               string              `json:"url"` // パスとクエリを含むリクエストURI
+	RawPath           string              `json:"rawPath"`
+	Path              string              `json:"path"`
+	RawQuery          url.Values          `json:"rawQuery"`
+	Query             url.Values          `json:"query"`
+	Headers           map[string][]string `json:"headers"`
+	Body              string              `json:"body"`
+	Scheme            string              `json:"scheme"`
+	Host              string              `json:"host"`              // ポートを除いたHostヘッダー
+	Port              string              `json:"port"`              // リクエストを受け付けたポート
+	MatchedEndpointID string              `json:"matchedEndpointId"` // マッチしなかった場合は空
+	MatchedEndpoint   string              `json:"matchedEndpoint"`   // マッチしたEndpoint.Name
+	Match             *MatchInfo          `json:"match,omitempty"`   // マッチしなかった場合はnil
+	LoggedAt          time.Time           `json:"loggedAt"`
+}
+
+// MatchInfo explains why an endpoint was chosen over the other endpoints that matched the request.
+type MatchInfo struct {
+	Priority    int      `json:"priority"`
+	Specificity int      `json:"specificity"`        // エンドポイントが定義している制約の数
+	Shadowed    []string `json:"shadowed,omitempty"` // 同じくマッチしたが選ばれなかったエンドポイントのID (優先順)
+	Reason      string   `json:"reason"`
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// json-unit placeholders that can be used as values in equalToJson.
+const (
+	JSONUnitIgnore     = "${json-unit.ignore}"      // any value
+	JSONUnitAnyString  = "${json-unit.any-string}"  // any string
+	JSONUnitAnyNumber  = "${json-unit.any-number}"  // any number
+	JSONUnitAnyBoolean = "${json-unit.any-boolean}" // true or false
+	JSONUnitRegex      = "${json-unit.regex}"       // a string matching the regular expression that follows
+)
+
+// matchEqualToJSON compares the body with equalToJson as parsed JSON,
+// so that key order and whitespace do not matter.
+func (m Matcher) matchEqualToJSON(body string) bool {
+	expected, err := m.expectedJSON()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Invalid equalToJson: %s", err))
+		return false
+	}
+	actual, err := decodeJSON(body)
+	if err != nil {
+		return false
+	}
+	return jsonEqual(expected, actual, m.IgnoreArrayOrder, m.IgnoreExtraElements)
+}
+
+// expectedJSON returns the document of equalToJson, which is either a JSON string or an inline JSON value.
+func (m Matcher) expectedJSON() (any, error) {
+	if s, ok := m.EqualToJSON.(string); ok {
+		return decodeJSON(s)
+	}
+	b, err := json.Marshal(m.EqualToJSON)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(string(b))
+}
+
+func jsonEqual(expected, actual any, ignoreArrayOrder, ignoreExtraElements bool) bool {
+	if s, ok := expected.(string); ok && strings.HasPrefix(s, "${json-unit.") {
+		return matchJSONUnitPlaceholder(s, actual)
+	}
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok || (!ignoreExtraElements && len(a) != len(e)) {
+			return false
+		}
+		for k, ev := range e {
+			av, ok := a[k]
+			if !ok || !jsonEqual(ev, av, ignoreArrayOrder, ignoreExtraElements) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || len(a) < len(e) || (!ignoreExtraElements && len(a) != len(e)) {
+			return false
+		}
+		if ignoreArrayOrder {
+			return matchUnordered(e, a, ignoreArrayOrder, ignoreExtraElements)
+		}
+		for i := range e {
+			if !jsonEqual(e[i], a[i], ignoreArrayOrder, ignoreExtraElements) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		a, ok := actual.(json.Number)
+		return ok && numberEqual(e, a)
+	default: // string, bool or null
+		return expected == actual
+	}
+}
+
+// matchUnordered reports whether every expected element can be paired with a distinct actual element.
+// Plain values are paired by counting them, and objects, arrays and placeholders with pairDistinct.
+func matchUnordered(expected, actual []any, ignoreArrayOrder, ignoreExtraElements bool) bool {
+	counts := make(map[string]int, len(actual))
+	for _, a := range actual {
+		if k, ok := jsonValueKey(a); ok {
+			counts[k]++
+		}
+	}
+	var rest []any
+	for _, e := range expected {
+		k, ok := jsonValueKey(e)
+		if s, isString := e.(string); !ok || isString && strings.HasPrefix(s, "${json-unit.") {
+			rest = append(rest, e)
+			continue
+		}
+		// equal plain values are interchangeable, so any of them can be paired
+		if counts[k] == 0 {
+			return false
+		}
+		counts[k]--
+	}
+	var remaining []any
+	for _, a := range actual {
+		if k, ok := jsonValueKey(a); ok {
+			if counts[k] == 0 {
+				continue
+			}
+			counts[k]--
+		}
+		remaining = append(remaining, a)
+	}
+	return pairDistinct(len(rest), len(remaining), func(i, j int) bool {
+		return jsonEqual(rest[i], remaining[j], ignoreArrayOrder, ignoreExtraElements)
+	})
+}
+
+// jsonValueKey returns a key that is the same for equal strings, numbers, booleans and nulls.
+// It reports false for objects and arrays.
+func jsonValueKey(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return "s" + v, true
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(v.String()); ok {
+			return "n" + r.RatString(), true
+		}
+		return "n" + v.String(), true
+	case bool:
+		return fmt.Sprintf("b%t", v), true
+	case nil:
+		return "null", true
+	default:
+		return "", false
+	}
+}
+
+// numberEqual compares numbers by value, so that 1, 1.0 and 1e0 are equal.
+func numberEqual(a, b json.Number) bool {
+	x, okx := new(big.Rat).SetString(a.String())
+	y, oky := new(big.Rat).SetString(b.String())
+	if !okx || !oky {
+		return a == b
+	}
+	return x.Cmp(y) == 0
+}
+
+func matchJSONUnitPlaceholder(placeholder string, actual any) bool {
+	switch {
+	case placeholder == JSONUnitIgnore:
+		return true
+	case placeholder == JSONUnitAnyString:
+		_, ok := actual.(string)
+		return ok
+	case placeholder == JSONUnitAnyNumber:
+		_, ok := actual.(json.Number)
+		return ok
+	case placeholder == JSONUnitAnyBoolean:
+		_, ok := actual.(bool)
+		return ok
+	case strings.HasPrefix(placeholder, JSONUnitRegex):
+		s, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		re, err := regexp.Compile(strings.TrimPrefix(placeholder, JSONUnitRegex))
+		if err != nil {
+			slog.Error(fmt.Sprintf("Invalid regular expression in %s: %s", placeholder, err))
+			return false
+		}
+		return re.MatchString(s)
+	default:
+		// not a known placeholder, so it is compared as a plain string
+		return placeholder == actual
+	}
+}
@@ -0,0 +1,198 @@
+package model_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_BodyMatcherEqualToJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher model.Matcher
+		body    string
+		want    bool
+	}{
+		{
+			name:    "key order and whitespace are ignored",
+			matcher: model.Matcher{EqualToJSON: `{"name": "Alice", "age": 30}`},
+			body:    "{\n  \"age\": 30,\n  \"name\": \"Alice\"\n}",
+			want:    true,
+		},
+		{
+			name:    "different value",
+			matcher: model.Matcher{EqualToJSON: `{"name": "Alice", "age": 30}`},
+			body:    `{"name": "Bob", "age": 30}`,
+			want:    false,
+		},
+		{
+			name:    "inline JSON value",
+			matcher: model.Matcher{EqualToJSON: map[string]any{"ids": []any{1.0, 2.0}}},
+			body:    `{"ids": [1, 2]}`,
+			want:    true,
+		},
+		{
+			name:    "numbers are compared by value",
+			matcher: model.Matcher{EqualToJSON: `{"price": 10.50}`},
+			body:    `{"price": 1.05e1}`,
+			want:    true,
+		},
+		{
+			name:    "number is not a string",
+			matcher: model.Matcher{EqualToJSON: `{"id": 1}`},
+			body:    `{"id": "1"}`,
+			want:    false,
+		},
+		{
+			name:    "extra element",
+			matcher: model.Matcher{EqualToJSON: `{"name": "Alice"}`},
+			body:    `{"name": "Alice", "age": 30}`,
+			want:    false,
+		},
+		{
+			name:    "extra element is ignored",
+			matcher: model.Matcher{EqualToJSON: `{"user": {"name": "Alice"}}`, IgnoreExtraElements: true},
+			body:    `{"user": {"name": "Alice", "age": 30}, "requestId": "x"}`,
+			want:    true,
+		},
+		{
+			name:    "missing element is not ignored",
+			matcher: model.Matcher{EqualToJSON: `{"name": "Alice", "age": 30}`, IgnoreExtraElements: true},
+			body:    `{"name": "Alice"}`,
+			want:    false,
+		},
+		{
+			name:    "array order matters by default",
+			matcher: model.Matcher{EqualToJSON: `[1, 2, 3]`},
+			body:    `[3, 2, 1]`,
+			want:    false,
+		},
+		{
+			name:    "array order is ignored",
+			matcher: model.Matcher{EqualToJSON: `{"tags": ["a", "b", "a"]}`, IgnoreArrayOrder: true},
+			body:    `{"tags": ["a", "a", "b"]}`,
+			want:    true,
+		},
+		{
+			name:    "array with different elements in another order",
+			matcher: model.Matcher{EqualToJSON: `["a", "b", "a"]`, IgnoreArrayOrder: true},
+			body:    `["a", "b", "b"]`,
+			want:    false,
+		},
+		{
+			name:    "extra array elements in any order",
+			matcher: model.Matcher{EqualToJSON: `[{"id": 2}]`, IgnoreArrayOrder: true, IgnoreExtraElements: true},
+			body:    `[{"id": 1}, {"id": 2, "name": "b"}]`,
+			want:    true,
+		},
+		{
+			name:    "numbers in any order are compared by value",
+			matcher: model.Matcher{EqualToJSON: `[1, 2.0, null, true]`, IgnoreArrayOrder: true},
+			body:    `[true, 2, null, 1e0]`,
+			want:    true,
+		},
+		{
+			name:    "placeholder takes the element left by plain values",
+			matcher: model.Matcher{EqualToJSON: `["${json-unit.any-string}", "a"]`, IgnoreArrayOrder: true},
+			body:    `["a", "b"]`,
+			want:    true,
+		},
+		{
+			name:    "earlier pairing is revised for a later element",
+			matcher: model.Matcher{EqualToJSON: `[{"id": "${json-unit.any-number}"}, {"id": 1}]`, IgnoreArrayOrder: true},
+			body:    `[{"id": 1}, {"id": 2}]`,
+			want:    true,
+		},
+		{
+			name:    "extra array elements after the expected ones",
+			matcher: model.Matcher{EqualToJSON: `[1, 2]`, IgnoreExtraElements: true},
+			body:    `[1, 2, 3]`,
+			want:    true,
+		},
+		{
+			name: "placeholders",
+			matcher: model.Matcher{EqualToJSON: `{
+				"id": "${json-unit.any-string}",
+				"count": "${json-unit.any-number}",
+				"active": "${json-unit.any-boolean}",
+				"createdAt": "${json-unit.regex}^\\d{4}-\\d{2}-\\d{2}T",
+				"meta": "${json-unit.ignore}"
+			}`},
+			body: `{"id": "7f3a", "count": 3, "active": false, "createdAt": "2025-01-01T10:00:00Z", "meta": {"trace": [1]}}`,
+			want: true,
+		},
+		{
+			name:    "placeholder with wrong type",
+			matcher: model.Matcher{EqualToJSON: `{"id": "${json-unit.any-string}"}`},
+			body:    `{"id": 7}`,
+			want:    false,
+		},
+		{
+			name:    "ignored field must be present",
+			matcher: model.Matcher{EqualToJSON: `{"id": 1, "meta": "${json-unit.ignore}"}`},
+			body:    `{"id": 1}`,
+			want:    false,
+		},
+		{
+			name:    "body is not JSON",
+			matcher: model.Matcher{EqualToJSON: `{"id": 1}`},
+			body:    `id=1`,
+			want:    false,
+		},
+		{
+			name:    "invalid expected JSON",
+			matcher: model.Matcher{EqualToJSON: `{"id": `},
+			body:    `{"id": 1}`,
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{Body: tt.matcher}}
+			if got := e.BodyMatcher(tt.body); got != tt.want {
+				t.Errorf("BodyMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_EqualToJSONFromConfig(t *testing.T) {
+	var m model.Matcher
+	data := `{"equalToJson": {"id": "${json-unit.any-number}", "items": [{"sku": "A1"}]}, "ignoreExtraElements": true}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	e := model.Endpoint{Request: model.Request{Body: m}}
+	if !e.BodyMatcher(`{"id": 12, "items": [{"sku": "A1", "qty": 2}], "note": ""}`) {
+		t.Error("BodyMatcher() = false, want true")
+	}
+}
+
+func Test_BodyMatcherEqualToJSONLargeUnorderedArray(t *testing.T) {
+	// every expected element can be paired with all but the last actual element,
+	// which takes factorial time to rule out when every pairing is tried
+	var expected, actual []string
+	for i := range 30 {
+		expected = append(expected, `{"id": "${json-unit.any-number}"}`)
+		actual = append(actual, fmt.Sprintf(`{"id": %d}`, i))
+	}
+	expected = append(expected, `{"id": "${json-unit.any-number}"}`)
+	actual = append(actual, `{"id": "30"}`)
+	m := model.Matcher{EqualToJSON: "[" + strings.Join(expected, ",") + "]", IgnoreArrayOrder: true}
+	e := model.Endpoint{Request: model.Request{Body: m}}
+
+	done := make(chan bool)
+	go func() { done <- e.BodyMatcher("[" + strings.Join(actual, ",") + "]") }()
+	select {
+	case got := <-done:
+		if got {
+			t.Errorf("BodyMatcher() = true, want false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("BodyMatcher() did not return within 5s")
+	}
+}
@@ -0,0 +1,606 @@
+package model
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONPathMatcher selects values from a JSON body with a JSONPath expression.
+// Without a nested matcher the expression only has to select something,
+// otherwise at least one of the selected values has to satisfy the matcher.
+// In JSON it can also be written as a plain expression string.
+type JSONPathMatcher struct {
+	Expression string `json:"expression"`
+	Matcher
+}
+
+func (m *JSONPathMatcher) UnmarshalJSON(data []byte) error {
+	var expression string
+	if err := json.Unmarshal(data, &expression); err == nil {
+		*m = JSONPathMatcher{Expression: expression}
+		return nil
+	}
+	// plain has the same fields without this method, so the default decoding applies
+	type plain JSONPathMatcher
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*m = JSONPathMatcher(p)
+	return nil
+}
+
+func (m JSONPathMatcher) match(body string) bool {
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return false
+	}
+	nodes, err := evaluateJSONPath(m.Expression, doc)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to evaluate JSONPath: %s", err))
+		return false
+	}
+	if len(nodes) == 0 {
+		return false
+	}
+	if m.Matcher.isZero() {
+		return true
+	}
+	for _, n := range nodes {
+		if m.Matcher.matchValue(jsonValueString(n), true) {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONPathValues returns the values that the expression selects from a JSON document.
+// Strings are returned as they are and everything else as compact JSON. A document that is not JSON selects nothing.
+func JSONPathValues(expression, document string) ([]string, error) {
+	steps, err := parseJSONPath(expression, '$')
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJSON(document)
+	if err != nil {
+		return nil, nil
+	}
+	var ret []string
+	for _, n := range applyJSONPath(steps, doc) {
+		ret = append(ret, jsonValueString(n))
+	}
+	return ret, nil
+}
+
+// decodeJSON parses a JSON document, keeping numbers as json.Number so that they are reported as written.
+func decodeJSON(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return v, nil
+}
+
+// jsonValueString converts a selected node to the string the nested matcher is applied to.
+// Strings are used as is and everything else as compact JSON.
+func jsonValueString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
+
+// evaluateJSONPath returns the nodes of the document selected by the expression.
+// Supported syntax: $, .name, ['name'], [n], [a,b], [start:end:step], *, .. and filters such as
+// [?(@.price < 10 && @.sku == 'A1')], [?(@.name =~ /^a/i)] and [?(@.isbn)].
+func evaluateJSONPath(expression string, doc any) ([]any, error) {
+	steps, err := parseJSONPath(expression, '$')
+	if err != nil {
+		return nil, err
+	}
+	return applyJSONPath(steps, doc), nil
+}
+
+type jsonPathStep struct {
+	recursive bool // apply the selector to the node and all of its descendants
+	selector  func(node any) []any
+}
+
+func applyJSONPath(steps []jsonPathStep, root any) []any {
+	nodes := []any{root}
+	for _, step := range steps {
+		var next []any
+		for _, n := range nodes {
+			if step.recursive {
+				for _, d := range descendants(n) {
+					next = append(next, step.selector(d)...)
+				}
+				continue
+			}
+			next = append(next, step.selector(n)...)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// descendants returns the node and everything below it in document order.
+func descendants(node any) []any {
+	ret := []any{node}
+	for _, child := range children(node) {
+		ret = append(ret, descendants(child)...)
+	}
+	return ret
+}
+
+// children returns the elements of an array or the values of an object sorted by key.
+func children(node any) []any {
+	switch n := node.(type) {
+	case []any:
+		return n
+	case map[string]any:
+		ret := make([]any, 0, len(n))
+		for _, k := range slices.Sorted(maps.Keys(n)) {
+			ret = append(ret, n[k])
+		}
+		return ret
+	default:
+		return nil
+	}
+}
+
+type jsonPathParser struct {
+	s   string
+	pos int
+}
+
+// parseJSONPath parses an expression that starts with root ('$' for the document, '@' inside a filter).
+func parseJSONPath(expression string, root byte) ([]jsonPathStep, error) {
+	p := &jsonPathParser{s: strings.TrimSpace(expression)}
+	if p.peek() != root {
+		return nil, fmt.Errorf("JSONPath must start with %c: %s", root, expression)
+	}
+	p.pos++
+	steps, err := p.steps()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %s: %w", expression, err)
+	}
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("invalid JSONPath %s: unexpected %q at %d", expression, p.s[p.pos:], p.pos)
+	}
+	return steps, nil
+}
+
+func (p *jsonPathParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *jsonPathParser) steps() ([]jsonPathStep, error) {
+	var steps []jsonPathStep
+	for {
+		var (
+			step jsonPathStep
+			err  error
+		)
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], ".."):
+			p.pos += 2
+			step.recursive = true
+			if p.peek() == '[' {
+				step.selector, err = p.bracket()
+			} else {
+				step.selector, err = p.name()
+			}
+		case p.peek() == '.':
+			p.pos++
+			step.selector, err = p.name()
+		case p.peek() == '[':
+			step.selector, err = p.bracket()
+		default:
+			return steps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+}
+
+// name parses the member name after a dot.
+func (p *jsonPathParser) name() (func(any) []any, error) {
+	if p.peek() == '*' {
+		p.pos++
+		return children, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(".[]()=!<>&|~ \t", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, fmt.Errorf("missing member name at %d", start)
+	}
+	return memberSelector([]string{p.s[start:p.pos]}), nil
+}
+
+// bracket parses a [...] selector.
+func (p *jsonPathParser) bracket() (func(any) []any, error) {
+	p.pos++ // [
+	end, err := p.closing('[', ']')
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(p.s[p.pos:end])
+	p.pos = end + 1
+	switch {
+	case content == "*":
+		return children, nil
+	case strings.HasPrefix(content, "?"):
+		filter := strings.TrimSpace(content[1:])
+		if !strings.HasPrefix(filter, "(") || !strings.HasSuffix(filter, ")") {
+			return nil, fmt.Errorf("filter must be enclosed in parentheses: %s", content)
+		}
+		return parseJSONPathFilter(filter[1 : len(filter)-1])
+	case strings.HasPrefix(content, "'") || strings.HasPrefix(content, `"`):
+		var names []string
+		for _, part := range splitOutsideQuotes(content, ",") {
+			name, ok := unquote(strings.TrimSpace(part))
+			if !ok {
+				return nil, fmt.Errorf("invalid member name: %s", part)
+			}
+			names = append(names, name)
+		}
+		return memberSelector(names), nil
+	case strings.Contains(content, ":"):
+		return parseSlice(content)
+	default:
+		var indexes []int
+		for _, part := range strings.Split(content, ",") {
+			i, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("invalid index: %s", part)
+			}
+			indexes = append(indexes, i)
+		}
+		return indexSelector(indexes), nil
+	}
+}
+
+// closing returns the position of the bracket that closes the one just consumed, skipping quoted strings.
+func (p *jsonPathParser) closing(open, close byte) (int, error) {
+	depth := 1
+	var quote byte
+	for i := p.pos; i < len(p.s); i++ {
+		c := p.s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("missing %c", close)
+}
+
+func memberSelector(names []string) func(any) []any {
+	return func(node any) []any {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		var ret []any
+		for _, name := range names {
+			if v, ok := obj[name]; ok {
+				ret = append(ret, v)
+			}
+		}
+		return ret
+	}
+}
+
+func indexSelector(indexes []int) func(any) []any {
+	return func(node any) []any {
+		arr, ok := node.([]any)
+		if !ok {
+			return nil
+		}
+		var ret []any
+		for _, i := range indexes {
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				ret = append(ret, arr[i])
+			}
+		}
+		return ret
+	}
+}
+
+func parseSlice(content string) (func(any) []any, error) {
+	parts := strings.Split(content, ":")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid slice: %s", content)
+	}
+	var bounds [3]*int
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slice: %s", content)
+		}
+		bounds[i] = &n
+	}
+	step := 1
+	if bounds[2] != nil {
+		step = *bounds[2]
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("slice step must be positive: %s", content)
+	}
+	return func(node any) []any {
+		arr, ok := node.([]any)
+		if !ok {
+			return nil
+		}
+		clamp := func(b *int, def int) int {
+			if b == nil {
+				return def
+			}
+			i := *b
+			if i < 0 {
+				i += len(arr)
+			}
+			return min(max(i, 0), len(arr))
+		}
+		var ret []any
+		for i := clamp(bounds[0], 0); i < clamp(bounds[1], len(arr)); i += step {
+			ret = append(ret, arr[i])
+		}
+		return ret
+	}, nil
+}
+
+// jsonPathCondition is a single comparison in a filter, or an existence test when op is empty.
+type jsonPathCondition struct {
+	path    []jsonPathStep
+	op      string
+	literal any // string, float64, bool, nil or *regexp.Regexp
+}
+
+var jsonPathOperators = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+// parseJSONPathFilter parses conditions combined with && and ||, where && binds tighter.
+func parseJSONPathFilter(filter string) (func(any) []any, error) {
+	var alternatives [][]jsonPathCondition
+	for _, or := range splitOutsideQuotes(filter, "||") {
+		var conditions []jsonPathCondition
+		for _, and := range splitOutsideQuotes(or, "&&") {
+			c, err := parseJSONPathCondition(strings.TrimSpace(and))
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, c)
+		}
+		alternatives = append(alternatives, conditions)
+	}
+	holds := func(node any) bool {
+		for _, conditions := range alternatives {
+			if !slices.ContainsFunc(conditions, func(c jsonPathCondition) bool { return !c.holds(node) }) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(node any) []any {
+		var ret []any
+		for _, child := range children(node) {
+			if holds(child) {
+				ret = append(ret, child)
+			}
+		}
+		return ret
+	}, nil
+}
+
+func parseJSONPathCondition(s string) (jsonPathCondition, error) {
+	p := &jsonPathParser{s: s}
+	if p.peek() != '@' {
+		return jsonPathCondition{}, fmt.Errorf("filter condition must start with @: %s", s)
+	}
+	p.pos++
+	path, err := p.steps()
+	if err != nil {
+		return jsonPathCondition{}, err
+	}
+	rest := strings.TrimSpace(s[p.pos:])
+	if rest == "" {
+		return jsonPathCondition{path: path}, nil
+	}
+	for _, op := range jsonPathOperators {
+		if !strings.HasPrefix(rest, op) {
+			continue
+		}
+		literal, err := parseJSONPathLiteral(strings.TrimSpace(rest[len(op):]), op == "=~")
+		if err != nil {
+			return jsonPathCondition{}, err
+		}
+		return jsonPathCondition{path: path, op: op, literal: literal}, nil
+	}
+	return jsonPathCondition{}, fmt.Errorf("invalid filter condition: %s", s)
+}
+
+func parseJSONPathLiteral(s string, regex bool) (any, error) {
+	if regex {
+		pattern, flags, ok := strings.Cut(strings.TrimPrefix(s, "/"), "/")
+		if !strings.HasPrefix(s, "/") || !ok {
+			if unquoted, ok := unquote(s); ok {
+				pattern, flags = unquoted, ""
+			} else {
+				return nil, fmt.Errorf("invalid regular expression: %s", s)
+			}
+		}
+		if strings.Contains(flags, "i") {
+			pattern = "(?i)" + pattern
+		}
+		return regexp.Compile(pattern)
+	}
+	if unquoted, ok := unquote(s); ok {
+		return unquoted, nil
+	}
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid literal: %s", s)
+	}
+	return f, nil
+}
+
+func (c jsonPathCondition) holds(node any) bool {
+	values := applyJSONPath(c.path, node)
+	if c.op == "" {
+		return len(values) > 0
+	}
+	return slices.ContainsFunc(values, func(v any) bool {
+		return compareJSONValue(v, c.op, c.literal)
+	})
+}
+
+func compareJSONValue(v any, op string, literal any) bool {
+	if re, ok := literal.(*regexp.Regexp); ok {
+		s, ok := v.(string)
+		return ok && re.MatchString(s)
+	}
+	var order int
+	switch lit := literal.(type) {
+	case float64:
+		n, ok := v.(json.Number)
+		if !ok {
+			return op == "!="
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		order = cmp.Compare(f, lit)
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return op == "!="
+		}
+		order = strings.Compare(s, lit)
+	default: // bool or null
+		equal := v == literal
+		switch op {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		default:
+			return false
+		}
+	}
+	switch op {
+	case "==":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	default:
+		return false
+	}
+}
+
+// splitOutsideQuotes splits s around sep, ignoring separators inside quoted strings and regular expressions.
+func splitOutsideQuotes(s, sep string) []string {
+	var ret []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '/' && i > 0 && strings.HasSuffix(strings.TrimSpace(s[:i]), "=~"):
+			quote = '/'
+		case strings.HasPrefix(s[i:], sep):
+			ret = append(ret, s[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(ret, s[start:])
+}
+
+// unquote removes single or double quotes around a string literal.
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return "", false
+	}
+	inner := s[1 : len(s)-1]
+	if s[0] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted, true
+		}
+	}
+	return strings.ReplaceAll(inner, `\'`, `'`), true
+}
@@ -0,0 +1,206 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+const orderBody = `{
+	"order": {
+		"id": 42,
+		"express": true,
+		"note": null,
+		"customer": {"name": "Alice", "tags": ["vip", "beta"]},
+		"items": [
+			{"sku": "A1", "price": 9.5, "qty": 2},
+			{"sku": "B2", "price": 20, "qty": 1},
+			{"sku": "C3", "price": 3, "qty": 10, "gift": {"wrap": "red"}}
+		]
+	}
+}`
+
+func jsonPathEndpoint(matchers ...model.JSONPathMatcher) model.Endpoint {
+	return model.Endpoint{Request: model.Request{Body: model.Matcher{MatchesJSONPath: matchers}}}
+}
+
+func Test_BodyMatcherJSONPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchers []model.JSONPathMatcher
+		body     string
+		want     bool
+	}{
+		{
+			name:     "nested field equalTo",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[0].sku", Matcher: model.Matcher{EqualTo: "A1"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "nested field does not match",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[0].sku", Matcher: model.Matcher{EqualTo: "B2"}}},
+			body:     orderBody,
+			want:     false,
+		},
+		{
+			name:     "number is compared as written",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.id", Matcher: model.Matcher{EqualTo: 42}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "boolean",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.express", Matcher: model.Matcher{EqualTo: "true"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "existence only",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.customer.name"}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "null value exists",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.note"}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "missing field",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.coupon"}},
+			body:     orderBody,
+			want:     false,
+		},
+		{
+			name:     "index out of range",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[3]"}},
+			body:     orderBody,
+			want:     false,
+		},
+		{
+			name:     "negative index",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[-1].sku", Matcher: model.Matcher{EqualTo: "C3"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "bracket notation",
+			matchers: []model.JSONPathMatcher{{Expression: "$['order']['customer'][\"name\"]", Matcher: model.Matcher{EqualTo: "Alice"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "any element of a wildcard",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[*].sku", Matcher: model.Matcher{EqualTo: "B2"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "slice",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[1:].sku", Matcher: model.Matcher{EqualTo: "A1"}}},
+			body:     orderBody,
+			want:     false,
+		},
+		{
+			name:     "recursive descent",
+			matchers: []model.JSONPathMatcher{{Expression: "$..wrap", Matcher: model.Matcher{EqualTo: "red"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "filter with comparison",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[?(@.price > 10)].sku", Matcher: model.Matcher{EqualTo: "B2"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "filter with and",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[?(@.price < 10 && @.qty >= 10)].sku", Matcher: model.Matcher{EqualTo: "C3"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "filter with string equality",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[?(@.sku == 'D4')]"}},
+			body:     orderBody,
+			want:     false,
+		},
+		{
+			name:     "filter with existence",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[?(@.gift)].sku", Matcher: model.Matcher{EqualTo: "C3"}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "filter with regular expression",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.customer.tags[?(@ =~ /^VI/i)]"}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name:     "object is matched as compact JSON",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order.items[2].gift", Matcher: model.Matcher{EqualTo: `{"wrap":"red"}`}}},
+			body:     orderBody,
+			want:     true,
+		},
+		{
+			name: "every entry must hold",
+			matchers: []model.JSONPathMatcher{
+				{Expression: "$.order.items[0].sku", Matcher: model.Matcher{EqualTo: "A1"}},
+				{Expression: "$.order.customer.name", Matcher: model.Matcher{Matches: "^B"}},
+			},
+			body: orderBody,
+			want: false,
+		},
+		{
+			name: "nested matchesJsonPath",
+			matchers: []model.JSONPathMatcher{
+				{Expression: "$.order.customer", Matcher: model.Matcher{
+					MatchesJSONPath: []model.JSONPathMatcher{{Expression: "$.tags[1]", Matcher: model.Matcher{EqualTo: "beta"}}},
+				}},
+			},
+			body: orderBody,
+			want: true,
+		},
+		{
+			name:     "body is not JSON",
+			matchers: []model.JSONPathMatcher{{Expression: "$.order"}},
+			body:     "order=1",
+			want:     false,
+		},
+		{
+			name:     "invalid expression",
+			matchers: []model.JSONPathMatcher{{Expression: "order.id"}},
+			body:     orderBody,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonPathEndpoint(tt.matchers...).BodyMatcher(tt.body); got != tt.want {
+				t.Errorf("BodyMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_JSONPathMatcherUnmarshalJSON(t *testing.T) {
+	var got model.Matcher
+	data := `{"matchesJsonPath": ["$.order.id", {"expression": "$.order.items[0].sku", "equalTo": "A1"}]}`
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := model.Matcher{
+		MatchesJSONPath: []model.JSONPathMatcher{
+			{Expression: "$.order.id"},
+			{Expression: "$.order.items[0].sku", Matcher: model.Matcher{EqualTo: "A1"}},
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("diff: %v", cmp.Diff(got, want))
+	}
+}
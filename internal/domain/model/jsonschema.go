@@ -0,0 +1,587 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSchemaDepth stops $ref cycles that never descend into the instance.
+const maxSchemaDepth = 64
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SchemaError is a single reason why a JSON document does not validate against a JSON schema.
+type SchemaError struct {
+	Path    string `json:"path"` // JSONPath of the invalid value, $ for the document itself
+	Message string `json:"message"`
+}
+
+func (e SchemaError) String() string {
+	return e.Path + ": " + e.Message
+}
+
+// matchJSONSchema reports whether the value is a JSON document that validates against matchesJsonSchema.
+func (m Matcher) matchJSONSchema(value string) bool {
+	return len(m.validateJSONSchema(value)) == 0
+}
+
+// JSONSchemaErrors returns why the value does not validate against the JSON schema of the matcher,
+// or of the matchers it combines with and or negates with not. It is empty if the value is valid or there is no schema.
+func (m Matcher) JSONSchemaErrors(value string) []SchemaError {
+	var ret []SchemaError
+	if m.MatchesJSONSchema != nil {
+		ret = append(ret, m.validateJSONSchema(value)...)
+	}
+	for _, sub := range m.And {
+		ret = append(ret, sub.JSONSchemaErrors(value)...)
+	}
+	if m.Not != nil {
+		ret = append(ret, m.Not.JSONSchemaErrors(value)...)
+	}
+	return ret
+}
+
+func (m Matcher) validateJSONSchema(value string) []SchemaError {
+	schema, dir, err := m.jsonSchema()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Invalid matchesJsonSchema: %s", err))
+		return []SchemaError{{Path: "$", Message: "invalid schema: " + err.Error()}}
+	}
+	instance, err := decodeJSON(value)
+	if err != nil {
+		return []SchemaError{{Path: "$", Message: "invalid JSON: " + err.Error()}}
+	}
+	v := schemaValidator{documents: map[string]any{}}
+	return v.validate(schemaRef{schema: schema, root: schema, dir: dir}, instance, "$", 0)
+}
+
+// jsonSchema returns the schema of matchesJsonSchema and the directory that relative $refs are resolved against.
+// A string is the path of a schema file, unless it is the schema itself as JSON text.
+func (m Matcher) jsonSchema() (any, string, error) {
+	switch s := m.MatchesJSONSchema.(type) {
+	case string:
+		if strings.HasPrefix(strings.TrimSpace(s), "{") {
+			v, err := decodeJSON(s)
+			return v, "", err
+		}
+		v, err := loadJSONSchemaFile(s)
+		return v, filepath.Dir(s), err
+	default:
+		b, err := json.Marshal(s)
+		if err != nil {
+			return nil, "", err
+		}
+		v, err := decodeJSON(string(b))
+		return v, "", err
+	}
+}
+
+func loadJSONSchemaFile(path string) (any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(string(b))
+}
+
+// schemaRef is a schema together with the document it belongs to, which its $refs are resolved in.
+type schemaRef struct {
+	schema any
+	root   any
+	dir    string
+}
+
+func (s schemaRef) with(schema any) schemaRef {
+	s.schema = schema
+	return s
+}
+
+type schemaValidator struct {
+	documents map[string]any // schema files referenced with $ref, by path
+}
+
+// validate checks the instance at path against the schema.
+// It supports the keywords of draft-07 and 2020-12 that constrain values; unknown keywords are ignored.
+func (v *schemaValidator) validate(s schemaRef, instance any, path string, depth int) []SchemaError {
+	if depth > maxSchemaDepth {
+		return []SchemaError{{Path: path, Message: "schema nests too deeply, is there a $ref cycle?"}}
+	}
+	switch schema := s.schema.(type) {
+	case bool:
+		if !schema {
+			return []SchemaError{{Path: path, Message: "no value is allowed here"}}
+		}
+		return nil
+	case map[string]any:
+		var errs []SchemaError
+		fail := func(format string, args ...any) {
+			errs = append(errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+		}
+		sub := func(schema any, instance any, path string) []SchemaError {
+			return v.validate(s.with(schema), instance, path, depth+1)
+		}
+
+		// nullable is how OpenAPI 3.0 allows null next to the type
+		if instance == nil && schema["nullable"] == true {
+			return nil
+		}
+		if ref, ok := schema["$ref"].(string); ok {
+			target, err := v.resolve(s, ref)
+			if err != nil {
+				fail("%s", err)
+			} else {
+				errs = append(errs, v.validate(target, instance, path, depth+1)...)
+			}
+		}
+
+		if t, ok := schema["type"]; ok && !matchesType(t, instance) {
+			fail("must be %s, not %s", describeTypes(t), jsonType(instance))
+			// the remaining keywords would only repeat that the type is wrong
+			return errs
+		}
+		if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return jsonEqual(e, instance, false, false) }) {
+			fail("must be one of %s", compactJSON(enum))
+		}
+		if c, ok := schema["const"]; ok && !jsonEqual(c, instance, false, false) {
+			fail("must be %s", compactJSON(c))
+		}
+
+		switch instance := instance.(type) {
+		case string:
+			n := utf8.RuneCountInString(instance)
+			if min, ok := schemaInt(schema["minLength"]); ok && n < min {
+				fail("must be at least %d characters long", min)
+			}
+			if max, ok := schemaInt(schema["maxLength"]); ok && n > max {
+				fail("must be at most %d characters long", max)
+			}
+			if pattern, ok := schema["pattern"].(string); ok {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					fail("invalid pattern %q: %s", pattern, err)
+				} else if !re.MatchString(instance) {
+					fail("must match the pattern %q", pattern)
+				}
+			}
+			if format, ok := schema["format"].(string); ok && !matchesFormat(format, instance) {
+				fail("must be a valid %s", format)
+			}
+		case json.Number:
+			errs = append(errs, validateNumber(schema, instance, path)...)
+		case []any:
+			if min, ok := schemaInt(schema["minItems"]); ok && len(instance) < min {
+				fail("must have at least %d items", min)
+			}
+			if max, ok := schemaInt(schema["maxItems"]); ok && len(instance) > max {
+				fail("must have at most %d items", max)
+			}
+			if unique, _ := schema["uniqueItems"].(bool); unique {
+				for i := range instance {
+					for j := range i {
+						if jsonEqual(instance[j], instance[i], false, false) {
+							fail("items %d and %d must not be equal", j, i)
+						}
+					}
+				}
+			}
+			// prefixItems (2020-12) or items as an array (draft-07) apply by position,
+			// items (2020-12) or additionalItems (draft-07) to the rest
+			prefix, ok := schema["prefixItems"].([]any)
+			rest, hasRest := schema["items"]
+			if tuple, isTuple := rest.([]any); isTuple && !ok {
+				prefix = tuple
+				rest, hasRest = schema["additionalItems"]
+			}
+			for i, item := range instance {
+				itemPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i < len(prefix):
+					errs = append(errs, sub(prefix[i], item, itemPath)...)
+				case hasRest:
+					errs = append(errs, sub(rest, item, itemPath)...)
+				}
+			}
+			if contains, ok := schema["contains"]; ok {
+				n := 0
+				for i, item := range instance {
+					if len(sub(contains, item, fmt.Sprintf("%s[%d]", path, i))) == 0 {
+						n++
+					}
+				}
+				min, ok := schemaInt(schema["minContains"])
+				if !ok {
+					min = 1
+				}
+				if n < min {
+					fail("must contain at least %d matching items, found %d", min, n)
+				}
+				if max, ok := schemaInt(schema["maxContains"]); ok && n > max {
+					fail("must contain at most %d matching items, found %d", max, n)
+				}
+			}
+		case map[string]any:
+			if min, ok := schemaInt(schema["minProperties"]); ok && len(instance) < min {
+				fail("must have at least %d properties", min)
+			}
+			if max, ok := schemaInt(schema["maxProperties"]); ok && len(instance) > max {
+				fail("must have at most %d properties", max)
+			}
+			if required, ok := schema["required"].([]any); ok {
+				for _, r := range required {
+					if name, ok := r.(string); ok {
+						if _, ok := instance[name]; !ok {
+							fail("missing required property %q", name)
+						}
+					}
+				}
+			}
+			properties, _ := schema["properties"].(map[string]any)
+			patterns, _ := schema["patternProperties"].(map[string]any)
+			additional, hasAdditional := schema["additionalProperties"]
+			names, hasNames := schema["propertyNames"]
+			for _, k := range slices.Sorted(maps.Keys(instance)) {
+				value := instance[k]
+				propertyPath := jsonPathMember(path, k)
+				if hasNames {
+					for _, e := range sub(names, k, propertyPath) {
+						fail("property name %q %s", k, e.Message)
+					}
+				}
+				evaluated := false
+				if p, ok := properties[k]; ok {
+					evaluated = true
+					errs = append(errs, sub(p, value, propertyPath)...)
+				}
+				for pattern, p := range patterns {
+					re, err := regexp.Compile(pattern)
+					if err != nil {
+						fail("invalid pattern %q: %s", pattern, err)
+						continue
+					}
+					if re.MatchString(k) {
+						evaluated = true
+						errs = append(errs, sub(p, value, propertyPath)...)
+					}
+				}
+				if !evaluated && hasAdditional {
+					if allowed, ok := additional.(bool); ok && !allowed {
+						errs = append(errs, SchemaError{Path: propertyPath, Message: "additional property is not allowed"})
+					} else {
+						errs = append(errs, sub(additional, value, propertyPath)...)
+					}
+				}
+			}
+			dependentRequired, _ := schema["dependentRequired"].(map[string]any)
+			dependentSchemas, _ := schema["dependentSchemas"].(map[string]any)
+			// draft-07 dependencies holds either kind
+			if dependencies, ok := schema["dependencies"].(map[string]any); ok {
+				dependentRequired = mergeDependencies(dependentRequired, dependencies, true)
+				dependentSchemas = mergeDependencies(dependentSchemas, dependencies, false)
+			}
+			for _, k := range slices.Sorted(maps.Keys(dependentRequired)) {
+				if _, ok := instance[k]; !ok {
+					continue
+				}
+				required, _ := dependentRequired[k].([]any)
+				for _, r := range required {
+					if name, ok := r.(string); ok {
+						if _, ok := instance[name]; !ok {
+							fail("property %q is required when %q is present", name, k)
+						}
+					}
+				}
+			}
+			for _, k := range slices.Sorted(maps.Keys(dependentSchemas)) {
+				if _, ok := instance[k]; ok {
+					errs = append(errs, sub(dependentSchemas[k], instance, path)...)
+				}
+			}
+		}
+
+		if all, ok := schema["allOf"].([]any); ok {
+			for _, a := range all {
+				errs = append(errs, sub(a, instance, path)...)
+			}
+		}
+		if anyOf, ok := schema["anyOf"].([]any); ok &&
+			!slices.ContainsFunc(anyOf, func(a any) bool { return len(sub(a, instance, path)) == 0 }) {
+			fail("must match at least one schema in anyOf")
+		}
+		if oneOf, ok := schema["oneOf"].([]any); ok {
+			n := 0
+			for _, o := range oneOf {
+				if len(sub(o, instance, path)) == 0 {
+					n++
+				}
+			}
+			if n != 1 {
+				fail("must match exactly one schema in oneOf, matched %d", n)
+			}
+		}
+		if not, ok := schema["not"]; ok && len(sub(not, instance, path)) == 0 {
+			fail("must not match the schema in not")
+		}
+		if cond, ok := schema["if"]; ok {
+			if len(sub(cond, instance, path)) == 0 {
+				if then, ok := schema["then"]; ok {
+					errs = append(errs, sub(then, instance, path)...)
+				}
+			} else if els, ok := schema["else"]; ok {
+				errs = append(errs, sub(els, instance, path)...)
+			}
+		}
+		return errs
+	default:
+		return []SchemaError{{Path: path, Message: "invalid schema: must be an object or a boolean"}}
+	}
+}
+
+func validateNumber(schema map[string]any, n json.Number, path string) []SchemaError {
+	var errs []SchemaError
+	x, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return []SchemaError{{Path: path, Message: "invalid number " + n.String()}}
+	}
+	fail := func(format string, args ...any) {
+		errs = append(errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	// draft-04 writes exclusive bounds as booleans next to minimum and maximum
+	exclusiveMin, _ := schema["exclusiveMinimum"].(bool)
+	exclusiveMax, _ := schema["exclusiveMaximum"].(bool)
+	if min, ok := schemaRat(schema["minimum"]); ok {
+		if exclusiveMin && x.Cmp(min) <= 0 {
+			fail("must be greater than %s", min.RatString())
+		} else if x.Cmp(min) < 0 {
+			fail("must be greater than or equal to %s", min.RatString())
+		}
+	}
+	if max, ok := schemaRat(schema["maximum"]); ok {
+		if exclusiveMax && x.Cmp(max) >= 0 {
+			fail("must be less than %s", max.RatString())
+		} else if x.Cmp(max) > 0 {
+			fail("must be less than or equal to %s", max.RatString())
+		}
+	}
+	if min, ok := schemaRat(schema["exclusiveMinimum"]); ok && x.Cmp(min) <= 0 {
+		fail("must be greater than %s", min.RatString())
+	}
+	if max, ok := schemaRat(schema["exclusiveMaximum"]); ok && x.Cmp(max) >= 0 {
+		fail("must be less than %s", max.RatString())
+	}
+	if m, ok := schemaRat(schema["multipleOf"]); ok && m.Sign() > 0 && !new(big.Rat).Quo(x, m).IsInt() {
+		fail("must be a multiple of %s", m.RatString())
+	}
+	return errs
+}
+
+// resolve follows a $ref to a JSON pointer in the same document ("#/$defs/user")
+// or to a schema file relative to the referencing one ("user.json#/properties/name").
+func (v *schemaValidator) resolve(s schemaRef, ref string) (schemaRef, error) {
+	file, pointer, _ := strings.Cut(ref, "#")
+	if file != "" {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.dir, path)
+		}
+		doc, ok := v.documents[path]
+		if !ok {
+			var err error
+			doc, err = loadJSONSchemaFile(path)
+			if err != nil {
+				return schemaRef{}, fmt.Errorf("cannot resolve $ref %q: %s", ref, err)
+			}
+			v.documents[path] = doc
+		}
+		s = schemaRef{root: doc, dir: filepath.Dir(path)}
+	}
+	target := s.root
+	if pointer != "" {
+		if !strings.HasPrefix(pointer, "/") {
+			return schemaRef{}, fmt.Errorf("unsupported $ref %q: only JSON pointers are supported", ref)
+		}
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token, err := url.PathUnescape(token)
+			if err != nil {
+				return schemaRef{}, fmt.Errorf("invalid $ref %q: %s", ref, err)
+			}
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			switch node := target.(type) {
+			case map[string]any:
+				next, ok := node[token]
+				if !ok {
+					return schemaRef{}, fmt.Errorf("cannot resolve $ref %q", ref)
+				}
+				target = next
+			case []any:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(node) {
+					return schemaRef{}, fmt.Errorf("cannot resolve $ref %q", ref)
+				}
+				target = node[i]
+			default:
+				return schemaRef{}, fmt.Errorf("cannot resolve $ref %q", ref)
+			}
+		}
+	}
+	return s.with(target), nil
+}
+
+func mergeDependencies(dst, dependencies map[string]any, required bool) map[string]any {
+	for k, d := range dependencies {
+		if _, isList := d.([]any); isList != required {
+			continue
+		}
+		if dst == nil {
+			dst = map[string]any{}
+		}
+		dst[k] = d
+	}
+	return dst
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if isInteger(v) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func isInteger(n json.Number) bool {
+	x, ok := new(big.Rat).SetString(n.String())
+	return ok && x.IsInt()
+}
+
+// matchesType checks the type keyword, which is a type name or a list of them.
+func matchesType(t any, instance any) bool {
+	names, ok := t.([]any)
+	if !ok {
+		names = []any{t}
+	}
+	actual := jsonType(instance)
+	for _, name := range names {
+		if name == actual || name == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func describeTypes(t any) string {
+	names, ok := t.([]any)
+	if !ok {
+		return fmt.Sprint(t)
+	}
+	s := make([]string, 0, len(names))
+	for _, n := range names {
+		s = append(s, fmt.Sprint(n))
+	}
+	return strings.Join(s, " or ")
+}
+
+// matchesFormat checks the formats that are commonly used in API contracts. Other formats are not checked.
+func matchesFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05.999999999Z07:00", s)
+		return err == nil
+	case "email":
+		a, err := mail.ParseAddress(s)
+		return err == nil && a.Address == s
+	case "uuid":
+		return uuidPattern.MatchString(s)
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	case "regex":
+		_, err := regexp.Compile(s)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+func schemaInt(v any) (int, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(n.String())
+	if err != nil {
+		f, err := n.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return int(f), true
+	}
+	return i, true
+}
+
+func schemaRat(v any) (*big.Rat, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetString(n.String())
+}
+
+// jsonPathMember appends a member name to a JSONPath, in bracket notation if it is not a plain name.
+func jsonPathMember(path, name string) string {
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return path + "['" + strings.ReplaceAll(name, "'", `\'`) + "']"
+		}
+	}
+	if name == "" {
+		return path + "['']"
+	}
+	return path + "." + name
+}
+
+func compactJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,146 @@
+package model_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_BodyMatcherJSONSchema(t *testing.T) {
+	user := `{
+		"type": "object",
+		"required": ["name", "email"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 20},
+			"email": {"type": "string", "format": "email"},
+			"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"role": {"enum": ["admin", "member"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3}
+		},
+		"additionalProperties": false
+	}`
+	tests := []struct {
+		name   string
+		schema string
+		body   string
+		want   bool
+	}{
+		{name: "valid", schema: user, body: `{"name": "Alice", "email": "alice@example.com", "age": 30, "tags": ["a", "b"]}`, want: true},
+		{name: "missing required property", schema: user, body: `{"name": "Alice"}`, want: false},
+		{name: "wrong type", schema: user, body: `{"name": 1, "email": "alice@example.com"}`, want: false},
+		{name: "integer written as a float", schema: user, body: `{"name": "Alice", "email": "alice@example.com", "age": 30.0}`, want: true},
+		{name: "not an integer", schema: user, body: `{"name": "Alice", "email": "alice@example.com", "age": 30.5}`, want: false},
+		{name: "exclusive maximum", schema: user, body: `{"name": "Alice", "email": "alice@example.com", "age": 150}`, want: false},
+		{name: "format", schema: user, body: `{"name": "Alice", "email": "alice"}`, want: false},
+		{name: "enum", schema: user, body: `{"name": "Alice", "email": "alice@example.com", "role": "owner"}`, want: false},
+		{name: "additional property", schema: user, body: `{"name": "Alice", "email": "alice@example.com", "admin": true}`, want: false},
+		{name: "unique items", schema: user, body: `{"name": "Alice", "email": "alice@example.com", "tags": ["a", "a"]}`, want: false},
+		{name: "not JSON", schema: user, body: `name=Alice`, want: false},
+		{name: "empty body", schema: user, body: ``, want: false},
+		{name: "min length counts characters", schema: `{"maxLength": 2}`, body: `"日本"`, want: true},
+		{name: "pattern", schema: `{"pattern": "^[A-Z]{3}$"}`, body: `"abc"`, want: false},
+		{name: "multipleOf", schema: `{"multipleOf": 0.01}`, body: `19.99`, want: true},
+		{name: "not a multiple", schema: `{"multipleOf": 0.01}`, body: `19.995`, want: false},
+		{name: "draft-04 exclusive minimum", schema: `{"minimum": 0, "exclusiveMinimum": true}`, body: `0`, want: false},
+		{name: "type list", schema: `{"type": ["string", "null"]}`, body: `null`, want: true},
+		{name: "const", schema: `{"const": {"a": [1, 2]}}`, body: `{"a": [1, 2.0]}`, want: true},
+		{name: "boolean schema", schema: `{"properties": {"id": false}}`, body: `{"id": 1}`, want: false},
+		{name: "local $ref", schema: `{"$defs": {"id": {"type": "integer"}}, "properties": {"id": {"$ref": "#/$defs/id"}}}`, body: `{"id": "1"}`, want: false},
+		{name: "draft-07 definitions", schema: `{"definitions": {"id": {"type": "integer"}}, "items": {"$ref": "#/definitions/id"}}`, body: `[1, 2, 3]`, want: true},
+		{name: "unresolvable $ref", schema: `{"$ref": "#/$defs/missing"}`, body: `{}`, want: false},
+		{name: "$ref cycle", schema: `{"$ref": "#"}`, body: `{}`, want: false},
+		{name: "prefixItems", schema: `{"prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false}`, body: `["a", 1]`, want: true},
+		{name: "items after prefixItems", schema: `{"prefixItems": [{"type": "string"}], "items": false}`, body: `["a", 1]`, want: false},
+		{name: "draft-07 tuple", schema: `{"items": [{"type": "string"}], "additionalItems": {"type": "integer"}}`, body: `["a", 1, 2]`, want: true},
+		{name: "contains", schema: `{"contains": {"const": "admin"}}`, body: `["member", "admin"]`, want: true},
+		{name: "maxContains", schema: `{"contains": {"const": 1}, "maxContains": 1}`, body: `[1, 1]`, want: false},
+		{name: "patternProperties", schema: `{"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}`, body: `{"x-trace": "1"}`, want: true},
+		{name: "propertyNames", schema: `{"propertyNames": {"pattern": "^[a-z]+$"}}`, body: `{"Name": 1}`, want: false},
+		{name: "dependentRequired", schema: `{"dependentRequired": {"card": ["cvc"]}}`, body: `{"card": "4111"}`, want: false},
+		{name: "draft-07 dependencies", schema: `{"dependencies": {"card": {"required": ["cvc"]}}}`, body: `{"card": "4111", "cvc": "123"}`, want: true},
+		{name: "anyOf", schema: `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, body: `1.5`, want: false},
+		{name: "oneOf matching both", schema: `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, body: `1`, want: false},
+		{name: "not", schema: `{"not": {"type": "null"}}`, body: `0`, want: true},
+		{name: "if then else", schema: `{"if": {"properties": {"type": {"const": "card"}}}, "then": {"required": ["number"]}, "else": {"required": ["iban"]}}`, body: `{"type": "bank", "iban": "DE00"}`, want: true},
+		{name: "then fails", schema: `{"if": {"properties": {"type": {"const": "card"}}}, "then": {"required": ["number"]}}`, body: `{"type": "card"}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema any
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+			e := model.Endpoint{Request: model.Request{Body: model.Matcher{MatchesJSONSchema: schema}}}
+			if got := e.BodyMatcher(tt.body); got != tt.want {
+				t.Errorf("BodyMatcher() = %v, want %v", got, tt.want)
+			}
+			// the same schema written as JSON text
+			e = model.Endpoint{Request: model.Request{Body: model.Matcher{MatchesJSONSchema: tt.schema}}}
+			if tt.schema[0] == '{' {
+				if got := e.BodyMatcher(tt.body); got != tt.want {
+					t.Errorf("BodyMatcher() with a JSON string = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_JSONSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "common"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"order.json":          `{"type": "object", "required": ["id", "items"], "properties": {"id": {"$ref": "common/types.json#/$defs/id"}, "items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}}}, "$defs": {"item": {"required": ["sku"], "properties": {"sku": {"type": "string"}, "qty": {"$ref": "common/types.json#/$defs/quantity"}}}}}`,
+		"common/types.json":   `{"$defs": {"id": {"type": "string", "format": "uuid"}, "quantity": {"$ref": "numbers.json#/$defs/positive"}}}`,
+		"common/numbers.json": `{"$defs": {"positive": {"type": "integer", "minimum": 1}}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := model.Matcher{MatchesJSONSchema: filepath.Join(dir, "order.json")}
+
+	tests := []struct {
+		name string
+		body string
+		want []model.SchemaError
+	}{
+		{
+			name: "valid",
+			body: `{"id": "0b7a3e36-7c55-4cb1-9f4e-8f4b3b3c3f10", "items": [{"sku": "A1", "qty": 2}]}`,
+			want: nil,
+		},
+		{
+			name: "errors with the path of the invalid values",
+			body: `{"id": "1", "items": [{"sku": "A1", "qty": 0}, {"qty": 1}]}`,
+			want: []model.SchemaError{
+				{Path: "$.id", Message: "must be a valid uuid"},
+				{Path: "$.items[0].qty", Message: "must be greater than or equal to 1"},
+				{Path: "$.items[1]", Message: `missing required property "sku"`},
+			},
+		},
+		{
+			name: "missing property",
+			body: `{"id": "0b7a3e36-7c55-4cb1-9f4e-8f4b3b3c3f10"}`,
+			want: []model.SchemaError{{Path: "$", Message: `missing required property "items"`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, m.JSONSchemaErrors(tt.body)); diff != "" {
+				t.Errorf("JSONSchemaErrors() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	missing := model.Matcher{MatchesJSONSchema: filepath.Join(dir, "missing.json")}
+	if errs := missing.JSONSchemaErrors(`{}`); len(errs) != 1 {
+		t.Errorf("JSONSchemaErrors() with a missing schema file = %v, want one error", errs)
+	}
+}
@@ -0,0 +1,251 @@
+package model
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // SHA-256 for HS256, RS256, PS256 and ES256
+	_ "crypto/sha512" // SHA-384 and SHA-512 for the other algorithms
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+const defaultJWTHeader = "Authorization"
+
+// JWTMatcher decodes a JSON Web Token from a request header and matches its header and claims.
+// Array values such as aud are matched as repeated values, anything other than a string as compact JSON.
+type JWTMatcher struct {
+	Header  string             `json:"header,omitempty"`  // トークンを含むヘッダー。デフォルトはAuthorization。"Bearer "は取り除かれる
+	KeyFile string             `json:"keyFile,omitempty"` // 指定されている場合は署名を検証する。HS*は共有鍵のファイル、それ以外はPEM形式の公開鍵または証明書
+	Headers map[string]Matcher `json:"headers,omitempty"` // JOSEヘッダー (alg, kid, typなど)
+	Claims  map[string]Matcher `json:"claims,omitempty"`
+
+	// 署名の検証で許可するアルゴリズム。省略時は鍵の種類に合うものすべて
+	Algorithms []string `json:"algorithms,omitempty"`
+}
+
+// JWT is a decoded JSON Web Token. Numbers are kept as json.Number.
+type JWT struct {
+	Header map[string]any
+	Claims map[string]any
+
+	signingInput string
+	signature    []byte
+}
+
+// ParseJWT decodes a compact JWS without verifying its signature.
+func ParseJWT(token string) (JWT, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return JWT{}, fmt.Errorf("JWT must have three parts")
+	}
+	var ret JWT
+	for i, dst := range []*map[string]any{&ret.Header, &ret.Claims} {
+		b, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return JWT{}, fmt.Errorf("invalid JWT encoding: %w", err)
+		}
+		v, err := decodeJSON(string(b))
+		if err != nil {
+			return JWT{}, fmt.Errorf("invalid JWT JSON: %w", err)
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			return JWT{}, fmt.Errorf("JWT header and claims must be JSON objects")
+		}
+		*dst = m
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return JWT{}, fmt.Errorf("invalid JWT signature encoding: %w", err)
+	}
+	ret.signingInput = parts[0] + "." + parts[1]
+	ret.signature = sig
+	return ret, nil
+}
+
+// RequestJWT decodes the token in the header without verifying it. The Bearer scheme is optional.
+func RequestJWT(headers map[string][]string, header string) (JWT, bool) {
+	if header == "" {
+		header = defaultJWTHeader
+	}
+	values := headers[header]
+	if len(values) == 0 {
+		return JWT{}, false
+	}
+	token := strings.TrimSpace(values[0])
+	if scheme, rest, found := strings.Cut(token, " "); found && strings.EqualFold(scheme, "Bearer") {
+		token = strings.TrimSpace(rest)
+	}
+	t, err := ParseJWT(token)
+	if err != nil {
+		return JWT{}, false
+	}
+	return t, true
+}
+
+// JWTMatcher matches the token of the request against the jwt matcher.
+// key is the content of keyFile and is only used when keyFile is set.
+// The returned claims are decoded from the Authorization header when the endpoint has no jwt matcher.
+func (endpoint Endpoint) JWTMatcher(headers map[string][]string, key []byte) (bool, map[string]any) {
+	m := endpoint.Request.JWT
+	if m == nil {
+		t, _ := RequestJWT(headers, defaultJWTHeader)
+		return true, t.Claims
+	}
+	t, ok := RequestJWT(headers, m.Header)
+	if !ok {
+		return false, nil
+	}
+	if m.KeyFile != "" {
+		if err := t.Verify(key, m.Algorithms); err != nil {
+			return false, nil
+		}
+	}
+	for _, fields := range []struct {
+		matchers map[string]Matcher
+		values   map[string]any
+	}{
+		{m.Headers, t.Header},
+		{m.Claims, t.Claims},
+	} {
+		for k, v := range fields.matchers {
+			if !v.matchValues(jwtValues(fields.values[k])) {
+				return false, nil
+			}
+		}
+	}
+	return true, t.Claims
+}
+
+func jwtValues(v any) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		ret := make([]string, 0, len(v))
+		for _, e := range v {
+			ret = append(ret, jsonValueString(e))
+		}
+		return ret
+	default:
+		return []string{jsonValueString(v)}
+	}
+}
+
+// Verify checks the signature with a shared secret (HS256, HS384, HS512)
+// or a PEM encoded public key or certificate (RS*, PS*, ES* and EdDSA).
+// The key decides the family of algorithms, so that a token cannot be signed with HS256
+// and the public key as the secret. algorithms further limits the accepted ones when it is not empty.
+func (t JWT) Verify(key []byte, algorithms []string) error {
+	if len(key) == 0 {
+		return errors.New("no key to verify the JWT with")
+	}
+	alg, _ := t.Header["alg"].(string)
+	if len(algorithms) > 0 && !slices.Contains(algorithms, alg) {
+		return fmt.Errorf("JWT algorithm %q is not one of %s", alg, strings.Join(algorithms, ", "))
+	}
+	if block, _ := pem.Decode(key); block != nil && strings.HasPrefix(alg, "HS") {
+		return fmt.Errorf("%s cannot be verified with a PEM key", alg)
+	}
+	var hash crypto.Hash
+	switch {
+	case strings.HasSuffix(alg, "256"):
+		hash = crypto.SHA256
+	case strings.HasSuffix(alg, "384"):
+		hash = crypto.SHA384
+	case strings.HasSuffix(alg, "512"):
+		hash = crypto.SHA512
+	}
+	input := []byte(t.signingInput)
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(input)
+		digest = h.Sum(nil)
+	}
+
+	if strings.HasPrefix(alg, "HS") && hash != 0 {
+		mac := hmac.New(hash.New, bytes.TrimRight(key, "\r\n"))
+		mac.Write(input)
+		if !hmac.Equal(mac.Sum(nil), t.signature) {
+			return errors.New("invalid JWT signature")
+		}
+		return nil
+	}
+	pub, err := publicKey(key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case strings.HasPrefix(alg, "RS") && hash != 0:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(k, hash, digest, t.signature)
+	case strings.HasPrefix(alg, "PS") && hash != 0:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an RSA key", alg)
+		}
+		return rsa.VerifyPSS(k, hash, digest, t.signature, nil)
+	case strings.HasPrefix(alg, "ES") && hash != 0:
+		k, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an ECDSA key", alg)
+		}
+		// the signature is r and s, each padded to the size of the curve
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return errors.New("invalid JWT signature")
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid JWT signature")
+		}
+		return nil
+	case alg == "EdDSA":
+		k, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an Ed25519 key", alg)
+		}
+		if !ed25519.Verify(k, input, t.signature) {
+			return errors.New("invalid JWT signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported JWT algorithm: %q", alg)
+	}
+}
+
+func publicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("key file is not PEM encoded")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block: %s", block.Type)
+	}
+}
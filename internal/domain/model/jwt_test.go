@@ -0,0 +1,263 @@
+package model_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// signJWT returns a compact JWS of claims signed by sign.
+func signJWT(t *testing.T, alg string, claims map[string]any, sign func(input []byte) []byte) string {
+	t.Helper()
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	input := enc(map[string]any{"alg": alg, "typ": "JWT", "kid": "k1"}) + "." + enc(claims)
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(input)))
+}
+
+func hs256(secret string) func([]byte) []byte {
+	return func(input []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(input)
+		return mac.Sum(nil)
+	}
+}
+
+// withSignatureOf replaces the signature of token with the one of other.
+func withSignatureOf(token, other string) string {
+	return token[:strings.LastIndex(token, ".")] + other[strings.LastIndex(other, "."):]
+}
+
+func publicKeyPEM(t *testing.T, pub crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func Test_JWTMatcher(t *testing.T) {
+	claims := map[string]any{
+		"sub":   "user-1",
+		"scope": "read write",
+		"aud":   []string{"api", "admin"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": map[string]any{"admin": true},
+	}
+	token := signJWT(t, "HS256", claims, hs256("secret"))
+	bearer := map[string][]string{"Authorization": {"Bearer " + token}}
+
+	tests := []struct {
+		name    string
+		matcher *model.JWTMatcher
+		headers map[string][]string
+		key     []byte
+		want    bool
+	}{
+		{
+			name:    "no jwt matcher",
+			matcher: nil,
+			headers: map[string][]string{},
+			want:    true,
+		},
+		{
+			name:    "claims match without verification",
+			matcher: &model.JWTMatcher{Claims: map[string]model.Matcher{"sub": {EqualTo: "user-1"}, "scope": {Contains: "write"}}},
+			headers: bearer,
+			want:    true,
+		},
+		{
+			name:    "claim does not match",
+			matcher: &model.JWTMatcher{Claims: map[string]model.Matcher{"sub": {EqualTo: "user-2"}}},
+			headers: bearer,
+			want:    false,
+		},
+		{
+			name:    "array claim",
+			matcher: &model.JWTMatcher{Claims: map[string]model.Matcher{"aud": {Includes: []model.Matcher{{EqualTo: "admin"}}}}},
+			headers: bearer,
+			want:    true,
+		},
+		{
+			name:    "exp in the future",
+			matcher: &model.JWTMatcher{Claims: map[string]model.Matcher{"exp": {After: "now"}}},
+			headers: bearer,
+			want:    true,
+		},
+		{
+			name:    "exp as a number",
+			matcher: &model.JWTMatcher{Claims: map[string]model.Matcher{"exp": {GreaterThan: float(float64(time.Now().Unix()))}}},
+			headers: bearer,
+			want:    true,
+		},
+		{
+			name:    "object claim",
+			matcher: &model.JWTMatcher{Claims: map[string]model.Matcher{"roles": {MatchesJSONPath: []model.JSONPathMatcher{{Expression: "$.admin"}}}}},
+			headers: bearer,
+			want:    true,
+		},
+		{
+			name:    "absent claim",
+			matcher: &model.JWTMatcher{Claims: map[string]model.Matcher{"email": {Absent: true}}},
+			headers: bearer,
+			want:    true,
+		},
+		{
+			name:    "jose header",
+			matcher: &model.JWTMatcher{Headers: map[string]model.Matcher{"alg": {EqualTo: "HS256"}, "kid": {EqualTo: "k1"}}},
+			headers: bearer,
+			want:    true,
+		},
+		{
+			name:    "custom header without bearer",
+			matcher: &model.JWTMatcher{Header: "X-Token", Claims: map[string]model.Matcher{"sub": {EqualTo: "user-1"}}},
+			headers: map[string][]string{"X-Token": {token}},
+			want:    true,
+		},
+		{
+			name:    "no token",
+			matcher: &model.JWTMatcher{},
+			headers: map[string][]string{},
+			want:    false,
+		},
+		{
+			name:    "not a jwt",
+			matcher: &model.JWTMatcher{},
+			headers: map[string][]string{"Authorization": {"Bearer abc"}},
+			want:    false,
+		},
+		{
+			name:    "verified with the shared secret",
+			matcher: &model.JWTMatcher{KeyFile: "secret.key"},
+			headers: bearer,
+			key:     []byte("secret\n"),
+			want:    true,
+		},
+		{
+			name:    "wrong shared secret",
+			matcher: &model.JWTMatcher{KeyFile: "secret.key"},
+			headers: bearer,
+			key:     []byte("other"),
+			want:    false,
+		},
+		{
+			name:    "key file could not be read",
+			matcher: &model.JWTMatcher{KeyFile: "secret.key"},
+			headers: bearer,
+			key:     nil,
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{JWT: tt.matcher}}
+			got, gotClaims := e.JWTMatcher(tt.headers, tt.key)
+			if got != tt.want {
+				t.Errorf("JWTMatcher() = %v, want %v", got, tt.want)
+			}
+			if got && len(tt.headers) > 0 && gotClaims["sub"] != "user-1" {
+				t.Errorf("JWTMatcher() claims = %v, want sub user-1", gotClaims)
+			}
+		})
+	}
+}
+
+func Test_JWTVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := func(input []byte) []byte {
+		h := sha256.Sum256(input)
+		return h[:]
+	}
+	rs256 := func(input []byte) []byte {
+		sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	ps256 := func(input []byte) []byte {
+		sig, err := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest(input), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	es256 := func(input []byte) []byte {
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+	eddsa := func(input []byte) []byte {
+		return ed25519.Sign(edKey, input)
+	}
+	claims := map[string]any{"sub": "user-1"}
+	rsaPEM := publicKeyPEM(t, &rsaKey.PublicKey)
+
+	tests := []struct {
+		name       string
+		token      string
+		key        []byte
+		algorithms []string
+		wantErr    bool
+	}{
+		{name: "HS256", token: signJWT(t, "HS256", claims, hs256("secret")), key: []byte("secret")},
+		{name: "RS256", token: signJWT(t, "RS256", claims, rs256), key: publicKeyPEM(t, &rsaKey.PublicKey)},
+		{name: "PS256", token: signJWT(t, "PS256", claims, ps256), key: publicKeyPEM(t, &rsaKey.PublicKey)},
+		{name: "ES256", token: signJWT(t, "ES256", claims, es256), key: publicKeyPEM(t, &ecKey.PublicKey)},
+		{name: "EdDSA", token: signJWT(t, "EdDSA", claims, eddsa), key: publicKeyPEM(t, edPub)},
+		{name: "RS256 with the wrong key type", token: signJWT(t, "RS256", claims, rs256), key: publicKeyPEM(t, &ecKey.PublicKey), wantErr: true},
+		{name: "signature of other claims", token: withSignatureOf(signJWT(t, "ES256", claims, es256), signJWT(t, "ES256", map[string]any{"sub": "user-2"}, es256)), key: publicKeyPEM(t, &ecKey.PublicKey), wantErr: true},
+		{name: "key is not PEM", token: signJWT(t, "RS256", claims, rs256), key: []byte("secret"), wantErr: true},
+		{name: "HS256 signed with the public key as the secret", token: signJWT(t, "HS256", claims, hs256(strings.TrimRight(string(rsaPEM), "\n"))), key: rsaPEM, wantErr: true},
+		{name: "allowed algorithm", token: signJWT(t, "RS256", claims, rs256), key: rsaPEM, algorithms: []string{"RS256", "ES256"}},
+		{name: "algorithm not allowed", token: signJWT(t, "PS256", claims, ps256), key: rsaPEM, algorithms: []string{"RS256"}, wantErr: true},
+		{name: "unsigned token", token: signJWT(t, "none", claims, func([]byte) []byte { return nil }), key: []byte("secret"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwt, err := model.ParseJWT(tt.token)
+			if err != nil {
+				t.Fatalf("ParseJWT() error = %v", err)
+			}
+			if err := jwt.Verify(tt.key, tt.algorithms); (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
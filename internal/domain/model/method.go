@@ -0,0 +1,110 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MethodAny matches requests of any method.
+const MethodAny = "ANY"
+
+// MethodMatcher matches the HTTP method of the request. In JSON it is written as a method, "ANY",
+// a list of methods or a matcher, and converted into a Matcher when the endpoint is loaded.
+// The zero value matches any method.
+type MethodMatcher struct {
+	Matcher
+}
+
+// Method returns a MethodMatcher that accepts any of the methods.
+// Without methods, or with "ANY", it matches any method.
+func Method(methods ...string) MethodMatcher {
+	switch {
+	case len(methods) == 0 || len(methods) == 1 && (methods[0] == "" || strings.EqualFold(methods[0], MethodAny)):
+		return MethodMatcher{}
+	case len(methods) == 1:
+		return MethodMatcher{Matcher{EqualTo: methods[0]}}
+	}
+	m := MethodMatcher{Matcher{Or: make([]Matcher, 0, len(methods))}}
+	for _, s := range methods {
+		m.Or = append(m.Or, Matcher{EqualTo: s})
+	}
+	return m
+}
+
+func (m *MethodMatcher) UnmarshalJSON(data []byte) error {
+	switch bytes.TrimSpace(data)[0] {
+	case '"':
+		var method string
+		if err := json.Unmarshal(data, &method); err != nil {
+			return err
+		}
+		*m = Method(method)
+		return nil
+	case '[':
+		var methods []string
+		if err := json.Unmarshal(data, &methods); err != nil {
+			return fmt.Errorf("methods must be strings: %s", data)
+		}
+		*m = Method(methods...)
+		return nil
+	case '{':
+		// Matcher has no UnmarshalJSON of its own, so the default decoding applies
+		return json.Unmarshal(data, &m.Matcher)
+	case 'n':
+		*m = MethodMatcher{}
+		return nil
+	default:
+		return fmt.Errorf("method must be a string, an array of strings or a matcher: %s", data)
+	}
+}
+
+// MarshalJSON writes the matcher back in the form it was written in: a method, a list of methods or a matcher.
+func (m MethodMatcher) MarshalJSON() ([]byte, error) {
+	if m.IsZero() {
+		return json.Marshal(MethodAny)
+	}
+	if methods, ok := m.methods(); ok {
+		if len(methods) == 1 && m.Or == nil {
+			return json.Marshal(methods[0])
+		}
+		return json.Marshal(methods)
+	}
+	return json.Marshal(m.Matcher)
+}
+
+// IsZero reports whether the matcher accepts any method. It lets the method be omitted from JSON.
+func (m MethodMatcher) IsZero() bool {
+	return m.isZero()
+}
+
+// methods returns the methods of a matcher created by Method, reporting false for other matchers.
+func (m MethodMatcher) methods() ([]string, bool) {
+	if s, ok := m.EqualTo.(string); ok && reflect.DeepEqual(Matcher{EqualTo: s}, m.Matcher) {
+		return []string{s}, true
+	}
+	if len(m.Or) == 0 || !reflect.DeepEqual(Matcher{Or: m.Or}, m.Matcher) {
+		return nil, false
+	}
+	methods := make([]string, 0, len(m.Or))
+	for _, sub := range m.Or {
+		s, ok := sub.EqualTo.(string)
+		if !ok || !reflect.DeepEqual(Matcher{EqualTo: s}, sub) {
+			return nil, false
+		}
+		methods = append(methods, s)
+	}
+	return methods, true
+}
+
+// MethodMatcher matches the HTTP method of the request. An endpoint without a method matches any method.
+func (endpoint Endpoint) MethodMatcher(method string) bool {
+	return endpoint.Request.Method.matchValue(method, method != "")
+}
+
+// MatchesAnyMethod reports whether the request matcher accepts every method.
+func (r Request) MatchesAnyMethod() bool {
+	return r.Method.IsZero()
+}
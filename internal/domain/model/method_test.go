@@ -0,0 +1,95 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_MethodMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string // JSON
+		got     string
+		want    bool
+		wantErr bool // when unmarshalling
+	}{
+		{name: "single method", method: `"GET"`, got: "GET", want: true},
+		{name: "other method", method: `"GET"`, got: "POST", want: false},
+		{name: "methods are case-sensitive", method: `"GET"`, got: "get", want: false},
+		{name: "ANY", method: `"ANY"`, got: "DELETE", want: true},
+		{name: "no method", method: ``, got: "PATCH", want: true},
+		{name: "empty method", method: `""`, got: "PATCH", want: true},
+		{name: "list of methods", method: `["PUT", "PATCH"]`, got: "PATCH", want: true},
+		{name: "not in the list", method: `["PUT", "PATCH"]`, got: "POST", want: false},
+		{name: "matcher", method: `{"matches": "^(GET|HEAD)$"}`, got: "HEAD", want: true},
+		{name: "negated matcher", method: `{"not": {"equalTo": "DELETE"}}`, got: "DELETE", want: false},
+		{name: "invalid method", method: `42`, wantErr: true},
+		{name: "invalid list", method: `["GET", 1]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r model.Request
+			if tt.method != "" {
+				err := json.Unmarshal([]byte(`{"method": `+tt.method+`}`), &r)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("json.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if tt.wantErr {
+					return
+				}
+			}
+			e := model.Endpoint{Request: r}
+			if got := e.MethodMatcher(tt.got); got != tt.want {
+				t.Errorf("MethodMatcher(%q) = %v, want %v", tt.got, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MethodMatcherGo(t *testing.T) {
+	tests := []struct {
+		name   string
+		method model.MethodMatcher
+		got    string
+		want   bool
+	}{
+		{name: "method", method: model.Method("POST"), got: "POST", want: true},
+		{name: "methods", method: model.Method("GET", "HEAD"), got: "HEAD", want: true},
+		{name: "ANY", method: model.Method(model.MethodAny), got: "PUT", want: true},
+		{name: "matcher", method: model.MethodMatcher{Matcher: model.Matcher{Contains: "P"}}, got: "GET", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{Method: tt.method}}
+			if got := e.MethodMatcher(tt.got); got != tt.want {
+				t.Errorf("MethodMatcher(%q) = %v, want %v", tt.got, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MethodMatcherMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		request model.Request
+		want    string
+	}{
+		{name: "no method", request: model.Request{}, want: `{}`},
+		{name: "method", request: model.Request{Method: model.Method("GET")}, want: `{"method":"GET"}`},
+		{name: "methods", request: model.Request{Method: model.Method("PUT", "PATCH")}, want: `{"method":["PUT","PATCH"]}`},
+		{name: "matcher", request: model.Request{Method: model.MethodMatcher{Matcher: model.Matcher{Matches: "^P"}}}, want: `{"method":{"matches":"^P"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.request)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+)
+
+// CountMatcher is applied to the number of values of a repeated query parameter or header.
+// In JSON it can also be written as a plain number, which is the same as equalTo.
+type CountMatcher struct {
+	Matcher
+}
+
+func (m *CountMatcher) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*m = CountMatcher{Matcher{EqualTo: n}}
+		return nil
+	}
+	// Matcher has no UnmarshalJSON of its own, so the default decoding applies
+	return json.Unmarshal(data, &m.Matcher)
+}
+
+// matchMultiValue applies hasExactly, includes and count.
+func (m Matcher) matchMultiValue(values []string) bool {
+	if len(m.HasExactly) > 0 && (len(values) != len(m.HasExactly) || !matchDistinct(m.HasExactly, values)) {
+		return false
+	}
+	for _, inc := range m.Includes {
+		if !slices.ContainsFunc(values, func(v string) bool { return inc.matchValue(v, true) }) {
+			return false
+		}
+	}
+	if m.Count != nil && !m.Count.matchValue(strconv.Itoa(len(values)), true) {
+		return false
+	}
+	return true
+}
+
+// matchDistinct reports whether every matcher can be paired with a distinct value that satisfies it.
+func matchDistinct(matchers []Matcher, values []string) bool {
+	return pairDistinct(len(matchers), len(values), func(i, j int) bool { return matchers[i].matchValue(values[j], true) })
+}
+
+// pairDistinct reports whether each of n items can be paired with a distinct one of m candidates,
+// where matches(i, j) tells whether item i may take candidate j. It is a bipartite matching by augmenting paths,
+// so that the time grows polynomially instead of trying every pairing, and matches is called once per pair.
+func pairDistinct(n, m int, matches func(i, j int) bool) bool {
+	if m < n {
+		return false
+	}
+	candidates := make([][]int, n)
+	for i := range n {
+		for j := range m {
+			if matches(i, j) {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+		if len(candidates[i]) == 0 {
+			return false
+		}
+	}
+	// pairedWith[j] is the item paired with candidate j, or -1
+	pairedWith := make([]int, m)
+	for j := range pairedWith {
+		pairedWith[j] = -1
+	}
+	// pair finds a candidate for item i, moving the items paired before to other candidates if needed
+	var pair func(i int, visited []bool) bool
+	pair = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if pairedWith[j] == -1 || pair(pairedWith[j], visited) {
+				pairedWith[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range n {
+		if !pair(i, make([]bool, m)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package model_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_QueryMatcherMultiValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher model.Matcher
+		values  []string
+		want    bool
+	}{
+		{
+			name:    "hasExactly in any order",
+			matcher: model.Matcher{HasExactly: []model.Matcher{{EqualTo: "b"}, {EqualTo: "a"}}},
+			values:  []string{"a", "b"},
+			want:    true,
+		},
+		{
+			name:    "hasExactly with an extra value",
+			matcher: model.Matcher{HasExactly: []model.Matcher{{EqualTo: "a"}, {EqualTo: "b"}}},
+			values:  []string{"a", "b", "c"},
+			want:    false,
+		},
+		{
+			name:    "hasExactly pairs each value with a different matcher",
+			matcher: model.Matcher{HasExactly: []model.Matcher{{Matches: "^a"}, {EqualTo: "ab"}}},
+			values:  []string{"ab", "ab"},
+			want:    true,
+		},
+		{
+			name:    "hasExactly with a missing value",
+			matcher: model.Matcher{HasExactly: []model.Matcher{{EqualTo: "a"}, {EqualTo: "b"}}},
+			values:  []string{"a", "a"},
+			want:    false,
+		},
+		{
+			name:    "includes",
+			matcher: model.Matcher{Includes: []model.Matcher{{EqualTo: "b"}, {Matches: "^c"}}},
+			values:  []string{"a", "b", "cd"},
+			want:    true,
+		},
+		{
+			name:    "includes does not match",
+			matcher: model.Matcher{Includes: []model.Matcher{{EqualTo: "b"}, {Matches: "^c"}}},
+			values:  []string{"a", "b"},
+			want:    false,
+		},
+		{
+			name:    "includes without values",
+			matcher: model.Matcher{Includes: []model.Matcher{{EqualTo: "a"}}},
+			values:  nil,
+			want:    false,
+		},
+		{
+			name:    "count",
+			matcher: model.Matcher{Count: &model.CountMatcher{Matcher: model.Matcher{EqualTo: 2}}},
+			values:  []string{"a", "b"},
+			want:    true,
+		},
+		{
+			name:    "count does not match",
+			matcher: model.Matcher{Count: &model.CountMatcher{Matcher: model.Matcher{EqualTo: 2}}},
+			values:  []string{"a"},
+			want:    false,
+		},
+		{
+			name:    "count with a numeric matcher",
+			matcher: model.Matcher{Count: &model.CountMatcher{Matcher: model.Matcher{Between: []float64{1, 3}}}},
+			values:  []string{"a", "b", "c"},
+			want:    true,
+		},
+		{
+			name:    "count of a missing parameter",
+			matcher: model.Matcher{Count: &model.CountMatcher{Matcher: model.Matcher{EqualTo: 0}}},
+			values:  nil,
+			want:    true,
+		},
+		{
+			name:    "single value matchers look at the first value",
+			matcher: model.Matcher{EqualTo: "a"},
+			values:  []string{"a", "b"},
+			want:    true,
+		},
+		{
+			name:    "or of multi value matchers",
+			matcher: model.Matcher{Or: []model.Matcher{{Includes: []model.Matcher{{EqualTo: "x"}}}, {Count: &model.CountMatcher{Matcher: model.Matcher{EqualTo: 2}}}}},
+			values:  []string{"a", "b"},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{QueryParameters: map[string]model.Matcher{"tag": tt.matcher}}}
+			q := url.Values{}
+			if tt.values != nil {
+				q["tag"] = tt.values
+			}
+			if got, _ := e.QueryMatcher(q, q); got != tt.want {
+				t.Errorf("QueryMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_QueryMatcherHasExactlyManyValues(t *testing.T) {
+	// every matcher accepts all but the last value,
+	// which takes factorial time to rule out when every pairing is tried
+	var matchers []model.Matcher
+	q := url.Values{}
+	for i := range 50 {
+		matchers = append(matchers, model.Matcher{Matches: "^v[0-9]+$"})
+		q.Add("tag", fmt.Sprintf("v%d", i))
+	}
+	q["tag"][49] = "x"
+	e := model.Endpoint{Request: model.Request{QueryParameters: map[string]model.Matcher{"tag": {HasExactly: matchers}}}}
+
+	done := make(chan bool)
+	go func() {
+		got, _ := e.QueryMatcher(q, q)
+		done <- got
+	}()
+	select {
+	case got := <-done:
+		if got {
+			t.Errorf("QueryMatcher() = true, want false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("QueryMatcher() did not return within 5s")
+	}
+}
+
+func Test_HeaderMatcherMultiValue(t *testing.T) {
+	e := model.Endpoint{Request: model.Request{Headers: map[string]model.Matcher{
+		"Accept": {Includes: []model.Matcher{{Contains: "json"}}, Count: &model.CountMatcher{Matcher: model.Matcher{GreaterThan: float(1)}}},
+	}}}
+	if got, _ := e.HeaderMatcher(map[string][]string{"Accept": {"text/html", "application/json"}}); !got {
+		t.Errorf("HeaderMatcher() = %v, want true", got)
+	}
+	if got, _ := e.HeaderMatcher(map[string][]string{"Accept": {"application/json"}}); got {
+		t.Errorf("HeaderMatcher() = %v, want false", got)
+	}
+}
+
+func Test_CountMatcherUnmarshalJSON(t *testing.T) {
+	var got model.Matcher
+	data := `{"and": [{"count": 2}, {"count": {"lessThan": 5}}]}`
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := model.Matcher{And: []model.Matcher{
+		{Count: &model.CountMatcher{Matcher: model.Matcher{EqualTo: 2}}},
+		{Count: &model.CountMatcher{Matcher: model.Matcher{LessThan: float(5)}}},
+	}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("diff: %v", cmp.Diff(got, want))
+	}
+}
@@ -0,0 +1,524 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var openAPIPathParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// OpenAPI is an OpenAPI 3 document that requests and stub responses are checked against.
+type OpenAPI struct {
+	document  map[string]any
+	dir       string   // directory that relative $refs are resolved against
+	basePaths []string // paths of the server URLs, which request paths may start with
+}
+
+// OpenAPIRequest is a request checked against an OpenAPI document.
+type OpenAPIRequest struct {
+	Method  string
+	Path    string
+	Query   map[string][]string
+	Headers map[string][]string
+	Body    string
+}
+
+// OpenAPIResponse is a rendered stub response checked against an OpenAPI document.
+type OpenAPIResponse struct {
+	Status  int
+	Headers map[string][]string
+	Body    string
+}
+
+// OpenAPIError is a single way in which a request or a response breaks the contract of an OpenAPI document.
+type OpenAPIError struct {
+	In      string `json:"in"`             // path, method, query, header, cookie, body or status
+	Name    string `json:"name,omitempty"` // name of the parameter or header, or JSONPath of the invalid value in the body
+	Message string `json:"message"`
+}
+
+func (e OpenAPIError) String() string {
+	if e.Name == "" {
+		return e.In + ": " + e.Message
+	}
+	return e.In + " " + e.Name + ": " + e.Message
+}
+
+// ParseOpenAPI parses an OpenAPI 3 document in JSON. Relative $refs are resolved against dir.
+func ParseOpenAPI(data []byte, dir string) (OpenAPI, error) {
+	v, err := decodeJSON(string(data))
+	if err != nil {
+		return OpenAPI{}, err
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return OpenAPI{}, fmt.Errorf("OpenAPI document must be an object")
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return OpenAPI{}, fmt.Errorf("unsupported OpenAPI version %q, only OpenAPI 3 is supported", version)
+	}
+	if _, ok := doc["paths"].(map[string]any); !ok {
+		return OpenAPI{}, fmt.Errorf("OpenAPI document has no paths")
+	}
+	o := OpenAPI{document: doc, dir: dir}
+	servers, _ := doc["servers"].([]any)
+	for _, s := range servers {
+		server, _ := s.(map[string]any)
+		raw, _ := server["url"].(string)
+		variables, _ := server["variables"].(map[string]any)
+		raw = openAPIPathParam.ReplaceAllStringFunc(raw, func(p string) string {
+			variable, _ := variables[p[1:len(p)-1]].(map[string]any)
+			def, _ := variable["default"].(string)
+			return def
+		})
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if base := strings.TrimSuffix(u.Path, "/"); base != "" {
+			o.basePaths = append(o.basePaths, base)
+		}
+	}
+	return o, nil
+}
+
+// ValidateRequest checks the request against the operation the document defines for its method and path.
+func (o OpenAPI) ValidateRequest(r OpenAPIRequest) []OpenAPIError {
+	v := newOpenAPIValidator()
+	op, ok := o.operation(v, r.Method, r.Path)
+	if !ok {
+		return v.errs
+	}
+	v.parameters(op, r)
+	v.requestBody(op, r)
+	return v.errs
+}
+
+// ValidateResponse checks a response to the request against the responses the document defines for its operation.
+func (o OpenAPI) ValidateResponse(r OpenAPIRequest, resp OpenAPIResponse) []OpenAPIError {
+	v := newOpenAPIValidator()
+	op, ok := o.operation(v, r.Method, r.Path)
+	if !ok {
+		return v.errs
+	}
+	responses, _ := op.operation["responses"].(map[string]any)
+	code := strconv.Itoa(resp.Status)
+	raw, ok := responses[code]
+	if !ok {
+		for k, response := range responses {
+			if len(code) == 3 && strings.EqualFold(k, code[:1]+"XX") {
+				raw, ok = response, true
+			}
+		}
+	}
+	if !ok {
+		raw, ok = responses["default"]
+	}
+	if !ok {
+		v.fail("status", "", "%d is not a documented response of %s %s, expected one of %s", resp.Status, op.method, op.template, strings.Join(slices.Sorted(maps.Keys(responses)), ", "))
+		return v.errs
+	}
+	s, response, ok := v.deref(op.ref.with(raw))
+	if !ok {
+		return v.errs
+	}
+	headers, _ := response["headers"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		if strings.EqualFold(name, "Content-Type") {
+			// described by content instead
+			continue
+		}
+		h, header, ok := v.deref(s.with(headers[name]))
+		if !ok {
+			continue
+		}
+		values := http.Header(resp.Headers).Values(name)
+		if len(values) == 0 {
+			if required, _ := header["required"].(bool); required {
+				v.fail("header", name, "missing required header")
+			}
+			continue
+		}
+		v.value(h, header, "header", name, values)
+	}
+	if resp.Body != "" {
+		v.content(s, response, http.Header(resp.Headers).Get("Content-Type"), resp.Body)
+	}
+	return v.errs
+}
+
+// openAPIOperation is the operation of a path item that a request was routed to.
+type openAPIOperation struct {
+	method     string
+	template   string            // path of the path item, e.g. /users/{id}
+	pathParams map[string]string // values of the path parameters
+	item       map[string]any
+	operation  map[string]any
+	ref        schemaRef // document that the path item belongs to
+}
+
+type openAPIValidator struct {
+	schemas schemaValidator
+	errs    []OpenAPIError
+}
+
+func newOpenAPIValidator() *openAPIValidator {
+	return &openAPIValidator{schemas: schemaValidator{documents: map[string]any{}}}
+}
+
+func (v *openAPIValidator) fail(in, name, format string, args ...any) {
+	v.errs = append(v.errs, OpenAPIError{In: in, Name: name, Message: fmt.Sprintf(format, args...)})
+}
+
+// operation finds the operation for the method on the path item whose template matches the path.
+// Paths without parameters win over templated ones, as the specification requires.
+// A HEAD request falls back to the GET operation.
+func (o OpenAPI) operation(v *openAPIValidator, method, path string) (openAPIOperation, bool) {
+	for _, base := range o.basePaths {
+		if path == base {
+			path = "/"
+			break
+		}
+		if strings.HasPrefix(path, base+"/") {
+			path = strings.TrimPrefix(path, base)
+			break
+		}
+	}
+	paths, _ := o.document["paths"].(map[string]any)
+	var op openAPIOperation
+	found := false
+	for _, template := range slices.Sorted(maps.Keys(paths)) {
+		params, ok := matchPathTemplate(template, path)
+		if ok && (!found || len(params) < len(op.pathParams)) {
+			op.template, op.pathParams, found = template, params, true
+		}
+	}
+	if !found {
+		v.fail("path", "", "%s is not a path of the OpenAPI document", path)
+		return openAPIOperation{}, false
+	}
+	ref, item, ok := v.deref(schemaRef{schema: paths[op.template], root: o.document, dir: o.dir})
+	if !ok {
+		return openAPIOperation{}, false
+	}
+	op.method, op.item, op.ref = strings.ToUpper(method), item, ref
+	op.operation, ok = item[strings.ToLower(method)].(map[string]any)
+	if !ok && op.method == http.MethodHead {
+		op.operation, ok = item["get"].(map[string]any)
+	}
+	if !ok {
+		var allowed []string
+		for _, m := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+			if _, ok := item[m]; ok {
+				allowed = append(allowed, strings.ToUpper(m))
+			}
+		}
+		v.fail("method", "", "%s is not allowed on %s, expected one of %s", op.method, op.template, strings.Join(allowed, ", "))
+		return openAPIOperation{}, false
+	}
+	return op, true
+}
+
+// matchPathTemplate matches a path against a path template such as /users/{id} and returns the values of its parameters.
+func matchPathTemplate(template, path string) (map[string]string, bool) {
+	templates, segments := strings.Split(template, "/"), strings.Split(path, "/")
+	if len(templates) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, t := range templates {
+		matches := openAPIPathParam.FindAllStringSubmatchIndex(t, -1)
+		if matches == nil {
+			if t != segments[i] {
+				return nil, false
+			}
+			continue
+		}
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		last := 0
+		for _, m := range matches {
+			pattern.WriteString(regexp.QuoteMeta(t[last:m[0]]))
+			pattern.WriteString("(.+?)")
+			last = m[1]
+		}
+		pattern.WriteString(regexp.QuoteMeta(t[last:]) + "$")
+		values := regexp.MustCompile(pattern.String()).FindStringSubmatch(segments[i])
+		if values == nil {
+			return nil, false
+		}
+		for j, m := range matches {
+			params[t[m[2]:m[3]]] = values[j+1]
+		}
+	}
+	return params, true
+}
+
+// deref follows the $ref of a path item, parameter, request body, response, header or schema.
+// It returns the object, together with the document it belongs to.
+func (v *openAPIValidator) deref(s schemaRef) (schemaRef, map[string]any, bool) {
+	for range maxSchemaDepth {
+		obj, ok := s.schema.(map[string]any)
+		if !ok {
+			return s, nil, false
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return s, obj, true
+		}
+		next, err := v.schemas.resolve(s, ref)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Invalid OpenAPI document: %s", err))
+			return s, nil, false
+		}
+		s = next
+	}
+	slog.Error("Invalid OpenAPI document: $refs nest too deeply")
+	return s, nil, false
+}
+
+// parameters checks the parameters of the path item and of the operation; the latter override the former.
+func (v *openAPIValidator) parameters(op openAPIOperation, r OpenAPIRequest) {
+	type key struct{ in, name string }
+	params := map[key]schemaRef{}
+	var order []key
+	for _, owner := range []map[string]any{op.item, op.operation} {
+		list, _ := owner["parameters"].([]any)
+		for _, p := range list {
+			s, param, ok := v.deref(op.ref.with(p))
+			if !ok {
+				continue
+			}
+			in, _ := param["in"].(string)
+			name, _ := param["name"].(string)
+			k := key{in: in, name: name}
+			if in == "header" {
+				k.name = http.CanonicalHeaderKey(name)
+			}
+			if _, ok := params[k]; !ok {
+				order = append(order, k)
+			}
+			params[k] = s
+		}
+	}
+	for _, k := range order {
+		s := params[k]
+		param := s.schema.(map[string]any)
+		var values []string
+		switch k.in {
+		case "path":
+			if value, ok := op.pathParams[k.name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = r.Query[k.name]
+		case "header":
+			if k.name == "Accept" || k.name == "Content-Type" || k.name == "Authorization" {
+				// described by the content and security of the operation instead
+				continue
+			}
+			values = http.Header(r.Headers).Values(k.name)
+		case "cookie":
+			for _, c := range (&http.Request{Header: r.Headers}).Cookies() {
+				if c.Name == k.name {
+					values = append(values, c.Value)
+				}
+			}
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if required, _ := param["required"].(bool); required || k.in == "path" {
+				v.fail(k.in, k.name, "missing required %s parameter", k.in)
+			}
+			continue
+		}
+		v.value(s, param, k.in, k.name, values)
+	}
+}
+
+// value checks the values of a parameter or a header against its schema,
+// after converting them to the type that the schema expects.
+// Object values are not checked, as their serialization depends on the style.
+func (v *openAPIValidator) value(s schemaRef, param map[string]any, in, name string, values []string) {
+	var instance any
+	schema, ok := param["schema"]
+	if !ok {
+		// the value is a serialized document, usually JSON
+		content, _ := param["content"].(map[string]any)
+		keys := slices.Sorted(maps.Keys(content))
+		if len(keys) == 0 {
+			return
+		}
+		media, _ := content[keys[0]].(map[string]any)
+		if schema, ok = media["schema"]; !ok {
+			return
+		}
+		var err error
+		if instance, err = decodeJSON(values[0]); err != nil {
+			v.fail(in, name, "invalid JSON: %s", err)
+			return
+		}
+	} else {
+		switch typed, t := v.schemaType(s.with(schema)); t {
+		case "object":
+			return
+		case "array":
+			items := values
+			if len(values) == 1 {
+				separator := ","
+				switch param["style"] {
+				case "spaceDelimited":
+					separator = " "
+				case "pipeDelimited":
+					separator = "|"
+				}
+				items = strings.Split(values[0], separator)
+			}
+			itemSchema := typed.with(typed.schema.(map[string]any)["items"])
+			_, itemType := v.schemaType(itemSchema)
+			array := make([]any, 0, len(items))
+			for _, item := range items {
+				array = append(array, coerce(itemType, item))
+			}
+			instance = array
+		default:
+			instance = coerce(t, values[0])
+		}
+	}
+	for _, e := range v.schemas.validate(s.with(schema), instance, "$", 0) {
+		v.fail(in, name+strings.TrimPrefix(e.Path, "$"), "%s", e.Message)
+	}
+}
+
+// schemaType returns the type of a schema, following its $refs.
+// For a list of types, the first one other than null is returned.
+func (v *openAPIValidator) schemaType(s schemaRef) (schemaRef, string) {
+	s, schema, ok := v.deref(s)
+	if !ok {
+		return s, ""
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		return s, t
+	case []any:
+		for _, t := range t {
+			if t, ok := t.(string); ok && t != "null" {
+				return s, t
+			}
+		}
+	}
+	return s, ""
+}
+
+// coerce converts the string value of a parameter or a header to the given JSON type.
+// A value that cannot be converted stays a string, so that the schema reports it.
+func coerce(t, value string) any {
+	switch t {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		switch value {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	case "null":
+		if value == "" {
+			return nil
+		}
+	}
+	return value
+}
+
+func (v *openAPIValidator) requestBody(op openAPIOperation, r OpenAPIRequest) {
+	raw, ok := op.operation["requestBody"]
+	if !ok {
+		return
+	}
+	s, body, ok := v.deref(op.ref.with(raw))
+	if !ok {
+		return
+	}
+	if r.Body == "" {
+		if required, _ := body["required"].(bool); required {
+			v.fail("body", "", "missing required request body")
+		}
+		return
+	}
+	v.content(s, body, http.Header(r.Headers).Get("Content-Type"), r.Body)
+}
+
+// content checks a body against the media type of the content map that its content type selects.
+// Only JSON bodies are checked against the schema of the media type.
+func (v *openAPIValidator) content(s schemaRef, owner map[string]any, contentType, body string) {
+	content, _ := owner["content"].(map[string]any)
+	if len(content) == 0 {
+		return
+	}
+	mediaTypes := slices.Sorted(maps.Keys(content))
+	var mediaType string
+	if contentType == "" {
+		if len(mediaTypes) > 1 {
+			v.fail("header", "Content-Type", "missing, expected one of %s", strings.Join(mediaTypes, ", "))
+			return
+		}
+		mediaType, _, _ = mime.ParseMediaType(mediaTypes[0])
+	} else {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			v.fail("header", "Content-Type", "invalid content type %q", contentType)
+			return
+		}
+	}
+	media, ok := selectMediaType(content, mediaType)
+	if !ok {
+		v.fail("header", "Content-Type", "%s is not one of %s", mediaType, strings.Join(mediaTypes, ", "))
+		return
+	}
+	schema, ok := media["schema"]
+	if !ok || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return
+	}
+	instance, err := decodeJSON(body)
+	if err != nil {
+		v.fail("body", "", "invalid JSON: %s", err)
+		return
+	}
+	for _, e := range v.schemas.validate(s.with(schema), instance, "$", 0) {
+		v.fail("body", e.Path, "%s", e.Message)
+	}
+}
+
+// selectMediaType returns the media type object for a content type: an exact match first,
+// then a range such as application/*, then */*.
+func selectMediaType(content map[string]any, mediaType string) (map[string]any, bool) {
+	ranges := map[string]map[string]any{}
+	for k, media := range content {
+		key, _, err := mime.ParseMediaType(k)
+		if err != nil {
+			continue
+		}
+		m, _ := media.(map[string]any)
+		ranges[key] = m
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	for _, key := range []string{mediaType, major + "/*", "*/*"} {
+		if m, ok := ranges[key]; ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
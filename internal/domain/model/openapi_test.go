@@ -0,0 +1,287 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testOpenAPI = `{
+	"openapi": "3.0.3",
+	"servers": [{"url": "https://{host}/v1", "variables": {"host": {"default": "api.example.com"}}}],
+	"paths": {
+		"/users": {
+			"get": {
+				"parameters": [
+					{"name": "limit", "in": "query", "schema": {"type": "integer", "maximum": 100}},
+					{"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}}}
+				],
+				"responses": {"200": {"description": "ok"}}
+			},
+			"post": {
+				"requestBody": {
+					"required": true,
+					"content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewUser"}}}
+				},
+				"responses": {
+					"201": {
+						"description": "created",
+						"headers": {"Location": {"required": true, "schema": {"type": "string", "pattern": "^/users/[0-9]+$"}}},
+						"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}
+					},
+					"4XX": {"$ref": "#/components/responses/Error"}
+				}
+			}
+		},
+		"/users/me": {
+			"get": {"responses": {"200": {"description": "ok"}}}
+		},
+		"/users/{id}": {
+			"parameters": [{"$ref": "#/components/parameters/UserID"}],
+			"get": {
+				"parameters": [
+					{"name": "X-Request-Id", "in": "header", "required": true, "schema": {"type": "string", "format": "uuid"}},
+					{"name": "session", "in": "cookie", "required": true, "schema": {"type": "string", "minLength": 8}}
+				],
+				"responses": {
+					"200": {"description": "ok", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+					"default": {"$ref": "#/components/responses/Error"}
+				}
+			}
+		},
+		"/files/{name}.{ext}": {
+			"get": {
+				"parameters": [{"name": "ext", "in": "path", "required": true, "schema": {"enum": ["png", "jpg"]}}],
+				"responses": {"200": {"description": "ok"}}
+			}
+		}
+	},
+	"components": {
+		"parameters": {
+			"UserID": {"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "minimum": 1}}
+		},
+		"schemas": {
+			"NewUser": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {"name": {"type": "string"}, "nickname": {"type": "string", "nullable": true}}
+			},
+			"User": {
+				"allOf": [{"$ref": "#/components/schemas/NewUser"}, {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}]
+			}
+		},
+		"responses": {
+			"Error": {
+				"description": "error",
+				"content": {"application/problem+json": {"schema": {"type": "object", "required": ["title"]}}}
+			}
+		}
+	}
+}`
+
+func Test_ParseOpenAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr bool
+	}{
+		{name: "OpenAPI 3", doc: testOpenAPI},
+		{name: "Swagger 2", doc: `{"swagger": "2.0", "paths": {}}`, wantErr: true},
+		{name: "no paths", doc: `{"openapi": "3.1.0"}`, wantErr: true},
+		{name: "not JSON", doc: `openapi: 3.0.0`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := model.ParseOpenAPI([]byte(tt.doc), "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseOpenAPI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_OpenAPIValidateRequest(t *testing.T) {
+	o, err := model.ParseOpenAPI([]byte(testOpenAPI), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	validHeaders := map[string][]string{
+		"X-Request-Id": {"0b6f1b9e-4a0c-4c4e-9a57-4c6f0c5b6f3a"},
+		"Cookie":       {"session=abcdefgh"},
+	}
+	tests := []struct {
+		name    string
+		request model.OpenAPIRequest
+		want    []model.OpenAPIError
+	}{
+		{
+			name:    "valid query parameters",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users", Query: map[string][]string{"limit": {"10"}, "tags": {"a", "b"}}},
+		},
+		{
+			name:    "server base path",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/v1/users"},
+		},
+		{
+			name:    "query parameter of the wrong type",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users", Query: map[string][]string{"limit": {"ten"}}},
+			want:    []model.OpenAPIError{{In: "query", Name: "limit", Message: "must be integer, not string"}},
+		},
+		{
+			name:    "query parameter out of range",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users", Query: map[string][]string{"limit": {"1000"}}},
+			want:    []model.OpenAPIError{{In: "query", Name: "limit", Message: "must be less than or equal to 100"}},
+		},
+		{
+			name:    "comma separated array",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users", Query: map[string][]string{"tags": {"a,c"}}},
+			want:    []model.OpenAPIError{{In: "query", Name: "tags[1]", Message: `must be one of ["a","b"]`}},
+		},
+		{
+			name:    "unknown path",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/orders"},
+			want:    []model.OpenAPIError{{In: "path", Message: "/orders is not a path of the OpenAPI document"}},
+		},
+		{
+			name:    "method not allowed",
+			request: model.OpenAPIRequest{Method: "DELETE", Path: "/users"},
+			want:    []model.OpenAPIError{{In: "method", Message: "DELETE is not allowed on /users, expected one of GET, POST"}},
+		},
+		{
+			name:    "literal path wins over template",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users/me"},
+		},
+		{
+			name:    "path, header and cookie parameters",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users/1", Headers: validHeaders},
+		},
+		{
+			name:    "HEAD falls back to GET",
+			request: model.OpenAPIRequest{Method: "HEAD", Path: "/users/1", Headers: validHeaders},
+		},
+		{
+			name:    "invalid path parameter",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users/0", Headers: validHeaders},
+			want:    []model.OpenAPIError{{In: "path", Name: "id", Message: "must be greater than or equal to 1"}},
+		},
+		{
+			name:    "missing header and cookie",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/users/1", Headers: map[string][]string{"X-Request-Id": {"nope"}}},
+			want: []model.OpenAPIError{
+				{In: "header", Name: "X-Request-Id", Message: `must be a valid uuid`},
+				{In: "cookie", Name: "session", Message: "missing required cookie parameter"},
+			},
+		},
+		{
+			name:    "parameters within a segment",
+			request: model.OpenAPIRequest{Method: "GET", Path: "/files/logo.gif"},
+			want:    []model.OpenAPIError{{In: "path", Name: "ext", Message: `must be one of ["png","jpg"]`}},
+		},
+		{
+			name: "valid body",
+			request: model.OpenAPIRequest{
+				Method:  "POST",
+				Path:    "/users",
+				Headers: map[string][]string{"Content-Type": {"application/json; charset=utf-8"}},
+				Body:    `{"name": "Alice", "nickname": null}`,
+			},
+		},
+		{
+			name:    "invalid body",
+			request: model.OpenAPIRequest{Method: "POST", Path: "/users", Headers: map[string][]string{"Content-Type": {"application/json"}}, Body: `{"nickname": 1}`},
+			want: []model.OpenAPIError{
+				{In: "body", Name: "$", Message: `missing required property "name"`},
+				{In: "body", Name: "$.nickname", Message: "must be string, not integer"},
+			},
+		},
+		{
+			name:    "missing body",
+			request: model.OpenAPIRequest{Method: "POST", Path: "/users"},
+			want:    []model.OpenAPIError{{In: "body", Message: "missing required request body"}},
+		},
+		{
+			name:    "unexpected content type",
+			request: model.OpenAPIRequest{Method: "POST", Path: "/users", Headers: map[string][]string{"Content-Type": {"text/plain"}}, Body: "Alice"},
+			want:    []model.OpenAPIError{{In: "header", Name: "Content-Type", Message: "text/plain is not one of application/json"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := o.ValidateRequest(tt.request)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ValidateRequest() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_OpenAPIValidateResponse(t *testing.T) {
+	o, err := model.ParseOpenAPI([]byte(testOpenAPI), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := model.OpenAPIRequest{Method: "POST", Path: "/users"}
+	tests := []struct {
+		name     string
+		request  model.OpenAPIRequest
+		response model.OpenAPIResponse
+		want     []model.OpenAPIError
+	}{
+		{
+			name:    "valid response",
+			request: post,
+			response: model.OpenAPIResponse{
+				Status:  201,
+				Headers: map[string][]string{"Location": {"/users/1"}, "Content-Type": {"application/json"}},
+				Body:    `{"id": 1, "name": "Alice"}`,
+			},
+		},
+		{
+			name:    "content type inferred from the only media type",
+			request: post,
+			response: model.OpenAPIResponse{
+				Status:  201,
+				Headers: map[string][]string{"Location": {"/users/1"}},
+				Body:    `{"id": "1", "name": "Alice"}`,
+			},
+			want: []model.OpenAPIError{{In: "body", Name: "$.id", Message: "must be integer, not string"}},
+		},
+		{
+			name:     "missing header",
+			request:  post,
+			response: model.OpenAPIResponse{Status: 201, Body: `{"id": 1, "name": "Alice"}`},
+			want:     []model.OpenAPIError{{In: "header", Name: "Location", Message: "missing required header"}},
+		},
+		{
+			name:    "status range",
+			request: post,
+			response: model.OpenAPIResponse{
+				Status:  422,
+				Headers: map[string][]string{"Content-Type": {"application/problem+json"}},
+				Body:    `{"detail": "invalid"}`,
+			},
+			want: []model.OpenAPIError{{In: "body", Name: "$", Message: `missing required property "title"`}},
+		},
+		{
+			name:     "undocumented status",
+			request:  post,
+			response: model.OpenAPIResponse{Status: 500},
+			want:     []model.OpenAPIError{{In: "status", Message: "500 is not a documented response of POST /users, expected one of 201, 4XX"}},
+		},
+		{
+			name:     "default response",
+			request:  model.OpenAPIRequest{Method: "GET", Path: "/users/1"},
+			response: model.OpenAPIResponse{Status: 500, Headers: map[string][]string{"Content-Type": {"text/html"}}, Body: "<h1>oops</h1>"},
+			want:     []model.OpenAPIError{{In: "header", Name: "Content-Type", Message: "text/html is not one of application/problem+json"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := o.ValidateResponse(tt.request, tt.response)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ValidateResponse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+// ProxyRequest is a request forwarded to another server.
+type ProxyRequest struct {
+	BaseURL string // scheme, host and optional path prefix of the target server
+	Method  string
+	URL     string // path and query appended to BaseURL
+	Headers map[string][]string
+	Body    []byte
+}
+
+// ProxyResponse is the response received from the server a request was forwarded to.
+type ProxyResponse struct {
+	Status  int
+	Headers map[string][]string
+	Body    []byte
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"regexp"
+	"sync"
+)
+
+// regexps holds the compiled patterns of matches and doesNotMatch, keyed by the pattern.
+var regexps sync.Map
+
+// compiledRegexp compiles a pattern the first time it is used and returns the same *regexp.Regexp afterwards.
+// The pattern must be valid, which Endpoint.Validate checks before an endpoint is used.
+func compiledRegexp(pattern string) *regexp.Regexp {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, _ := regexps.LoadOrStore(pattern, regexp.MustCompile(pattern))
+	return re.(*regexp.Regexp)
+}
@@ -0,0 +1,11 @@
+package model
+
+// ScenarioStarted is the state every scenario is in until a stub moves it.
+const ScenarioStarted = "Started"
+
+// Scenario is a named state machine shared by the stubs that refer to it.
+type Scenario struct {
+	Name           string   `json:"name"`
+	State          string   `json:"state"`
+	PossibleStates []string `json:"possibleStates"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Engines that render the response body template.
+const (
+	TemplateEngineText = "text" // values are written as they are
+	TemplateEngineJSON = "json" // values inside JSON strings are escaped as JSON
+	TemplateEngineHTML = "html" // values are escaped for their HTML context by html/template
+)
+
+// BodyTemplateEngine returns the engine that renders the body: templateEngine when it is set,
+// otherwise the one that suits the Content-Type header of the response.
+func (r Response) BodyTemplateEngine() string {
+	if r.TemplateEngine != "" {
+		return strings.ToLower(r.TemplateEngine)
+	}
+	var contentType string
+	for k, v := range r.Headers {
+		if http.CanonicalHeaderKey(k) == "Content-Type" && len(v) > 0 {
+			contentType = v[0]
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return TemplateEngineText
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return TemplateEngineJSON
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return TemplateEngineHTML
+	default:
+		return TemplateEngineText
+	}
+}
@@ -0,0 +1,61 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_ResponseBodyTemplateEngine(t *testing.T) {
+	tests := []struct {
+		name     string
+		response model.Response
+		want     string
+	}{
+		{
+			name:     "no content type",
+			response: model.Response{},
+			want:     model.TemplateEngineText,
+		},
+		{
+			name:     "json",
+			response: model.Response{Headers: map[string]model.ResponseHeader{"Content-Type": {"application/json; charset=utf-8"}}},
+			want:     model.TemplateEngineJSON,
+		},
+		{
+			name:     "json suffix and lower case header",
+			response: model.Response{Headers: map[string]model.ResponseHeader{"content-type": {"application/problem+json"}}},
+			want:     model.TemplateEngineJSON,
+		},
+		{
+			name:     "html",
+			response: model.Response{Headers: map[string]model.ResponseHeader{"Content-Type": {"text/html"}}},
+			want:     model.TemplateEngineHTML,
+		},
+		{
+			name:     "plain text",
+			response: model.Response{Headers: map[string]model.ResponseHeader{"Content-Type": {"text/plain"}}},
+			want:     model.TemplateEngineText,
+		},
+		{
+			name:     "invalid content type",
+			response: model.Response{Headers: map[string]model.ResponseHeader{"Content-Type": {"{{.Query.type}}"}}},
+			want:     model.TemplateEngineText,
+		},
+		{
+			name: "explicit engine wins over the content type",
+			response: model.Response{
+				TemplateEngine: "Text",
+				Headers:        map[string]model.ResponseHeader{"Content-Type": {"application/json"}},
+			},
+			want: model.TemplateEngineText,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.response.BodyTemplateEngine(); got != tt.want {
+				t.Errorf("BodyTemplateEngine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
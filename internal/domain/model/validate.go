@@ -0,0 +1,139 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// Validate checks that every pattern of the endpoint compiles and every matcher has values of the right type,
+// so that an endpoint registered at runtime cannot break the matching of later requests.
+func (endpoint Endpoint) Validate() error {
+	r := endpoint.Request
+	var errs []error
+	if _, err := regexp.Compile(r.URLPattern); err != nil {
+		errs = append(errs, fmt.Errorf("request.urlPattern: %w", err))
+	}
+	if _, err := regexp.Compile(r.URLPathPattern); err != nil {
+		errs = append(errs, fmt.Errorf("request.urlPathPattern: %w", err))
+	}
+	errs = append(errs,
+		r.Method.validate("request.method"),
+		validateMatchers("request.headers", r.Headers),
+		validateMatchers("request.queryParameters", r.QueryParameters),
+		validateMatchers("request.pathParameters", r.PathParameters),
+		validateMatchers("request.cookies", r.Cookies),
+		validateMatchers("request.formParameters", r.FormParameters),
+		r.Body.validate("request.body"),
+		r.Host.validate("request.host"),
+		r.Scheme.validate("request.scheme"),
+		r.Port.validate("request.port"),
+	)
+	for i, p := range r.MultipartPatterns {
+		path := fmt.Sprintf("request.multipartPatterns[%d]", i)
+		errs = append(errs,
+			p.Name.validate(path+".name"),
+			p.FileName.validate(path+".fileName"),
+			validateMatchers(path+".headers", p.Headers),
+			p.Body.validate(path+".body"),
+		)
+	}
+	if r.JWT != nil {
+		errs = append(errs,
+			validateMatchers("request.jwt.headers", r.JWT.Headers),
+			validateMatchers("request.jwt.claims", r.JWT.Claims),
+		)
+		if r.JWT.KeyFile != "" {
+			if _, err := os.ReadFile(r.JWT.KeyFile); err != nil {
+				errs = append(errs, fmt.Errorf("request.jwt.keyFile: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateMatchers(path string, matchers map[string]Matcher) error {
+	var errs []error
+	for _, k := range slices.Sorted(maps.Keys(matchers)) {
+		errs = append(errs, matchers[k].validate(path+"."+k))
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks the matcher and the matchers it is made of. path names the matcher in the errors.
+func (m Matcher) validate(path string) error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+	for _, f := range []struct {
+		name  string
+		value any
+		regex bool
+	}{
+		{"matches", m.Matches, true},
+		{"doesNotMatch", m.DoesNotMatch, true},
+		{"contains", m.Contains, false},
+		{"doesNotContain", m.DoesNotContain, false},
+	} {
+		if f.value == nil {
+			continue
+		}
+		s, ok := f.value.(string)
+		if !ok {
+			fail("%s must be a string, not %T", f.name, f.value)
+			continue
+		}
+		if _, err := regexp.Compile(s); f.regex && err != nil {
+			fail("%s: %s", f.name, err)
+		}
+	}
+	if m.Between != nil && len(m.Between) != 2 {
+		fail("between must have a lower and an upper bound")
+	}
+	if m.EqualToJSON != nil {
+		if _, err := m.expectedJSON(); err != nil {
+			fail("equalToJson: %s", err)
+		}
+	}
+	if m.MatchesJSONSchema != nil {
+		if _, _, err := m.jsonSchema(); err != nil {
+			fail("matchesJsonSchema: %s", err)
+		}
+	}
+	for i, jp := range m.MatchesJSONPath {
+		if _, err := parseJSONPath(jp.Expression, '$'); err != nil {
+			fail("matchesJsonPath[%d]: %s", i, err)
+		}
+		errs = append(errs, jp.Matcher.validate(fmt.Sprintf("%s.matchesJsonPath[%d]", path, i)))
+	}
+	for i, xp := range m.MatchesXPath {
+		if _, err := parseXPath(xp.Expression, m.XPathNamespaces); err != nil {
+			fail("matchesXPath[%d]: %s", i, err)
+		}
+		errs = append(errs, xp.Matcher.validate(fmt.Sprintf("%s.matchesXPath[%d]", path, i)))
+	}
+	for _, subs := range []struct {
+		name     string
+		matchers []Matcher
+	}{
+		{"hasExactly", m.HasExactly},
+		{"includes", m.Includes},
+		{"and", m.And},
+		{"or", m.Or},
+	} {
+		for i, sub := range subs.matchers {
+			errs = append(errs, sub.validate(fmt.Sprintf("%s.%s[%d]", path, subs.name, i)))
+		}
+	}
+	if m.Count != nil {
+		errs = append(errs, m.Count.Matcher.validate(path+".count"))
+	}
+	if m.Not != nil {
+		errs = append(errs, m.Not.validate(path+".not"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package model_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_EndpointValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		wantErr string
+	}{
+		{
+			name:    "valid matchers",
+			request: `{"urlPathPattern": "^/users/[0-9]+$", "method": ["GET", "HEAD"], "headers": {"X-Id": {"matches": "^[a-z]+$"}}, "body": {"matchesJsonPath": ["$.name"], "matchesXPath": ["//a"]}}`,
+		},
+		{
+			name:    "invalid URL pattern",
+			request: `{"urlPattern": "/users/("}`,
+			wantErr: "request.urlPattern: error parsing regexp",
+		},
+		{
+			name:    "invalid regex",
+			request: `{"queryParameters": {"q": {"matches": "[a-"}}}`,
+			wantErr: "request.queryParameters.q: matches: error parsing regexp",
+		},
+		{
+			name:    "regex that is not a string",
+			request: `{"cookies": {"session": {"doesNotMatch": 1}}}`,
+			wantErr: "request.cookies.session: doesNotMatch must be a string, not float64",
+		},
+		{
+			name:    "nested matcher",
+			request: `{"headers": {"Accept": {"or": [{"contains": "json"}, {"not": {"contains": ["xml"]}}]}}}`,
+			wantErr: "request.headers.Accept.or[1].not: contains must be a string, not []interface {}",
+		},
+		{
+			name:    "invalid method matcher",
+			request: `{"method": {"matches": "(GET"}}`,
+			wantErr: "request.method: matches: error parsing regexp",
+		},
+		{
+			name:    "invalid JSONPath and XPath",
+			request: `{"body": {"matchesJsonPath": ["$.a["], "matchesXPath": [{"expression": "//a", "matches": "("}]}}`,
+			wantErr: "request.body: matchesJsonPath[0]",
+		},
+		{
+			name:    "invalid between",
+			request: `{"jwt": {"claims": {"exp": {"between": [1]}}}}`,
+			wantErr: "request.jwt.claims.exp: between must have a lower and an upper bound",
+		},
+		{
+			name:    "unreadable JWT key file",
+			request: `{"jwt": {"keyFile": "testdata/no-such.key"}}`,
+			wantErr: "request.jwt.keyFile: open testdata/no-such.key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e model.Endpoint
+			if err := json.Unmarshal([]byte(`{"request": `+tt.request+`}`), &e); err != nil {
+				t.Fatal(err)
+			}
+			err := e.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
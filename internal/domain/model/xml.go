@@ -0,0 +1,136 @@
+package model
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+type xmlNodeKind int
+
+const (
+	xmlDocument xmlNodeKind = iota
+	xmlElement
+	xmlText
+	xmlAttribute
+)
+
+// xmlNode is a node of a parsed XML document. Names carry the namespace URI, not the prefix.
+type xmlNode struct {
+	kind     xmlNodeKind
+	name     xml.Name
+	value    string     // text and attribute nodes
+	attrs    []*xmlNode // element nodes
+	children []*xmlNode // elements and text in document order
+	parent   *xmlNode
+	order    int // position in the document, used to keep XPath results in document order
+}
+
+// parseXML builds a tree from an XML document. Whitespace-only text, comments and processing instructions are dropped.
+func parseXML(s string) (*xmlNode, error) {
+	dec := xml.NewDecoder(strings.NewReader(s))
+	doc := &xmlNode{kind: xmlDocument}
+	current := doc
+	order := 0
+	next := func() int {
+		order++
+		return order
+	}
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if current == doc && slices.ContainsFunc(doc.children, func(n *xmlNode) bool { return n.kind == xmlElement }) {
+				return nil, fmt.Errorf("XML document has more than one root element")
+			}
+			el := &xmlNode{kind: xmlElement, name: t.Name, parent: current, order: next()}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+					continue
+				}
+				el.attrs = append(el.attrs, &xmlNode{kind: xmlAttribute, name: a.Name, value: a.Value, parent: el, order: next()})
+			}
+			current.children = append(current.children, el)
+			current = el
+		case xml.EndElement:
+			current = current.parent
+		case xml.CharData:
+			if current == doc || strings.TrimSpace(string(t)) == "" {
+				continue
+			}
+			if last := len(current.children) - 1; last >= 0 && current.children[last].kind == xmlText {
+				current.children[last].value += string(t)
+				continue
+			}
+			current.children = append(current.children, &xmlNode{kind: xmlText, value: string(t), parent: current, order: next()})
+		}
+	}
+	if !slices.ContainsFunc(doc.children, func(n *xmlNode) bool { return n.kind == xmlElement }) {
+		return nil, fmt.Errorf("XML document has no root element")
+	}
+	return doc, nil
+}
+
+// stringValue returns the XPath string value of the node: the text it contains, or its value.
+func (n *xmlNode) stringValue() string {
+	switch n.kind {
+	case xmlText, xmlAttribute:
+		return n.value
+	default:
+		var b strings.Builder
+		for _, c := range n.children {
+			b.WriteString(c.stringValue())
+		}
+		return b.String()
+	}
+}
+
+// matchEqualToXML compares the body with equalToXml, ignoring whitespace between elements,
+// namespace prefixes and the order of attributes.
+func (m Matcher) matchEqualToXML(body string) bool {
+	expected, err := parseXML(m.EqualToXML)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Invalid equalToXml: %s", err))
+		return false
+	}
+	actual, err := parseXML(body)
+	if err != nil {
+		return false
+	}
+	return xmlEqual(expected, actual)
+}
+
+func xmlEqual(a, b *xmlNode) bool {
+	if a.kind != b.kind || a.name != b.name {
+		return false
+	}
+	if a.kind == xmlText {
+		return strings.TrimSpace(a.value) == strings.TrimSpace(b.value)
+	}
+	if len(a.attrs) != len(b.attrs) || len(a.children) != len(b.children) {
+		return false
+	}
+	for _, attr := range a.attrs {
+		if !slices.ContainsFunc(b.attrs, func(other *xmlNode) bool {
+			return other.name == attr.name && other.value == attr.value
+		}) {
+			return false
+		}
+	}
+	for i := range a.children {
+		if !xmlEqual(a.children[i], b.children[i]) {
+			return false
+		}
+	}
+	return true
+}
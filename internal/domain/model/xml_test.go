@@ -0,0 +1,97 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+func Test_BodyMatcherEqualToXML(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher model.Matcher
+		body    string
+		want    bool
+	}{
+		{
+			name:    "whitespace between elements is ignored",
+			matcher: model.Matcher{EqualToXML: `<order><id>1</id><item>Anvil</item></order>`},
+			body:    "<?xml version=\"1.0\"?>\n<order>\n  <id> 1 </id>\n  <item>Anvil</item>\n</order>\n",
+			want:    true,
+		},
+		{
+			name:    "attribute order is ignored",
+			matcher: model.Matcher{EqualToXML: `<item id="1" qty="2"/>`},
+			body:    `<item qty="2" id="1"></item>`,
+			want:    true,
+		},
+		{
+			name:    "namespace prefixes are ignored",
+			matcher: model.Matcher{EqualToXML: `<a:order xmlns:a="urn:orders"><a:id>1</a:id></a:order>`},
+			body:    `<order xmlns="urn:orders"><id>1</id></order>`,
+			want:    true,
+		},
+		{
+			name:    "different namespace",
+			matcher: model.Matcher{EqualToXML: `<order xmlns="urn:orders"/>`},
+			body:    `<order xmlns="urn:invoices"/>`,
+			want:    false,
+		},
+		{
+			name:    "comments are ignored",
+			matcher: model.Matcher{EqualToXML: `<order><id>1</id></order>`},
+			body:    `<order><!-- generated --><id>1</id></order>`,
+			want:    true,
+		},
+		{
+			name:    "different text",
+			matcher: model.Matcher{EqualToXML: `<order><id>1</id></order>`},
+			body:    `<order><id>2</id></order>`,
+			want:    false,
+		},
+		{
+			name:    "different attribute value",
+			matcher: model.Matcher{EqualToXML: `<item id="1"/>`},
+			body:    `<item id="2"/>`,
+			want:    false,
+		},
+		{
+			name:    "extra attribute",
+			matcher: model.Matcher{EqualToXML: `<item id="1"/>`},
+			body:    `<item id="1" qty="2"/>`,
+			want:    false,
+		},
+		{
+			name:    "element order matters",
+			matcher: model.Matcher{EqualToXML: `<order><id>1</id><item>Anvil</item></order>`},
+			body:    `<order><item>Anvil</item><id>1</id></order>`,
+			want:    false,
+		},
+		{
+			name:    "missing element",
+			matcher: model.Matcher{EqualToXML: `<order><id>1</id><item>Anvil</item></order>`},
+			body:    `<order><id>1</id></order>`,
+			want:    false,
+		},
+		{
+			name:    "body is not XML",
+			matcher: model.Matcher{EqualToXML: `<order/>`},
+			body:    `order`,
+			want:    false,
+		},
+		{
+			name:    "combined with matchesXPath",
+			matcher: model.Matcher{EqualToXML: `<order><id>1</id></order>`, MatchesXPath: []model.XPathMatcher{{Expression: "/order/id", Matcher: model.Matcher{EqualTo: "2"}}}},
+			body:    `<order><id>1</id></order>`,
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{Body: tt.matcher}}
+			if got := e.BodyMatcher(tt.body); got != tt.want {
+				t.Errorf("BodyMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,707 @@
+package model
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// XPathMatcher selects nodes from an XML body with an XPath expression.
+// Without a nested matcher the expression only has to select something,
+// otherwise the string value of at least one selected node has to satisfy the matcher.
+// In JSON it can also be written as a plain expression string.
+type XPathMatcher struct {
+	Expression string `json:"expression"`
+	Matcher
+}
+
+func (m *XPathMatcher) UnmarshalJSON(data []byte) error {
+	var expression string
+	if err := json.Unmarshal(data, &expression); err == nil {
+		*m = XPathMatcher{Expression: expression}
+		return nil
+	}
+	// plain has the same fields without this method, so the default decoding applies
+	type plain XPathMatcher
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*m = XPathMatcher(p)
+	return nil
+}
+
+// match evaluates the expression with the given namespace bindings (prefix -> URI).
+func (m XPathMatcher) match(body string, namespaces map[string]string) bool {
+	doc, err := parseXML(body)
+	if err != nil {
+		return false
+	}
+	nodes, err := evaluateXPath(m.Expression, doc, namespaces)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to evaluate XPath: %s", err))
+		return false
+	}
+	if len(nodes) == 0 {
+		return false
+	}
+	if m.Matcher.isZero() {
+		return true
+	}
+	for _, n := range nodes {
+		if m.Matcher.matchValue(n.stringValue(), true) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateXPath returns the nodes selected by a location path such as /a/b, //b[@id='1'] or //b/text().
+// Unprefixed names match elements in any namespace, prefixed names only in the namespace bound to the prefix.
+func evaluateXPath(expression string, doc *xmlNode, namespaces map[string]string) ([]*xmlNode, error) {
+	path, err := parseXPath(expression, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	return path.evaluate(doc), nil
+}
+
+func parseXPath(expression string, namespaces map[string]string) (xpathLocationPath, error) {
+	p := &xpathParser{lexer: xpathLexer{s: strings.TrimSpace(expression)}, namespaces: namespaces}
+	p.advance()
+	if p.tok.kind != xpathPath {
+		return xpathLocationPath{}, fmt.Errorf("XPath must be a location path: %s", expression)
+	}
+	path, err := p.path(p.tok.text)
+	if err != nil {
+		return xpathLocationPath{}, fmt.Errorf("invalid XPath %s: %w", expression, err)
+	}
+	p.advance()
+	if p.tok.kind != xpathEOF {
+		return xpathLocationPath{}, fmt.Errorf("invalid XPath %s: unexpected %q", expression, p.tok.text)
+	}
+	return path, nil
+}
+
+type xpathAxis int
+
+const (
+	xpathChild xpathAxis = iota
+	xpathDescendantOrSelf
+	xpathAttribute
+	xpathSelf
+	xpathParent
+)
+
+// xpathNodeTest is the part of a step that selects nodes by kind and name.
+type xpathNodeTest struct {
+	text      bool   // text()
+	node      bool   // node()
+	local     string // "*" for any name
+	space     string // namespace URI
+	anySpace  bool   // unprefixed names match in any namespace
+	anyLocal  bool
+	emptyTest bool // . and ..
+}
+
+func (t xpathNodeTest) matches(n *xmlNode) bool {
+	switch {
+	case t.emptyTest, t.node:
+		return true
+	case t.text:
+		return n.kind == xmlText
+	}
+	if n.kind != xmlElement && n.kind != xmlAttribute {
+		return false
+	}
+	return (t.anyLocal || n.name.Local == t.local) && (t.anySpace || n.name.Space == t.space)
+}
+
+type xpathStep struct {
+	axis       xpathAxis
+	test       xpathNodeTest
+	predicates []xpathExpr
+}
+
+type xpathLocationPath struct {
+	absolute bool
+	steps    []xpathStep
+}
+
+func (lp xpathLocationPath) evaluate(context *xmlNode) []*xmlNode {
+	nodes := []*xmlNode{context}
+	if lp.absolute {
+		root := context
+		for root.parent != nil {
+			root = root.parent
+		}
+		nodes = []*xmlNode{root}
+	}
+	for _, step := range lp.steps {
+		var next []*xmlNode
+		for _, n := range nodes {
+			candidates := slices.DeleteFunc(step.candidates(n), func(c *xmlNode) bool { return !step.test.matches(c) })
+			for _, pred := range step.predicates {
+				var kept []*xmlNode
+				for i, c := range candidates {
+					v := pred(xpathContext{node: c, position: i + 1, size: len(candidates)})
+					if f, ok := v.(float64); ok {
+						if f == float64(i+1) {
+							kept = append(kept, c)
+						}
+					} else if xpathBool(v) {
+						kept = append(kept, c)
+					}
+				}
+				candidates = kept
+			}
+			next = append(next, candidates...)
+		}
+		// keep document order without duplicates
+		slices.SortFunc(next, func(a, b *xmlNode) int { return cmp.Compare(a.order, b.order) })
+		nodes = slices.Compact(next)
+	}
+	return nodes
+}
+
+func (s xpathStep) candidates(n *xmlNode) []*xmlNode {
+	switch s.axis {
+	case xpathSelf:
+		return []*xmlNode{n}
+	case xpathParent:
+		if n.parent == nil {
+			return nil
+		}
+		return []*xmlNode{n.parent}
+	case xpathAttribute:
+		return slices.Clone(n.attrs)
+	case xpathDescendantOrSelf:
+		ret := []*xmlNode{n}
+		for _, c := range n.children {
+			ret = append(ret, s.candidates(c)...)
+		}
+		return ret
+	default:
+		return slices.Clone(n.children)
+	}
+}
+
+// xpathContext is the node a predicate is evaluated for, with its position among the candidates.
+type xpathContext struct {
+	node     *xmlNode
+	position int
+	size     int
+}
+
+// xpathExpr evaluates to []*xmlNode, string, float64 or bool.
+type xpathExpr func(xpathContext) any
+
+type xpathTokenKind int
+
+const (
+	xpathEOF xpathTokenKind = iota
+	xpathPath
+	xpathStringToken
+	xpathNumberToken
+	xpathOperator
+	xpathFunction
+	xpathLParen
+	xpathRParen
+	xpathComma
+)
+
+type xpathToken struct {
+	kind xpathTokenKind
+	text string
+}
+
+type xpathLexer struct {
+	s   string
+	pos int
+}
+
+var xpathFunctions = []string{"contains", "starts-with", "not", "last", "position", "normalize-space", "string-length"}
+
+func (l *xpathLexer) next() xpathToken {
+	for l.pos < len(l.s) && (l.s[l.pos] == ' ' || l.s[l.pos] == '\t' || l.s[l.pos] == '\n') {
+		l.pos++
+	}
+	if l.pos >= len(l.s) {
+		return xpathToken{kind: xpathEOF}
+	}
+	c := l.s[l.pos]
+	switch {
+	case c == '\'' || c == '"':
+		end := strings.IndexByte(l.s[l.pos+1:], c)
+		if end == -1 {
+			text := l.s[l.pos+1:]
+			l.pos = len(l.s)
+			return xpathToken{kind: xpathStringToken, text: text}
+		}
+		text := l.s[l.pos+1 : l.pos+1+end]
+		l.pos += end + 2
+		return xpathToken{kind: xpathStringToken, text: text}
+	case c >= '0' && c <= '9':
+		start := l.pos
+		for l.pos < len(l.s) && (l.s[l.pos] >= '0' && l.s[l.pos] <= '9' || l.s[l.pos] == '.') {
+			l.pos++
+		}
+		return xpathToken{kind: xpathNumberToken, text: l.s[start:l.pos]}
+	case c == '(':
+		l.pos++
+		return xpathToken{kind: xpathLParen, text: "("}
+	case c == ')':
+		l.pos++
+		return xpathToken{kind: xpathRParen, text: ")"}
+	case c == ',':
+		l.pos++
+		return xpathToken{kind: xpathComma, text: ","}
+	case strings.ContainsRune("=!<>", rune(c)):
+		start := l.pos
+		l.pos++
+		if l.pos < len(l.s) && l.s[l.pos] == '=' {
+			l.pos++
+		}
+		return xpathToken{kind: xpathOperator, text: l.s[start:l.pos]}
+	}
+	start := l.pos
+	depth := 0
+	for l.pos < len(l.s) {
+		c := l.s[l.pos]
+		if c == '[' {
+			depth++
+		} else if c == ']' {
+			depth--
+		} else if c == '\'' || c == '"' {
+			if end := strings.IndexByte(l.s[l.pos+1:], c); end != -1 {
+				l.pos += end + 1
+			}
+		} else if depth == 0 {
+			if c == '(' && (strings.HasSuffix(l.s[start:l.pos], "text") || strings.HasSuffix(l.s[start:l.pos], "node")) {
+				l.pos += 2 // ()
+				continue
+			}
+			if strings.ContainsRune(" \t\n=!<>(),", rune(c)) {
+				break
+			}
+		}
+		l.pos++
+	}
+	text := l.s[start:l.pos]
+	if slices.Contains(xpathFunctions, text) && strings.HasPrefix(strings.TrimLeft(l.s[l.pos:], " "), "(") {
+		return xpathToken{kind: xpathFunction, text: text}
+	}
+	if text == "and" || text == "or" {
+		return xpathToken{kind: xpathOperator, text: text}
+	}
+	return xpathToken{kind: xpathPath, text: text}
+}
+
+type xpathParser struct {
+	lexer      xpathLexer
+	tok        xpathToken
+	namespaces map[string]string
+}
+
+func (p *xpathParser) advance() {
+	p.tok = p.lexer.next()
+}
+
+// path parses a location path token such as /a/b[1]//c/@id.
+func (p *xpathParser) path(s string) (xpathLocationPath, error) {
+	var lp xpathLocationPath
+	if strings.HasPrefix(s, "/") {
+		lp.absolute = true
+	}
+	descendant := false
+	for _, part := range splitXPathSteps(s) {
+		if part == "" {
+			// an empty step between two slashes is the // abbreviation of /descendant-or-self::node()/,
+			// so that the predicates of the next step are applied among the children of each node
+			lp.steps = append(lp.steps, xpathStep{axis: xpathDescendantOrSelf, test: xpathNodeTest{node: true}})
+			descendant = true
+			continue
+		}
+		step, err := p.step(part)
+		if err != nil {
+			return xpathLocationPath{}, err
+		}
+		lp.steps = append(lp.steps, step)
+		descendant = false
+	}
+	if descendant {
+		return xpathLocationPath{}, fmt.Errorf("location path must not end with /")
+	}
+	return lp, nil
+}
+
+// splitXPathSteps splits a location path on the slashes that are not inside predicates or quotes.
+// A leading slash is dropped and // produces an empty step.
+func splitXPathSteps(s string) []string {
+	s = strings.TrimPrefix(s, "/")
+	var ret []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '/' && depth == 0:
+			ret = append(ret, s[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(s) || len(ret) == 0 && s != "" {
+		ret = append(ret, s[start:])
+	}
+	return ret
+}
+
+func (p *xpathParser) step(s string) (xpathStep, error) {
+	test := s
+	var predicates []string
+	if i := strings.IndexByte(s, '['); i != -1 {
+		test = s[:i]
+		rest := s[i:]
+		for rest != "" {
+			if rest[0] != '[' {
+				return xpathStep{}, fmt.Errorf("invalid step: %s", s)
+			}
+			end := closingBracket(rest)
+			if end == -1 {
+				return xpathStep{}, fmt.Errorf("missing ] in %s", s)
+			}
+			predicates = append(predicates, rest[1:end])
+			rest = rest[end+1:]
+		}
+	}
+
+	step := xpathStep{axis: xpathChild}
+	switch {
+	case test == ".":
+		step.axis, step.test = xpathSelf, xpathNodeTest{emptyTest: true}
+	case test == "..":
+		step.axis, step.test = xpathParent, xpathNodeTest{emptyTest: true}
+	case test == "text()":
+		step.test = xpathNodeTest{text: true}
+	case test == "node()":
+		step.test = xpathNodeTest{node: true}
+	case strings.HasPrefix(test, "@"):
+		step.axis = xpathAttribute
+		nt, err := p.nameTest(test[1:])
+		if err != nil {
+			return xpathStep{}, err
+		}
+		// unprefixed attributes have no namespace
+		if !strings.Contains(test, ":") && nt.local != "*" {
+			nt.anySpace = false
+		}
+		step.test = nt
+	default:
+		nt, err := p.nameTest(test)
+		if err != nil {
+			return xpathStep{}, err
+		}
+		step.test = nt
+	}
+
+	for _, pred := range predicates {
+		sub := &xpathParser{lexer: xpathLexer{s: pred}, namespaces: p.namespaces}
+		sub.advance()
+		expr, err := sub.or()
+		if err != nil {
+			return xpathStep{}, err
+		}
+		if sub.tok.kind != xpathEOF {
+			return xpathStep{}, fmt.Errorf("unexpected %q in predicate [%s]", sub.tok.text, pred)
+		}
+		step.predicates = append(step.predicates, expr)
+	}
+	return step, nil
+}
+
+func (p *xpathParser) nameTest(name string) (xpathNodeTest, error) {
+	if name == "" {
+		return xpathNodeTest{}, fmt.Errorf("missing name")
+	}
+	prefix, local, prefixed := strings.Cut(name, ":")
+	if !prefixed {
+		return xpathNodeTest{local: name, anyLocal: name == "*", anySpace: true}, nil
+	}
+	uri, ok := p.namespaces[prefix]
+	if !ok {
+		return xpathNodeTest{}, fmt.Errorf("namespace prefix %s is not bound", prefix)
+	}
+	return xpathNodeTest{local: local, anyLocal: local == "*", space: uri}, nil
+}
+
+// closingBracket returns the index of the ] that closes the [ at the start of s.
+func closingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (p *xpathParser) or() (xpathExpr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == xpathOperator && p.tok.text == "or" {
+		p.advance()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(c xpathContext) any { return xpathBool(l(c)) || xpathBool(right(c)) }
+	}
+	return left, nil
+}
+
+func (p *xpathParser) and() (xpathExpr, error) {
+	left, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == xpathOperator && p.tok.text == "and" {
+		p.advance()
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(c xpathContext) any { return xpathBool(l(c)) && xpathBool(right(c)) }
+	}
+	return left, nil
+}
+
+func (p *xpathParser) comparison() (xpathExpr, error) {
+	left, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != xpathOperator || p.tok.text == "and" || p.tok.text == "or" {
+		return left, nil
+	}
+	op := p.tok.text
+	p.advance()
+	right, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	return func(c xpathContext) any { return xpathCompare(left(c), op, right(c)) }, nil
+}
+
+func (p *xpathParser) primary() (xpathExpr, error) {
+	tok := p.tok
+	p.advance()
+	switch tok.kind {
+	case xpathStringToken:
+		return func(xpathContext) any { return tok.text }, nil
+	case xpathNumberToken:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", tok.text)
+		}
+		return func(xpathContext) any { return f }, nil
+	case xpathLParen:
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != xpathRParen {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.advance()
+		return expr, nil
+	case xpathPath:
+		lp, err := p.path(tok.text)
+		if err != nil {
+			return nil, err
+		}
+		return func(c xpathContext) any { return lp.evaluate(c.node) }, nil
+	case xpathFunction:
+		return p.function(tok.text)
+	default:
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+}
+
+func (p *xpathParser) function(name string) (xpathExpr, error) {
+	if p.tok.kind != xpathLParen {
+		return nil, fmt.Errorf("missing ( after %s", name)
+	}
+	p.advance()
+	var args []xpathExpr
+	for p.tok.kind != xpathRParen {
+		arg, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.tok.kind == xpathComma {
+			p.advance()
+		} else if p.tok.kind != xpathRParen {
+			return nil, fmt.Errorf("missing ) after arguments of %s", name)
+		}
+	}
+	p.advance()
+
+	// functions taking an optional string default to the string value of the context node
+	stringArg := func(c xpathContext, i int) string {
+		if i < len(args) {
+			return xpathString(args[i](c))
+		}
+		return c.node.stringValue()
+	}
+	want := map[string][2]int{
+		"contains": {2, 2}, "starts-with": {2, 2}, "not": {1, 1}, "last": {0, 0}, "position": {0, 0},
+		"normalize-space": {0, 1}, "string-length": {0, 1},
+	}[name]
+	if len(args) < want[0] || len(args) > want[1] {
+		return nil, fmt.Errorf("wrong number of arguments for %s", name)
+	}
+	switch name {
+	case "contains":
+		return func(c xpathContext) any { return strings.Contains(stringArg(c, 0), stringArg(c, 1)) }, nil
+	case "starts-with":
+		return func(c xpathContext) any { return strings.HasPrefix(stringArg(c, 0), stringArg(c, 1)) }, nil
+	case "not":
+		return func(c xpathContext) any { return !xpathBool(args[0](c)) }, nil
+	case "last":
+		return func(c xpathContext) any { return float64(c.size) }, nil
+	case "position":
+		return func(c xpathContext) any { return float64(c.position) }, nil
+	case "normalize-space":
+		return func(c xpathContext) any { return strings.Join(strings.Fields(stringArg(c, 0)), " ") }, nil
+	default: // string-length
+		return func(c xpathContext) any { return float64(len([]rune(stringArg(c, 0)))) }, nil
+	}
+}
+
+func xpathBool(v any) bool {
+	switch v := v.(type) {
+	case []*xmlNode:
+		return len(v) > 0
+	case string:
+		return v != ""
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case bool:
+		return v
+	default:
+		return false
+	}
+}
+
+func xpathString(v any) string {
+	switch v := v.(type) {
+	case []*xmlNode:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].stringValue()
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+func xpathNumber(v any) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	if b, ok := v.(bool); ok {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(xpathString(v)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// xpathCompare follows XPath 1.0: a node-set compares true if any of its nodes does.
+func xpathCompare(a any, op string, b any) bool {
+	if nodes, ok := a.([]*xmlNode); ok {
+		if _, isBool := b.(bool); !isBool {
+			return slices.ContainsFunc(nodes, func(n *xmlNode) bool { return xpathCompare(n.stringValue(), op, b) })
+		}
+	}
+	if nodes, ok := b.([]*xmlNode); ok {
+		if _, isBool := a.(bool); !isBool {
+			return slices.ContainsFunc(nodes, func(n *xmlNode) bool { return xpathCompare(a, op, n.stringValue()) })
+		}
+	}
+	_, aBool := a.(bool)
+	_, bBool := b.(bool)
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+	switch op {
+	case "=", "!=":
+		var equal bool
+		switch {
+		case aBool || bBool:
+			equal = xpathBool(a) == xpathBool(b)
+		case aNum || bNum:
+			equal = xpathNumber(a) == xpathNumber(b)
+		default:
+			equal = xpathString(a) == xpathString(b)
+		}
+		return equal == (op == "=")
+	}
+	x, y := xpathNumber(a), xpathNumber(b)
+	switch op {
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	case ">=":
+		return x >= y
+	default:
+		return false
+	}
+}
@@ -0,0 +1,203 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+const soapBody = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="http://example.com/stock">
+	<soap:Header/>
+	<soap:Body>
+		<m:GetStockPrice currency="USD">
+			<m:StockName>ACME</m:StockName>
+			<m:Item id="1" qty="2">Anvil</m:Item>
+			<m:Item id="2" qty="10">Rocket</m:Item>
+		</m:GetStockPrice>
+	</soap:Body>
+</soap:Envelope>`
+
+var stockNamespaces = map[string]string{
+	"soap":  "http://schemas.xmlsoap.org/soap/envelope/",
+	"stock": "http://example.com/stock",
+}
+
+func Test_BodyMatcherXPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		matchers   []model.XPathMatcher
+		namespaces map[string]string
+		body       string
+		want       bool
+	}{
+		{
+			name:     "element exists",
+			matchers: []model.XPathMatcher{{Expression: "/Envelope/Body/GetStockPrice/StockName"}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "element does not exist",
+			matchers: []model.XPathMatcher{{Expression: "//GetStockQuote"}},
+			body:     soapBody,
+			want:     false,
+		},
+		{
+			name:     "selected text equalTo",
+			matchers: []model.XPathMatcher{{Expression: "//StockName/text()", Matcher: model.Matcher{EqualTo: "ACME"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "selected element does not match",
+			matchers: []model.XPathMatcher{{Expression: "//StockName", Matcher: model.Matcher{EqualTo: "EMCA"}}},
+			body:     soapBody,
+			want:     false,
+		},
+		{
+			name:     "any selected node may match",
+			matchers: []model.XPathMatcher{{Expression: "//Item", Matcher: model.Matcher{EqualTo: "Rocket"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "attribute",
+			matchers: []model.XPathMatcher{{Expression: "//GetStockPrice/@currency", Matcher: model.Matcher{Matches: "^[A-Z]{3}$"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "attribute predicate",
+			matchers: []model.XPathMatcher{{Expression: "//Item[@id='2']", Matcher: model.Matcher{EqualTo: "Rocket"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "numeric predicate",
+			matchers: []model.XPathMatcher{{Expression: "//Item[@qty > 5 and @qty < 20]", Matcher: model.Matcher{EqualTo: "Rocket"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "position predicate",
+			matchers: []model.XPathMatcher{{Expression: "//Item[1]", Matcher: model.Matcher{EqualTo: "Anvil"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "position predicate applies per parent",
+			matchers: []model.XPathMatcher{{Expression: "//b[1]", Matcher: model.Matcher{EqualTo: "second"}}},
+			body:     `<root><a><b>first</b><b>x</b></a><a><b>second</b><b>y</b></a></root>`,
+			want:     true,
+		},
+		{
+			name:     "last position predicate applies per parent",
+			matchers: []model.XPathMatcher{{Expression: "//b[last()]", Matcher: model.Matcher{EqualTo: "x"}}},
+			body:     `<root><a><b>first</b><b>x</b></a><a><b>second</b><b>y</b></a></root>`,
+			want:     true,
+		},
+		{
+			name:     "position predicate that is not an integer selects nothing",
+			matchers: []model.XPathMatcher{{Expression: "//Item[1.5]"}},
+			body:     soapBody,
+			want:     false,
+		},
+		{
+			name:     "last predicate",
+			matchers: []model.XPathMatcher{{Expression: "//Item[last()]/@id", Matcher: model.Matcher{EqualTo: "2"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "child element predicate with function",
+			matchers: []model.XPathMatcher{{Expression: "//GetStockPrice[starts-with(StockName, 'AC')]"}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:     "not predicate",
+			matchers: []model.XPathMatcher{{Expression: "//Item[not(@id='1')]", Matcher: model.Matcher{EqualTo: "Anvil"}}},
+			body:     soapBody,
+			want:     false,
+		},
+		{
+			name:     "parent step",
+			matchers: []model.XPathMatcher{{Expression: "//StockName/../@currency", Matcher: model.Matcher{EqualTo: "USD"}}},
+			body:     soapBody,
+			want:     true,
+		},
+		{
+			name:       "prefixed names with bound namespaces",
+			matchers:   []model.XPathMatcher{{Expression: "/soap:Envelope/soap:Body/stock:GetStockPrice/stock:StockName", Matcher: model.Matcher{EqualTo: "ACME"}}},
+			namespaces: stockNamespaces,
+			body:       soapBody,
+			want:       true,
+		},
+		{
+			name:       "prefixed name in another namespace",
+			matchers:   []model.XPathMatcher{{Expression: "//soap:StockName"}},
+			namespaces: stockNamespaces,
+			body:       soapBody,
+			want:       false,
+		},
+		{
+			name:     "unbound prefix",
+			matchers: []model.XPathMatcher{{Expression: "//stock:StockName"}},
+			body:     soapBody,
+			want:     false,
+		},
+		{
+			name: "every entry must hold",
+			matchers: []model.XPathMatcher{
+				{Expression: "//StockName", Matcher: model.Matcher{EqualTo: "ACME"}},
+				{Expression: "//Item", Matcher: model.Matcher{EqualTo: "Hammer"}},
+			},
+			body: soapBody,
+			want: false,
+		},
+		{
+			name:     "body is not XML",
+			matchers: []model.XPathMatcher{{Expression: "//StockName"}},
+			body:     `{"StockName": "ACME"}`,
+			want:     false,
+		},
+		{
+			name:     "invalid expression",
+			matchers: []model.XPathMatcher{{Expression: "//Item[@id='1'"}},
+			body:     soapBody,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.Endpoint{Request: model.Request{Body: model.Matcher{MatchesXPath: tt.matchers, XPathNamespaces: tt.namespaces}}}
+			if got := e.BodyMatcher(tt.body); got != tt.want {
+				t.Errorf("BodyMatcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_XPathMatcherUnmarshalJSON(t *testing.T) {
+	var got model.Matcher
+	data := `{
+		"matchesXPath": ["//StockName", {"expression": "//m:Item/@id", "equalTo": "1"}],
+		"xPathNamespaces": {"m": "http://example.com/stock"}
+	}`
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := model.Matcher{
+		MatchesXPath: []model.XPathMatcher{
+			{Expression: "//StockName"},
+			{Expression: "//m:Item/@id", Matcher: model.Matcher{EqualTo: "1"}},
+		},
+		XPathNamespaces: map[string]string{"m": "http://example.com/stock"},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("diff: %v", cmp.Diff(got, want))
+	}
+}
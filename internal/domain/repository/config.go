@@ -7,3 +7,11 @@ import (
 type ConfigRepository interface {
 	Load(path string) ([]model.Endpoint, error)
 }
+
+// StubWriter persists recorded stubs so that ConfigRepository can load them.
+type StubWriter interface {
+	// Save writes the endpoints as a configuration file. It does nothing and returns false when the file already exists.
+	Save(path string, endpoints []model.Endpoint) (bool, error)
+	// SaveBody writes a response body file referenced by bodyFileName.
+	SaveBody(path string, body []byte) error
+}
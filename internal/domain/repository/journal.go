@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// JournalRepository records the requests received by the stub server.
+type JournalRepository interface {
+	Add(request model.LoggedRequest)
+	// List returns the recorded requests, oldest first.
+	List() []model.LoggedRequest
+	Clear()
+}
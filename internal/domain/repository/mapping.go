@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+var (
+	ErrMappingNotFound = errors.New("mapping not found")
+	ErrMappingExists   = errors.New("mapping already exists")
+)
+
+// MappingRepository stores stub mappings registered at runtime through the admin API.
+type MappingRepository interface {
+	List() []model.Endpoint
+	Get(id string) (model.Endpoint, error)
+	Create(endpoint model.Endpoint) error // ErrMappingExists if a mapping has the same ID
+	Update(endpoint model.Endpoint) error // ErrMappingNotFound if no mapping has the same ID
+	Delete(id string) error
+	Reset()
+}
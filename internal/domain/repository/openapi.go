@@ -0,0 +1,10 @@
+package repository
+
+import (
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// OpenAPIRepository loads the OpenAPI document that requests and responses are validated against.
+type OpenAPIRepository interface {
+	Load(path string) (model.OpenAPI, error)
+}
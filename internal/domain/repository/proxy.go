@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// ProxyRepository forwards requests to another HTTP server.
+type ProxyRepository interface {
+	Forward(ctx context.Context, request model.ProxyRequest) (model.ProxyResponse, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+// ScenarioRepository holds the current state of each scenario.
+// A scenario that has never been moved is in model.ScenarioStarted.
+type ScenarioRepository interface {
+	State(name string) string
+	SetState(name, state string)
+	// CompareAndSwap moves the scenario to newState only if it is still in oldState.
+	CompareAndSwap(name, oldState, newState string) bool
+	// States returns the scenarios that have been moved, with their current state.
+	States() map[string]string
+	Reset()
+}
@@ -0,0 +1,106 @@
+package handler_test
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+func delayedUsecase(response model.Response) *mockEndpointUsecase {
+	return &mockEndpointUsecase{
+		endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+			return usecase.EndpointMatcherResult{
+				Endpoint:       model.Endpoint{Response: response},
+				ResponseStatus: http.StatusOK,
+			}, nil
+		},
+		responseCreatorFunc: func(args usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error) {
+			return usecase.ResponseCreatorResult{Template: template.Must(template.New("test").Parse("delayed"))}, nil
+		},
+	}
+}
+
+func milliseconds(ms int) *int {
+	return &ms
+}
+
+func TestHandle_Delay(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     model.Response
+		defaultDelay time.Duration
+		minElapsed   time.Duration
+		maxElapsed   time.Duration
+	}{
+		{
+			name:       "Stub delay",
+			response:   model.Response{FixedDelayMilliseconds: milliseconds(50)},
+			minElapsed: 50 * time.Millisecond,
+			maxElapsed: time.Second,
+		},
+		{
+			name:         "Default delay for a stub without one",
+			defaultDelay: 50 * time.Millisecond,
+			minElapsed:   50 * time.Millisecond,
+			maxElapsed:   time.Second,
+		},
+		{
+			name:         "Stub delay overrides the default delay",
+			response:     model.Response{FixedDelayMilliseconds: milliseconds(10)},
+			defaultDelay: 10 * time.Second,
+			minElapsed:   10 * time.Millisecond,
+			maxElapsed:   time.Second,
+		},
+		{
+			name:         "Distribution that samples zero does not fall back to the default delay",
+			response:     model.Response{DelayDistribution: &model.DelayDistribution{Type: model.DelayDistributionUniform}},
+			defaultDelay: 10 * time.Second,
+			maxElapsed:   time.Second,
+		},
+		{
+			name:         "Zero fixed delay opts out of the default delay",
+			response:     model.Response{FixedDelayMilliseconds: milliseconds(0)},
+			defaultDelay: 10 * time.Second,
+			maxElapsed:   time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, tt.defaultDelay, delayedUsecase(tt.response), &mockJournalRecorder{}, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+			w := httptest.NewRecorder()
+			start := time.Now()
+			h.Handle(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+			elapsed := time.Since(start)
+
+			if elapsed < tt.minElapsed || elapsed > tt.maxElapsed {
+				t.Errorf("Expected the response to take between %v and %v, took %v", tt.minElapsed, tt.maxElapsed, elapsed)
+			}
+			if w.Code != http.StatusOK || w.Body.String() != "delayed" {
+				t.Errorf("Unexpected response: %d %q", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandle_DelayCancelled(t *testing.T) {
+	h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, delayedUsecase(model.Response{FixedDelayMilliseconds: milliseconds(10000)}), &mockJournalRecorder{}, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	start := time.Now()
+	h.Handle(w, httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the delay to stop when the request is cancelled, took %v", elapsed)
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
@@ -1,25 +1,42 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dev-shimada/gostubby/internal/domain/model"
 	"github.com/dev-shimada/gostubby/internal/usecase"
 )
 
 type endpointHandler struct {
-	configPath string
-	eu         endpointUsecase
+	configPath     string
+	nearMissFormat string
+	defaultDelay   time.Duration // used for stubs that do not define a delay of their own
+	eu             endpointUsecase
+	ju             journalRecorder
+	pu             proxyUsecase
+	ou             openAPIUsecase
 }
 
-func NewEndpointHandler(configPath string, eu endpointUsecase) endpointHandler {
+func NewEndpointHandler(configPath string, nearMissFormat string, defaultDelay time.Duration, eu endpointUsecase, ju journalRecorder, pu proxyUsecase, ou openAPIUsecase) endpointHandler {
 	return endpointHandler{
-		configPath: configPath,
-		eu:         eu,
+		configPath:     configPath,
+		nearMissFormat: nearMissFormat,
+		defaultDelay:   defaultDelay,
+		eu:             eu,
+		ju:             ju,
+		pu:             pu,
+		ou:             ou,
 	}
 }
 
@@ -28,47 +45,136 @@ type endpointUsecase interface {
 	ResponseCreator(usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error)
 }
 
+type journalRecorder interface {
+	Record(model.LoggedRequest)
+}
+
+type proxyUsecase interface {
+	Recording() bool
+	Record(context.Context, usecase.ProxyArgs) (model.ProxyResponse, error)
+	Proxy(context.Context, model.Endpoint, usecase.ProxyArgs) (model.ProxyResponse, error)
+}
+
+type openAPIUsecase interface {
+	ValidateRequest(model.OpenAPIRequest) error
+	ValidateResponse(model.OpenAPIRequest, model.OpenAPIResponse) ([]model.OpenAPIError, error)
+}
+
 func (eh endpointHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	configPath := eh.configPath
+	receivedAt := time.Now()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to read request body: %s", err))
+		http.NotFound(w, r)
+		return
+	}
 	rqv, err := rawQueryValues(*r)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to parse query parameters: %s", err))
+		eh.record(r, nil, body, receivedAt, usecase.EndpointMatcherResult{})
 		http.NotFound(w, r)
 		return
 	}
 
 	EndpointMatcherArgs := usecase.EndpointMatcherArgs{
-		Request: struct {
-			UrlRawPath     string
-			UrlPath        string
-			Body           io.ReadCloser
-			Method         string
-			Headers        map[string][]string
-			RawQueryValues url.Values
-			QueryValues    url.Values
-		}{
+		Request: usecase.MatcherRequest{
 			UrlRawPath:     r.URL.RawPath,
 			UrlPath:        r.URL.Path,
-			Body:           r.Body,
+			Body:           io.NopCloser(bytes.NewReader(body)),
 			Method:         r.Method,
 			Headers:        r.Header,
 			RawQueryValues: rqv,
 			QueryValues:    r.URL.Query(),
+			Scheme:         requestScheme(r),
+			Host:           requestHost(r),
+			Port:           localPort(r),
+			URL:            requestScheme(r) + "://" + r.Host + r.URL.RequestURI(),
+			RemoteAddr:     r.RemoteAddr,
+			ReceivedAt:     receivedAt,
 		},
 		ConfigPath: configPath,
 	}
-	em, err := eh.eu.EndpointMatcher(EndpointMatcherArgs)
+	openAPIRequest := model.OpenAPIRequest{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Headers: r.Header,
+		Body:    string(body),
+	}
+	// a request that breaks the contract of the OpenAPI document is not matched against the stubs
+	var em usecase.EndpointMatcherResult
+	err = eh.ou.ValidateRequest(openAPIRequest)
+	if err == nil {
+		em, err = eh.eu.EndpointMatcher(EndpointMatcherArgs)
+	}
+	eh.record(r, rqv, body, receivedAt, em)
+	proxyArgs := usecase.ProxyArgs{
+		Method:         r.Method,
+		URL:            r.URL.RequestURI(),
+		Path:           r.URL.Path,
+		RawQueryValues: rqv,
+		Headers:        r.Header,
+		Body:           body,
+	}
+	var nm *usecase.NoMatchError
+	if errors.As(err, &nm) && len(nm.OpenAPIErrors) == 0 && eh.pu.Recording() {
+		resp, err := eh.pu.Record(r.Context(), proxyArgs)
+		// the upstream response is still relayed when only saving the stub failed
+		if err != nil && !errors.Is(err, usecase.ErrRecordNotSaved) {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeProxyResponse(w, resp)
+		return
+	}
+	if errors.As(err, &nm) {
+		attrs := []any{
+			slog.String("method", r.Method),
+			slog.String("url", r.URL.RequestURI()),
+			slog.Any("nearMisses", nm.NearMisses),
+		}
+		if len(nm.OpenAPIErrors) > 0 {
+			attrs = append(attrs, slog.Any("openApiErrors", nm.OpenAPIErrors))
+		}
+		slog.Error(fmt.Sprintf("Failed to match endpoint: %v", err), attrs...)
+		writeNoMatch(w, r, eh.nearMissFormat, nm)
+		return
+	}
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to match endpoint: %v", err))
 		http.NotFound(w, r)
 		return
 	}
-	w.WriteHeader(em.ResponseStatus)
+	delay := eh.defaultDelay
+	if em.Endpoint.Response.HasDelay() {
+		delay = em.Endpoint.Response.Delay()
+	}
+	if em.Endpoint.Response.Fault != "" {
+		if wait(w, r, delay) {
+			writeFault(w, em.Endpoint.Response.Fault)
+		}
+		return
+	}
+	if em.Endpoint.Response.ProxyBaseURL != "" {
+		resp, err := eh.pu.Proxy(r.Context(), em.Endpoint, proxyArgs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if !wait(w, r, delay) {
+			return
+		}
+		writeProxyResponse(w, resp)
+		return
+	}
 	ResponseCreatorArgs := usecase.ResponseCreatorArgs{
 		Request: struct {
 			UrlQuery url.Values
+			Body     string
 		}{
 			UrlQuery: r.URL.Query(),
+			Body:     string(body),
 		},
 		Endpoint:     em.Endpoint,
 		ResponseBody: em.ResponseBody,
@@ -79,11 +185,143 @@ func (eh endpointHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
-	if err := rc.Template.Execute(w, em.Data); err != nil {
+	// render everything before writing so that a template error can still be reported as 404
+	header := http.Header{}
+	for k, tpls := range rc.Headers {
+		for _, tpl := range tpls {
+			var v strings.Builder
+			if err := tpl.Execute(&v, em.Data); err != nil {
+				slog.Error(fmt.Sprintf("Failed to execute header template %s: %s", k, err))
+				http.NotFound(w, r)
+				return
+			}
+			header.Add(k, v.String())
+		}
+	}
+	var responseBody bytes.Buffer
+	if err := rc.Template.Execute(&responseBody, em.Data); err != nil {
 		slog.Error(fmt.Sprintf("Failed to execute template: %s", err))
 		http.NotFound(w, r)
 		return
 	}
+	// a stub that drifted from the contract is still served, so that the client sees what the stub says
+	violations, err := eh.ou.ValidateResponse(openAPIRequest, model.OpenAPIResponse{
+		Status:  em.ResponseStatus,
+		Headers: header,
+		Body:    responseBody.String(),
+	})
+	if err == nil && len(violations) > 0 {
+		slog.Error(fmt.Sprintf("Response of endpoint %s does not satisfy the OpenAPI document", em.Endpoint.ID),
+			slog.String("method", r.Method),
+			slog.String("url", r.URL.RequestURI()),
+			slog.Any("openApiErrors", violations),
+		)
+	}
+	if !wait(w, r, delay) {
+		return
+	}
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	if r.Method == http.MethodHead {
+		// the headers of the response that a GET request would get, without the body
+		if w.Header().Get("Content-Type") == "" && responseBody.Len() > 0 {
+			w.Header().Set("Content-Type", http.DetectContentType(responseBody.Bytes()))
+		}
+		if w.Header().Get("Content-Length") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(responseBody.Len()))
+		}
+		w.WriteHeader(em.ResponseStatus)
+		return
+	}
+	w.WriteHeader(em.ResponseStatus)
+	if _, err := responseBody.WriteTo(w); err != nil {
+		slog.Error(fmt.Sprintf("Failed to write response: %s", err))
+	}
+}
+
+// wait delays the response, and gives up when the request is cancelled or the server shuts down.
+// It reports whether the response should still be written.
+func wait(w http.ResponseWriter, r *http.Request, delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		slog.Info(fmt.Sprintf("Delayed response cancelled: %s", r.Context().Err()))
+		http.Error(w, "response cancelled", http.StatusServiceUnavailable)
+		return false
+	}
+}
+
+// writeProxyResponse relays a response received from another server.
+func writeProxyResponse(w http.ResponseWriter, resp model.ProxyResponse) {
+	for k, values := range resp.Headers {
+		w.Header()[k] = values
+	}
+	w.WriteHeader(resp.Status)
+	if _, err := w.Write(resp.Body); err != nil {
+		slog.Error(fmt.Sprintf("Failed to write response: %s", err))
+	}
+}
+
+// record adds the request to the request journal together with the endpoint it matched, if any.
+func (eh endpointHandler) record(r *http.Request, rqv url.Values, body []byte, receivedAt time.Time, matched usecase.EndpointMatcherResult) {
+	var match *model.MatchInfo
+	if matched.Match.Reason != "" {
+		match = &matched.Match
+	}
+	eh.ju.Record(model.LoggedRequest{
+		Method:            r.Method,
+		URL:               r.URL.RequestURI(),
+		RawPath:           r.URL.RawPath,
+		Path:              r.URL.Path,
+		RawQuery:          rqv,
+		Query:             r.URL.Query(),
+		Headers:           r.Header,
+		Body:              string(body),
+		Scheme:            requestScheme(r),
+		Host:              requestHost(r),
+		Port:              localPort(r),
+		MatchedEndpointID: matched.Endpoint.ID,
+		MatchedEndpoint:   matched.Endpoint.Name,
+		Match:             match,
+		LoggedAt:          receivedAt,
+	})
+}
+
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestHost returns the Host header without the port, in lower case.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// localPort returns the port of the listener that received the request,
+// or the port in the Host header when the listener is unknown.
+func localPort(r *http.Request) string {
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		if _, port, err := net.SplitHostPort(addr.String()); err == nil {
+			return port
+		}
+	}
+	if _, port, err := net.SplitHostPort(r.Host); err == nil {
+		return port
+	}
+	return ""
 }
 
 // rawQueryValues parses the raw query string from the request URL and returns a url.Values map.
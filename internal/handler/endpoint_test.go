@@ -3,11 +3,14 @@ package handler_test
 import (
 	"errors"
 	"html/template"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	texttemplate "text/template"
 
 	"github.com/dev-shimada/gostubby/internal/domain/model"
 	"github.com/dev-shimada/gostubby/internal/handler"
@@ -27,17 +30,26 @@ func (m *mockEndpointUsecase) ResponseCreator(args usecase.ResponseCreatorArgs)
 	return m.responseCreatorFunc(args)
 }
 
+type mockJournalRecorder struct {
+	recorded []model.LoggedRequest
+}
+
+func (m *mockJournalRecorder) Record(r model.LoggedRequest) {
+	m.recorded = append(m.recorded, r)
+}
+
 func TestHandle(t *testing.T) {
 	tests := []struct {
-		name           string
-		configPath     string
-		request        *http.Request
-		matcherResult  usecase.EndpointMatcherResult
-		matcherErr     error
-		creatorResult  usecase.ResponseCreatorResult
-		creatorErr     error
-		expectedStatus int
-		expectedBody   string
+		name            string
+		configPath      string
+		request         *http.Request
+		matcherResult   usecase.EndpointMatcherResult
+		matcherErr      error
+		creatorResult   usecase.ResponseCreatorResult
+		creatorErr      error
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders http.Header
 	}{
 		{
 			name:       "Successful request",
@@ -49,11 +61,7 @@ func TestHandle(t *testing.T) {
 				},
 				ResponseStatus: http.StatusOK,
 				ResponseBody:   "template content",
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
+				Data: usecase.TemplateData{
 					Path:  map[string]string{"id": "123"},
 					Query: map[string]string{"param": "value"},
 				},
@@ -66,6 +74,47 @@ func TestHandle(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   "Hello 123",
 		},
+		{
+			name:       "Templated response headers",
+			configPath: "test/config.json",
+			request:    httptest.NewRequest(http.MethodPost, "/users/123", nil),
+			matcherResult: usecase.EndpointMatcherResult{
+				Endpoint: model.Endpoint{
+					Name: "test-endpoint",
+				},
+				ResponseStatus: http.StatusCreated,
+				Data: usecase.TemplateData{
+					Path: map[string]string{"id": "123"},
+				},
+			},
+			creatorResult: usecase.ResponseCreatorResult{
+				Template: template.Must(template.New("test").Parse("")),
+				Headers: map[string][]*texttemplate.Template{
+					"Location":   {texttemplate.Must(texttemplate.New("Location").Parse("/users/{{.Path.id}}"))},
+					"Set-Cookie": {texttemplate.Must(texttemplate.New("Set-Cookie").Parse("a=1")), texttemplate.Must(texttemplate.New("Set-Cookie").Parse("b=2"))},
+				},
+			},
+			expectedStatus: http.StatusCreated,
+			expectedHeaders: http.Header{
+				"Location":   {"/users/123"},
+				"Set-Cookie": {"a=1", "b=2"},
+			},
+		},
+		{
+			name:       "Header template error",
+			configPath: "test/config.json",
+			request:    httptest.NewRequest(http.MethodGet, "/test", nil),
+			matcherResult: usecase.EndpointMatcherResult{
+				ResponseStatus: http.StatusOK,
+			},
+			creatorResult: usecase.ResponseCreatorResult{
+				Template: template.Must(template.New("test").Parse("ok")),
+				Headers: map[string][]*texttemplate.Template{
+					"X-Broken": {texttemplate.Must(texttemplate.New("X-Broken").Parse("{{.Missing.field}}"))},
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name:           "EndpointMatcher error",
 			configPath:     "test/config.json",
@@ -83,11 +132,7 @@ func TestHandle(t *testing.T) {
 				},
 				ResponseStatus: http.StatusNotFound,
 				ResponseBody:   "template content",
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
+				Data: usecase.TemplateData{
 					Path:  map[string]string{},
 					Query: map[string]string{},
 				},
@@ -116,10 +161,18 @@ func TestHandle(t *testing.T) {
 				},
 			}
 
-			handler := handler.NewEndpointHandler(tt.configPath, mockUsecase)
+			journal := &mockJournalRecorder{}
+			handler := handler.NewEndpointHandler(tt.configPath, handler.NearMissFormatNone, 0, mockUsecase, journal, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
 			w := httptest.NewRecorder()
 			handler.Handle(w, tt.request)
 
+			if len(journal.recorded) != 1 {
+				t.Fatalf("Expected 1 journal entry, got %d", len(journal.recorded))
+			}
+			if got := journal.recorded[0]; got.Method != tt.request.Method || got.URL != tt.request.URL.RequestURI() || got.MatchedEndpoint != tt.matcherResult.Endpoint.Name {
+				t.Errorf("Unexpected journal entry: %+v", got)
+			}
+
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
 			}
@@ -129,6 +182,12 @@ func TestHandle(t *testing.T) {
 					t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
 				}
 			}
+
+			for k, want := range tt.expectedHeaders {
+				if got := w.Header().Values(k); !reflect.DeepEqual(got, want) {
+					t.Errorf("Expected header %s %v, got %v", k, want, got)
+				}
+			}
 		})
 	}
 }
@@ -198,3 +257,86 @@ func Test_rawQueryValues(t *testing.T) {
 		})
 	}
 }
+
+func TestHandle_JournalBody(t *testing.T) {
+	var matcherBody string
+	mockUsecase := &mockEndpointUsecase{
+		endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+			b, _ := io.ReadAll(args.Request.Body)
+			matcherBody = string(b)
+			return usecase.EndpointMatcherResult{
+				Endpoint:       model.Endpoint{ID: "orders", Name: "create order"},
+				ResponseStatus: http.StatusCreated,
+				Match:          model.MatchInfo{Priority: 5, Reason: "the only matching endpoint"},
+			}, nil
+		},
+		responseCreatorFunc: func(args usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error) {
+			return usecase.ResponseCreatorResult{Template: template.Must(template.New("test").Parse(""))}, nil
+		},
+	}
+	journal := &mockJournalRecorder{}
+	h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, mockUsecase, journal, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+	req := httptest.NewRequest(http.MethodPost, "/orders?tenant=acme", strings.NewReader(`{"item": 1}`))
+	req.Header.Set("X-Tenant", "acme")
+	h.Handle(httptest.NewRecorder(), req)
+
+	if matcherBody != `{"item": 1}` {
+		t.Errorf("Expected matcher to receive the body, got %q", matcherBody)
+	}
+	got := journal.recorded[0]
+	if got.Body != `{"item": 1}` || got.Path != "/orders" || got.Query.Get("tenant") != "acme" ||
+		got.Headers["X-Tenant"][0] != "acme" || got.MatchedEndpointID != "orders" || got.LoggedAt.IsZero() ||
+		got.Match == nil || got.Match.Reason != "the only matching endpoint" {
+		t.Errorf("Unexpected journal entry: %+v", got)
+	}
+}
+
+func TestHandle_RequestOrigin(t *testing.T) {
+	var got usecase.MatcherRequest
+	mockUsecase := &mockEndpointUsecase{
+		endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+			got = args.Request
+			return usecase.EndpointMatcherResult{ResponseStatus: http.StatusOK}, nil
+		},
+		responseCreatorFunc: func(args usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error) {
+			return usecase.ResponseCreatorResult{Template: template.Must(template.New("test").Parse(""))}, nil
+		},
+	}
+	journal := &mockJournalRecorder{}
+	h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, mockUsecase, journal, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+	h.Handle(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "https://API.example.com:8443/health", nil))
+
+	if got.Scheme != "https" || got.Host != "api.example.com" || got.Port != "8443" {
+		t.Errorf("Unexpected request origin: scheme %q, host %q, port %q", got.Scheme, got.Host, got.Port)
+	}
+	if r := journal.recorded[0]; r.Scheme != "https" || r.Host != "api.example.com" || r.Port != "8443" {
+		t.Errorf("Unexpected journal entry: %+v", r)
+	}
+}
+
+func TestHandle_Head(t *testing.T) {
+	mockUsecase := &mockEndpointUsecase{
+		endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+			return usecase.EndpointMatcherResult{ResponseStatus: http.StatusOK}, nil
+		},
+		responseCreatorFunc: func(args usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error) {
+			return usecase.ResponseCreatorResult{Template: template.Must(template.New("test").Parse("Hello"))}, nil
+		},
+	}
+	h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, mockUsecase, &mockJournalRecorder{}, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+	w := httptest.NewRecorder()
+	h.Handle(w, httptest.NewRequest(http.MethodHead, "/greeting", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected no body, got %q", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Length"); got != "5" {
+		t.Errorf("Expected Content-Length 5, got %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Expected the Content-Type of the body, got %q", got)
+	}
+}
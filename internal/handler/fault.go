@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// randomDataLength is the number of random bytes sent by RANDOM_DATA_THEN_CLOSE.
+const randomDataLength = 256
+
+// writeFault breaks the connection in the way described by the fault instead of sending a response.
+func writeFault(w http.ResponseWriter, fault string) {
+	switch fault {
+	case model.FaultConnectionResetByPeer, model.FaultEmptyResponse, model.FaultMalformedResponseChunk, model.FaultRandomDataThenClose:
+	default:
+		slog.Error(fmt.Sprintf("Unknown fault: %s", fault))
+		http.Error(w, fmt.Sprintf("unknown fault: %s", fault), http.StatusInternalServerError)
+		return
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		// HTTP/2 connections cannot be hijacked; aborting the handler resets the stream instead
+		slog.Info(fmt.Sprintf("Connection cannot be hijacked, aborting the stream for fault %s", fault))
+		panic(http.ErrAbortHandler)
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to hijack connection: %s", err))
+		panic(http.ErrAbortHandler)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			slog.Error(fmt.Sprintf("Failed to close connection: %s", err))
+		}
+	}()
+
+	switch fault {
+	case model.FaultConnectionResetByPeer:
+		// a TLS connection is reset underneath, without sending close_notify first
+		if tc, ok := conn.(*tls.Conn); ok {
+			conn = tc.NetConn()
+		}
+		// discarding unsent data on close makes the kernel send RST instead of FIN
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			if err := tcp.SetLinger(0); err != nil {
+				slog.Error(fmt.Sprintf("Failed to set linger: %s", err))
+			}
+		}
+		return
+	case model.FaultEmptyResponse:
+		return
+	case model.FaultMalformedResponseChunk:
+		_, err = buf.WriteString("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nlorem ipsum\r\n")
+	case model.FaultRandomDataThenClose:
+		data := make([]byte, randomDataLength)
+		_, _ = rand.Read(data)
+		_, err = buf.Write(data)
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to write fault %s: %s", fault, err))
+	}
+}
@@ -0,0 +1,118 @@
+package handler_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+func TestHandle_Fault(t *testing.T) {
+	tests := []struct {
+		name           string
+		fault          string
+		wantErr        bool // the client fails before it gets a response
+		errContains    string
+		wantBodyErr    bool // the client gets a response but fails to read the body
+		expectedStatus int
+	}{
+		{
+			name:        "Connection reset by peer",
+			fault:       model.FaultConnectionResetByPeer,
+			wantErr:     true,
+			errContains: "connection reset by peer",
+		},
+		{
+			name:        "Empty response",
+			fault:       model.FaultEmptyResponse,
+			wantErr:     true,
+			errContains: "EOF",
+		},
+		{
+			name:           "Malformed response chunk",
+			fault:          model.FaultMalformedResponseChunk,
+			wantBodyErr:    true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "Random data then close",
+			fault:   model.FaultRandomDataThenClose,
+			wantErr: true,
+		},
+		{
+			name:           "Unknown fault",
+			fault:          "TIMEOUT",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &mockEndpointUsecase{
+				endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+					return usecase.EndpointMatcherResult{
+						Endpoint: model.Endpoint{Response: model.Response{Fault: tt.fault}},
+					}, nil
+				},
+				responseCreatorFunc: func(args usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error) {
+					t.Fatal("ResponseCreator must not be called for a fault")
+					return usecase.ResponseCreatorResult{}, nil
+				},
+			}
+			h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, mockUsecase, &mockJournalRecorder{}, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+			srv := httptest.NewServer(http.HandlerFunc(h.Handle))
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL + "/broken")
+			if tt.wantErr {
+				if err == nil {
+					_ = resp.Body.Close()
+					t.Fatalf("Expected the request to fail, got status %d", resp.StatusCode)
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if _, err := io.ReadAll(resp.Body); (err != nil) != tt.wantBodyErr {
+				t.Errorf("Reading the body: error = %v, wantBodyErr %v", err, tt.wantBodyErr)
+			}
+		})
+	}
+}
+
+func TestHandle_FaultTLS(t *testing.T) {
+	mockUsecase := &mockEndpointUsecase{
+		endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+			return usecase.EndpointMatcherResult{
+				Endpoint: model.Endpoint{Response: model.Response{Fault: model.FaultConnectionResetByPeer}},
+			}, nil
+		},
+	}
+	h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, mockUsecase, &mockJournalRecorder{}, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(h.Handle))
+	defer srv.Close()
+
+	// the reset must not turn into a graceful close on the HTTPS listener
+	resp, err := srv.Client().Get(srv.URL + "/broken")
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatalf("Expected the request to fail, got status %d", resp.StatusCode)
+	}
+	if !strings.Contains(err.Error(), "connection reset by peer") {
+		t.Errorf("Expected error containing %q, got %v", "connection reset by peer", err)
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+// journalHandler serves the /__admin/requests API.
+type journalHandler struct {
+	ju journalUsecase
+}
+
+func NewJournalHandler(ju journalUsecase) journalHandler {
+	return journalHandler{
+		ju: ju,
+	}
+}
+
+type journalUsecase interface {
+	List(usecase.JournalFilter) []model.LoggedRequest
+	Clear()
+	Find(model.Request) ([]model.LoggedRequest, error)
+	Count(model.Request) (int, error)
+}
+
+type requestList struct {
+	Requests []model.LoggedRequest `json:"requests"`
+	Meta     struct {
+		Total int `json:"total"`
+	} `json:"meta"`
+}
+
+func newRequestList(requests []model.LoggedRequest) requestList {
+	var ret requestList
+	ret.Requests = requests
+	ret.Meta.Total = len(requests)
+	return ret
+}
+
+// List handles GET /__admin/requests
+// Supported query parameters: method, path, unmatched, since (RFC3339) and limit.
+func (jh journalHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := usecase.JournalFilter{
+		Method: q.Get("method"),
+		Path:   q.Get("path"),
+	}
+	var err error
+	if v := q.Get("unmatched"); v != "" {
+		if filter.Unmatched, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid unmatched: %w", err))
+			return
+		}
+	}
+	if v := q.Get("since"); v != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since: %w", err))
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, newRequestList(jh.ju.List(filter)))
+}
+
+// Clear handles DELETE /__admin/requests
+func (jh journalHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	jh.ju.Clear()
+	w.WriteHeader(http.StatusOK)
+}
+
+// Find handles POST /__admin/requests/find
+func (jh journalHandler) Find(w http.ResponseWriter, r *http.Request) {
+	var matcher model.Request
+	if err := json.NewDecoder(r.Body).Decode(&matcher); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request matcher: %w", err))
+		return
+	}
+	requests, err := jh.ju.Find(matcher)
+	if err != nil {
+		writeJournalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newRequestList(requests))
+}
+
+// Count handles POST /__admin/requests/count
+func (jh journalHandler) Count(w http.ResponseWriter, r *http.Request) {
+	var matcher model.Request
+	if err := json.NewDecoder(r.Body).Decode(&matcher); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request matcher: %w", err))
+		return
+	}
+	count, err := jh.ju.Count(matcher)
+	if err != nil {
+		writeJournalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"count": count})
+}
+
+// Verify handles POST /__admin/requests/verify
+// The expectation is given by the query parameters count, atLeast and/or atMost.
+// It responds with 200 when the expectation holds and 417 otherwise.
+func (jh journalHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	bounds := map[string]int{}
+	for _, k := range []string{"count", "atLeast", "atMost"} {
+		v := q.Get(k)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %w", k, err))
+			return
+		}
+		bounds[k] = n
+	}
+	if len(bounds) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("one of count, atLeast or atMost is required"))
+		return
+	}
+	var matcher model.Request
+	if err := json.NewDecoder(r.Body).Decode(&matcher); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request matcher: %w", err))
+		return
+	}
+
+	count, err := jh.ju.Count(matcher)
+	if err != nil {
+		writeJournalError(w, err)
+		return
+	}
+	ok := true
+	if n, exists := bounds["count"]; exists && count != n {
+		ok = false
+	}
+	if n, exists := bounds["atLeast"]; exists && count < n {
+		ok = false
+	}
+	if n, exists := bounds["atMost"]; exists && count > n {
+		ok = false
+	}
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusExpectationFailed
+	}
+	writeJSON(w, status, struct {
+		Count    int  `json:"count"`
+		Verified bool `json:"verified"`
+	}{
+		Count:    count,
+		Verified: ok,
+	})
+}
+
+func writeJournalError(w http.ResponseWriter, err error) {
+	if errors.Is(err, usecase.ErrInvalidRequestMatcher) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
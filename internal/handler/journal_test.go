@@ -0,0 +1,197 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+type mockJournalUsecase struct {
+	filter  usecase.JournalFilter
+	matcher model.Request
+	count   int
+	cleared bool
+}
+
+func (m *mockJournalUsecase) List(filter usecase.JournalFilter) []model.LoggedRequest {
+	m.filter = filter
+	return []model.LoggedRequest{{ID: "1"}}
+}
+
+func (m *mockJournalUsecase) Clear() {
+	m.cleared = true
+}
+
+func (m *mockJournalUsecase) Find(matcher model.Request) ([]model.LoggedRequest, error) {
+	m.matcher = matcher
+	if err := (model.Endpoint{Request: matcher}).Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrInvalidRequestMatcher, err)
+	}
+	return []model.LoggedRequest{{ID: "1"}}, nil
+}
+
+func (m *mockJournalUsecase) Count(matcher model.Request) (int, error) {
+	m.matcher = matcher
+	if err := (model.Endpoint{Request: matcher}).Validate(); err != nil {
+		return 0, fmt.Errorf("%w: %w", usecase.ErrInvalidRequestMatcher, err)
+	}
+	return m.count, nil
+}
+
+func newJournalMux(ju *mockJournalUsecase) *http.ServeMux {
+	jh := handler.NewJournalHandler(ju)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /__admin/requests", jh.List)
+	mux.HandleFunc("DELETE /__admin/requests", jh.Clear)
+	mux.HandleFunc("POST /__admin/requests/find", jh.Find)
+	mux.HandleFunc("POST /__admin/requests/count", jh.Count)
+	mux.HandleFunc("POST /__admin/requests/verify", jh.Verify)
+	return mux
+}
+
+func TestJournalHandler_List(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedFilter usecase.JournalFilter
+	}{
+		{
+			name:           "List with filters",
+			path:           "/__admin/requests?method=POST&path=/orders&unmatched=true&since=2025-01-01T00:00:00Z&limit=5",
+			expectedStatus: http.StatusOK,
+			expectedFilter: usecase.JournalFilter{
+				Method:    "POST",
+				Path:      "/orders",
+				Unmatched: true,
+				Since:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				Limit:     5,
+			},
+		},
+		{
+			name:           "Invalid since",
+			path:           "/__admin/requests?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid limit",
+			path:           "/__admin/requests?limit=many",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ju := &mockJournalUsecase{}
+			w := httptest.NewRecorder()
+			newJournalMux(ju).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusOK && ju.filter != tt.expectedFilter {
+				t.Errorf("Expected filter %+v, got %+v", tt.expectedFilter, ju.filter)
+			}
+		})
+	}
+}
+
+func TestJournalHandler_Verify(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		count          int
+		expectedStatus int
+	}{
+		{
+			name:           "Exact count holds",
+			query:          "?count=2",
+			body:           `{"method": "POST", "urlPath": "/orders", "headers": {"X-Tenant": {"equalTo": "acme"}}}`,
+			count:          2,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Exact count fails",
+			query:          "?count=2",
+			body:           `{"method": "POST"}`,
+			count:          3,
+			expectedStatus: http.StatusExpectationFailed,
+		},
+		{
+			name:           "Range holds",
+			query:          "?atLeast=1&atMost=3",
+			body:           `{}`,
+			count:          3,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing expectation",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid matcher",
+			query:          "?count=1",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid regular expression",
+			query:          "?count=1",
+			body:           `{"body": {"matches": "["}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ju := &mockJournalUsecase{count: tt.count}
+			w := httptest.NewRecorder()
+			newJournalMux(ju).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/__admin/requests/verify"+tt.query, strings.NewReader(tt.body)))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusBadRequest {
+				return
+			}
+			var got struct {
+				Count int `json:"count"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if got.Count != tt.count {
+				t.Errorf("Expected count %d, got %d", tt.count, got.Count)
+			}
+		})
+	}
+}
+
+func TestJournalHandler_CountAndClear(t *testing.T) {
+	ju := &mockJournalUsecase{count: 4}
+	mux := newJournalMux(ju)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/__admin/requests/count", strings.NewReader(`{"method": "GET"}`)))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"count":4}` || ju.matcher.Method.EqualTo != "GET" {
+		t.Errorf("Unexpected count response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/__admin/requests/find", strings.NewReader(`{"body": {"contains": 1}}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid matcher, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/__admin/requests", nil))
+	if w.Code != http.StatusOK || !ju.cleared {
+		t.Errorf("Expected journal to be cleared, got status %d", w.Code)
+	}
+}
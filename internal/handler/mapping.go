@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+// mappingHandler serves the /__admin/mappings API.
+type mappingHandler struct {
+	mu mappingUsecase
+}
+
+func NewMappingHandler(mu mappingUsecase) mappingHandler {
+	return mappingHandler{
+		mu: mu,
+	}
+}
+
+type mappingUsecase interface {
+	List() []model.Endpoint
+	Get(id string) (model.Endpoint, error)
+	Create(model.Endpoint) (model.Endpoint, error)
+	Update(id string, endpoint model.Endpoint) (model.Endpoint, error)
+	Delete(id string) error
+	Reset()
+}
+
+type mappingList struct {
+	Mappings []model.Endpoint `json:"mappings"`
+	Meta     struct {
+		Total int `json:"total"`
+	} `json:"meta"`
+}
+
+// List handles GET /__admin/mappings
+func (mh mappingHandler) List(w http.ResponseWriter, r *http.Request) {
+	var ret mappingList
+	ret.Mappings = mh.mu.List()
+	if ret.Mappings == nil {
+		ret.Mappings = []model.Endpoint{}
+	}
+	ret.Meta.Total = len(ret.Mappings)
+	writeJSON(w, http.StatusOK, ret)
+}
+
+// Get handles GET /__admin/mappings/{id}
+func (mh mappingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	e, err := mh.mu.Get(r.PathValue("id"))
+	if err != nil {
+		writeMappingError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// Create handles POST /__admin/mappings
+func (mh mappingHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var e model.Endpoint
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid mapping: %w", err))
+		return
+	}
+	created, err := mh.mu.Create(e)
+	if err != nil {
+		writeMappingError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// Update handles PUT /__admin/mappings/{id}
+func (mh mappingHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var e model.Endpoint
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid mapping: %w", err))
+		return
+	}
+	updated, err := mh.mu.Update(r.PathValue("id"), e)
+	if err != nil {
+		writeMappingError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// Delete handles DELETE /__admin/mappings/{id}
+func (mh mappingHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := mh.mu.Delete(r.PathValue("id")); err != nil {
+		writeMappingError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Reset handles POST /__admin/mappings/reset
+func (mh mappingHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	mh.mu.Reset()
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeMappingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrMappingNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, repository.ErrMappingExists):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, usecase.ErrInvalidMapping):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error(fmt.Sprintf("Failed to write JSON response: %s", err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
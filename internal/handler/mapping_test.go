@@ -0,0 +1,215 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+type mockMappingUsecase struct {
+	mappings map[string]model.Endpoint
+	reset    bool
+}
+
+func (m *mockMappingUsecase) List() []model.Endpoint {
+	ret := []model.Endpoint{}
+	for _, e := range m.mappings {
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+func (m *mockMappingUsecase) Get(id string) (model.Endpoint, error) {
+	if e, ok := m.mappings[id]; ok {
+		return e, nil
+	}
+	return model.Endpoint{}, repository.ErrMappingNotFound
+}
+
+func (m *mockMappingUsecase) Create(e model.Endpoint) (model.Endpoint, error) {
+	if err := e.Validate(); err != nil {
+		return model.Endpoint{}, fmt.Errorf("%w: %w", usecase.ErrInvalidMapping, err)
+	}
+	if _, ok := m.mappings[e.ID]; ok {
+		return model.Endpoint{}, fmt.Errorf("%w: %s", repository.ErrMappingExists, e.ID)
+	}
+	if e.ID == "" {
+		e.ID = "generated"
+	}
+	m.mappings[e.ID] = e
+	return e, nil
+}
+
+func (m *mockMappingUsecase) Update(id string, e model.Endpoint) (model.Endpoint, error) {
+	if _, ok := m.mappings[id]; !ok {
+		return model.Endpoint{}, repository.ErrMappingNotFound
+	}
+	e.ID = id
+	m.mappings[id] = e
+	return e, nil
+}
+
+func (m *mockMappingUsecase) Delete(id string) error {
+	if _, ok := m.mappings[id]; !ok {
+		return repository.ErrMappingNotFound
+	}
+	delete(m.mappings, id)
+	return nil
+}
+
+func (m *mockMappingUsecase) Reset() {
+	m.reset = true
+	clear(m.mappings)
+}
+
+func newMappingMux(mu *mockMappingUsecase) *http.ServeMux {
+	mh := handler.NewMappingHandler(mu)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /__admin/mappings", mh.List)
+	mux.HandleFunc("POST /__admin/mappings", mh.Create)
+	mux.HandleFunc("POST /__admin/mappings/reset", mh.Reset)
+	mux.HandleFunc("GET /__admin/mappings/{id}", mh.Get)
+	mux.HandleFunc("PUT /__admin/mappings/{id}", mh.Update)
+	mux.HandleFunc("DELETE /__admin/mappings/{id}", mh.Delete)
+	return mux
+}
+
+func TestMappingHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedName   string
+	}{
+		{
+			name:           "Create mapping",
+			method:         http.MethodPost,
+			path:           "/__admin/mappings",
+			body:           `{"name": "created", "request": {"urlPath": "/x", "method": "GET"}, "response": {"status": 200, "body": "ok"}}`,
+			expectedStatus: http.StatusCreated,
+			expectedName:   "created",
+		},
+		{
+			name:           "Create mapping with duplicate ID",
+			method:         http.MethodPost,
+			path:           "/__admin/mappings",
+			body:           `{"id": "existing"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Create mapping with invalid JSON",
+			method:         http.MethodPost,
+			path:           "/__admin/mappings",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Create mapping with an invalid matcher",
+			method:         http.MethodPost,
+			path:           "/__admin/mappings",
+			body:           `{"request": {"urlPath": "/x", "queryParameters": {"q": {"matches": "("}}}, "response": {"status": 200}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Get mapping",
+			method:         http.MethodGet,
+			path:           "/__admin/mappings/existing",
+			expectedStatus: http.StatusOK,
+			expectedName:   "existing stub",
+		},
+		{
+			name:           "Get unknown mapping",
+			method:         http.MethodGet,
+			path:           "/__admin/mappings/unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Update mapping",
+			method:         http.MethodPut,
+			path:           "/__admin/mappings/existing",
+			body:           `{"name": "updated"}`,
+			expectedStatus: http.StatusOK,
+			expectedName:   "updated",
+		},
+		{
+			name:           "Update unknown mapping",
+			method:         http.MethodPut,
+			path:           "/__admin/mappings/unknown",
+			body:           `{"name": "updated"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Delete mapping",
+			method:         http.MethodDelete,
+			path:           "/__admin/mappings/existing",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Delete unknown mapping",
+			method:         http.MethodDelete,
+			path:           "/__admin/mappings/unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu := &mockMappingUsecase{mappings: map[string]model.Endpoint{
+				"existing": {ID: "existing", Name: "existing stub"},
+			}}
+			w := httptest.NewRecorder()
+			newMappingMux(mu).ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedName != "" {
+				var got model.Endpoint
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if got.Name != tt.expectedName {
+					t.Errorf("Expected name %q, got %q", tt.expectedName, got.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestMappingHandler_ListAndReset(t *testing.T) {
+	mu := &mockMappingUsecase{mappings: map[string]model.Endpoint{
+		"existing": {ID: "existing", Name: "existing stub"},
+	}}
+	mux := newMappingMux(mu)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/__admin/mappings", nil))
+	var list struct {
+		Mappings []model.Endpoint `json:"mappings"`
+		Meta     struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if list.Meta.Total != 1 || len(list.Mappings) != 1 || list.Mappings[0].ID != "existing" {
+		t.Errorf("Unexpected mapping list: %+v", list)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/__admin/mappings/reset", nil))
+	if w.Code != http.StatusOK || !mu.reset {
+		t.Errorf("Expected reset to succeed, got status %d", w.Code)
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+// Formats of the 404 response sent when no endpoint matches.
+const (
+	NearMissFormatAuto = "auto" // JSON if the client accepts it, plain text otherwise
+	NearMissFormatJSON = "json"
+	NearMissFormatText = "text"
+	NearMissFormatNone = "none" // bare 404 without diagnostics
+)
+
+// ValidateNearMissFormat reports an error for a format other than the NearMissFormat constants.
+func ValidateNearMissFormat(format string) error {
+	switch format {
+	case NearMissFormatAuto, NearMissFormatJSON, NearMissFormatText, NearMissFormatNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown near miss format %q, want %s, %s, %s or %s",
+			format, NearMissFormatAuto, NearMissFormatJSON, NearMissFormatText, NearMissFormatNone)
+	}
+}
+
+type nearMissReport struct {
+	Message string `json:"message"`
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	NearMisses    []usecase.NearMiss   `json:"nearMisses"`
+	OpenAPIErrors []model.OpenAPIError `json:"openApiErrors,omitempty"`
+}
+
+// writeNoMatch sends a 404 response that lists the closest endpoints and the matchers that rejected the request.
+func writeNoMatch(w http.ResponseWriter, r *http.Request, format string, nm *usecase.NoMatchError) {
+	if format == "" || format == NearMissFormatAuto {
+		format = NearMissFormatText
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			format = NearMissFormatJSON
+		}
+	}
+	switch format {
+	case NearMissFormatJSON:
+		var report nearMissReport
+		report.Message = nm.Error()
+		report.Request.Method = r.Method
+		report.Request.URL = r.URL.RequestURI()
+		report.NearMisses = nm.NearMisses
+		report.OpenAPIErrors = nm.OpenAPIErrors
+		writeJSON(w, http.StatusNotFound, report)
+	case NearMissFormatText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, nearMissText(r, nm))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func nearMissText(r *http.Request, nm *usecase.NoMatchError) string {
+	var b strings.Builder
+	if len(nm.OpenAPIErrors) > 0 {
+		fmt.Fprintf(&b, "%s %s does not satisfy the OpenAPI document\n\n", r.Method, r.URL.RequestURI())
+		for _, e := range nm.OpenAPIErrors {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+		return b.String()
+	}
+	fmt.Fprintf(&b, "No stub matched %s %s\n", r.Method, r.URL.RequestURI())
+	if len(nm.NearMisses) == 0 {
+		b.WriteString("\nNo stubs are configured.\n")
+		return b.String()
+	}
+	b.WriteString("\nClosest stubs:\n")
+	for i, m := range nm.NearMisses {
+		fmt.Fprintf(&b, "\n%d. %q (id: %s) matched %d of %d\n", i+1, m.EndpointName, m.EndpointID, m.Score, m.MaxScore)
+		for _, mm := range m.Mismatches {
+			fmt.Fprintf(&b, "   - %s\n", mm.Matcher)
+			fmt.Fprintf(&b, "       expected: %s\n", mm.Expected)
+			fmt.Fprintf(&b, "       actual:   %s\n", mm.Actual)
+		}
+	}
+	return b.String()
+}
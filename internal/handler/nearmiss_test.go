@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+func TestHandle_NearMiss(t *testing.T) {
+	noMatch := &usecase.NoMatchError{
+		NearMisses: []usecase.NearMiss{
+			{
+				EndpointID:   "user",
+				EndpointName: "Get user",
+				Score:        4,
+				MaxScore:     5,
+				Mismatches: []usecase.Mismatch{
+					{Matcher: "path.id", Expected: `{"equalTo":"123"}`, Actual: "456"},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name                string
+		format              string
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "Auto format defaults to text",
+			format:              handler.NearMissFormatAuto,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody: `No stub matched GET /users/456
+
+Closest stubs:
+
+1. "Get user" (id: user) matched 4 of 5
+   - path.id
+       expected: {"equalTo":"123"}
+       actual:   456
+`,
+		},
+		{
+			name:                "Auto format follows the Accept header",
+			format:              handler.NearMissFormatAuto,
+			accept:              "application/json",
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "JSON format",
+			format:              handler.NearMissFormatJSON,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "No diagnostics",
+			format:              handler.NearMissFormatNone,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "404 page not found\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &mockEndpointUsecase{
+				endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+					return usecase.EndpointMatcherResult{}, noMatch
+				},
+			}
+			h := handler.NewEndpointHandler("test/config.json", tt.format, 0, mockUsecase, &mockJournalRecorder{}, &mockProxyUsecase{}, &mockOpenAPIUsecase{})
+			req := httptest.NewRequest(http.MethodGet, "/users/456", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			h.Handle(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.expectedContentType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.expectedContentType, got)
+			}
+			if tt.expectedContentType == "application/json" {
+				var got struct {
+					Request struct {
+						Method string `json:"method"`
+						URL    string `json:"url"`
+					} `json:"request"`
+					NearMisses []usecase.NearMiss `json:"nearMisses"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if got.Request.URL != "/users/456" || len(got.NearMisses) != 1 || got.NearMisses[0].Mismatches[0].Actual != "456" {
+					t.Errorf("Unexpected near-miss report: %s", w.Body.String())
+				}
+				return
+			}
+			if got := w.Body.String(); got != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, got)
+			}
+		})
+	}
+}
+
+func TestValidateNearMissFormat(t *testing.T) {
+	for _, format := range []string{handler.NearMissFormatAuto, handler.NearMissFormatJSON, handler.NearMissFormatText, handler.NearMissFormatNone} {
+		if err := handler.ValidateNearMissFormat(format); err != nil {
+			t.Errorf("ValidateNearMissFormat(%q) error = %v", format, err)
+		}
+	}
+	for _, format := range []string{"", "JSON", "xml"} {
+		if err := handler.ValidateNearMissFormat(format); err == nil {
+			t.Errorf("ValidateNearMissFormat(%q) error = nil, want an error", format)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package handler_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+type mockOpenAPIUsecase struct {
+	requestErr error
+	request    model.OpenAPIRequest
+	response   *model.OpenAPIResponse
+}
+
+func (m *mockOpenAPIUsecase) ValidateRequest(req model.OpenAPIRequest) error {
+	m.request = req
+	return m.requestErr
+}
+
+func (m *mockOpenAPIUsecase) ValidateResponse(req model.OpenAPIRequest, resp model.OpenAPIResponse) ([]model.OpenAPIError, error) {
+	m.response = &resp
+	return nil, nil
+}
+
+func TestHandle_OpenAPI(t *testing.T) {
+	violation := &usecase.NoMatchError{
+		OpenAPIErrors: []model.OpenAPIError{
+			{In: "path", Name: "id", Message: "must be integer, not string"},
+			{In: "body", Name: "$.name", Message: "must be string, not integer"},
+		},
+	}
+	tests := []struct {
+		name           string
+		ou             *mockOpenAPIUsecase
+		expectedStatus int
+		expectedBody   string
+		expectedMatch  bool
+	}{
+		{
+			name:           "Request that satisfies the contract is matched",
+			ou:             &mockOpenAPIUsecase{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+			expectedMatch:  true,
+		},
+		{
+			name:           "Request that breaks the contract is rejected with the violations",
+			ou:             &mockOpenAPIUsecase{requestErr: violation},
+			expectedStatus: http.StatusNotFound,
+			expectedBody: `PUT /users/abc?x=1 does not satisfy the OpenAPI document
+
+- path id: must be integer, not string
+- body $.name: must be string, not integer
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := false
+			mockUsecase := &mockEndpointUsecase{
+				endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+					matched = true
+					return usecase.EndpointMatcherResult{
+						Endpoint:       model.Endpoint{ID: "user"},
+						ResponseBody:   "ok",
+						ResponseStatus: http.StatusOK,
+					}, nil
+				},
+				responseCreatorFunc: func(args usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error) {
+					return usecase.ResponseCreatorResult{Template: template.Must(template.New("response").Parse(args.ResponseBody))}, nil
+				},
+			}
+			pu := &mockProxyUsecase{recording: true}
+			h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatText, 0, mockUsecase, &mockJournalRecorder{}, pu, tt.ou)
+			w := httptest.NewRecorder()
+			h.Handle(w, httptest.NewRequest(http.MethodPut, "/users/abc?x=1", strings.NewReader(`{"name": 1}`)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Body.String(); got != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, got)
+			}
+			if matched != tt.expectedMatch {
+				t.Errorf("Expected EndpointMatcher to be called: %v, got %v", tt.expectedMatch, matched)
+			}
+			if tt.ou.request.Method != http.MethodPut || tt.ou.request.Path != "/users/abc" || tt.ou.request.Query["x"][0] != "1" || tt.ou.request.Body != `{"name": 1}` {
+				t.Errorf("Unexpected validated request: %+v", tt.ou.request)
+			}
+			// the rendered response is validated, and an invalid request is not recorded from the target
+			if tt.expectedMatch && (tt.ou.response == nil || tt.ou.response.Body != "ok" || tt.ou.response.Status != http.StatusOK) {
+				t.Errorf("Unexpected validated response: %+v", tt.ou.response)
+			}
+			if !tt.expectedMatch && pu.args.URL != "" {
+				t.Error("Request that breaks the contract must not be recorded")
+			}
+		})
+	}
+}
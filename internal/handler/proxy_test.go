@@ -0,0 +1,174 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+type mockProxyUsecase struct {
+	recording bool
+	endpoint  model.Endpoint
+	args      usecase.ProxyArgs
+	resp      model.ProxyResponse
+	err       error
+}
+
+func (m *mockProxyUsecase) Recording() bool {
+	return m.recording
+}
+
+func (m *mockProxyUsecase) Record(ctx context.Context, args usecase.ProxyArgs) (model.ProxyResponse, error) {
+	m.args = args
+	return m.resp, m.err
+}
+
+func (m *mockProxyUsecase) Proxy(ctx context.Context, e model.Endpoint, args usecase.ProxyArgs) (model.ProxyResponse, error) {
+	m.endpoint = e
+	m.args = args
+	return m.resp, m.err
+}
+
+func TestHandle_Record(t *testing.T) {
+	tests := []struct {
+		name           string
+		pu             *mockProxyUsecase
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Unmatched request is recorded and relayed",
+			pu: &mockProxyUsecase{
+				recording: true,
+				resp: model.ProxyResponse{
+					Status:  http.StatusCreated,
+					Headers: map[string][]string{"X-Upstream": {"yes"}},
+					Body:    []byte("from upstream"),
+				},
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "from upstream",
+		},
+		{
+			name: "Response is relayed when the stub is not saved",
+			pu: &mockProxyUsecase{
+				recording: true,
+				resp: model.ProxyResponse{
+					Status:  http.StatusOK,
+					Headers: map[string][]string{"X-Upstream": {"yes"}},
+					Body:    []byte("from upstream"),
+				},
+				err: fmt.Errorf("%w: disk full", usecase.ErrRecordNotSaved),
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "from upstream",
+		},
+		{
+			name: "Upstream failure",
+			pu: &mockProxyUsecase{
+				recording: true,
+				err:       errors.New("connection refused"),
+			},
+			expectedStatus: http.StatusBadGateway,
+		},
+		{
+			name:           "Not recording",
+			pu:             &mockProxyUsecase{},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &mockEndpointUsecase{
+				endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+					return usecase.EndpointMatcherResult{}, &usecase.NoMatchError{}
+				},
+			}
+			h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, mockUsecase, &mockJournalRecorder{}, tt.pu, &mockOpenAPIUsecase{})
+			w := httptest.NewRecorder()
+			h.Handle(w, httptest.NewRequest(http.MethodPost, "/orders?x=1", strings.NewReader("payload")))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedBody != "" {
+				body, _ := io.ReadAll(w.Body)
+				if string(body) != tt.expectedBody || w.Header().Get("X-Upstream") != "yes" {
+					t.Errorf("Unexpected relayed response: %q %v", body, w.Header())
+				}
+				if tt.pu.args.URL != "/orders?x=1" || string(tt.pu.args.Body) != "payload" || tt.pu.args.RawQueryValues.Get("x") != "1" {
+					t.Errorf("Unexpected proxy args: %+v", tt.pu.args)
+				}
+			}
+		})
+	}
+}
+
+func TestHandle_Proxy(t *testing.T) {
+	tests := []struct {
+		name           string
+		pu             *mockProxyUsecase
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Matched proxy stub relays the backend response",
+			pu: &mockProxyUsecase{
+				resp: model.ProxyResponse{
+					Status:  http.StatusAccepted,
+					Headers: map[string][]string{"X-Upstream": {"yes"}},
+					Body:    []byte("from backend"),
+				},
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   "from backend",
+		},
+		{
+			name:           "Backend error is reported as bad gateway",
+			pu:             &mockProxyUsecase{err: errors.New("connection refused")},
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := model.Endpoint{
+				ID:       "catch-all",
+				Response: model.Response{ProxyBaseURL: "http://backend"},
+			}
+			mockUsecase := &mockEndpointUsecase{
+				endpointMatcherFunc: func(args usecase.EndpointMatcherArgs) (usecase.EndpointMatcherResult, error) {
+					return usecase.EndpointMatcherResult{Endpoint: endpoint}, nil
+				},
+				responseCreatorFunc: func(args usecase.ResponseCreatorArgs) (usecase.ResponseCreatorResult, error) {
+					t.Fatal("ResponseCreator must not be called for a proxy stub")
+					return usecase.ResponseCreatorResult{}, nil
+				},
+			}
+			h := handler.NewEndpointHandler("test/config.json", handler.NearMissFormatNone, 0, mockUsecase, &mockJournalRecorder{}, tt.pu, &mockOpenAPIUsecase{})
+			w := httptest.NewRecorder()
+			h.Handle(w, httptest.NewRequest(http.MethodPut, "/users/1", strings.NewReader("payload")))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.pu.endpoint.ID != "catch-all" || tt.pu.args.URL != "/users/1" || string(tt.pu.args.Body) != "payload" {
+				t.Errorf("Unexpected proxy call: %+v %+v", tt.pu.endpoint, tt.pu.args)
+			}
+			if tt.expectedBody != "" {
+				body, _ := io.ReadAll(w.Body)
+				if string(body) != tt.expectedBody || w.Header().Get("X-Upstream") != "yes" {
+					t.Errorf("Unexpected relayed response: %q %v", body, w.Header())
+				}
+			}
+		})
+	}
+}
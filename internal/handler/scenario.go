@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+// scenarioHandler serves the /__admin/scenarios API.
+type scenarioHandler struct {
+	configPath string
+	su         scenarioUsecase
+}
+
+func NewScenarioHandler(configPath string, su scenarioUsecase) scenarioHandler {
+	return scenarioHandler{
+		configPath: configPath,
+		su:         su,
+	}
+}
+
+type scenarioUsecase interface {
+	List(configPath string) ([]model.Scenario, error)
+	SetState(configPath, name, state string) error
+	Reset()
+}
+
+type scenarioList struct {
+	Scenarios []model.Scenario `json:"scenarios"`
+}
+
+// List handles GET /__admin/scenarios
+func (sh scenarioHandler) List(w http.ResponseWriter, r *http.Request) {
+	scenarios, err := sh.su.List(sh.configPath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, scenarioList{Scenarios: scenarios})
+}
+
+// SetState handles PUT /__admin/scenarios/{name}/state
+func (sh scenarioHandler) SetState(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid scenario state: %w", err))
+		return
+	}
+	if body.State == "" {
+		writeError(w, http.StatusBadRequest, errors.New("state is required"))
+		return
+	}
+	if err := sh.su.SetState(sh.configPath, r.PathValue("name"), body.State); err != nil {
+		if errors.Is(err, usecase.ErrScenarioNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Reset handles POST /__admin/scenarios/reset
+func (sh scenarioHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	sh.su.Reset()
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,139 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/handler"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+type mockScenarioUsecase struct {
+	states     map[string]string
+	configPath string
+	reset      bool
+}
+
+func (m *mockScenarioUsecase) List(configPath string) ([]model.Scenario, error) {
+	m.configPath = configPath
+	ret := []model.Scenario{}
+	for name, state := range m.states {
+		ret = append(ret, model.Scenario{Name: name, State: state, PossibleStates: []string{model.ScenarioStarted}})
+	}
+	return ret, nil
+}
+
+func (m *mockScenarioUsecase) SetState(configPath, name, state string) error {
+	m.configPath = configPath
+	if _, ok := m.states[name]; !ok {
+		return fmt.Errorf("%w: %s", usecase.ErrScenarioNotFound, name)
+	}
+	m.states[name] = state
+	return nil
+}
+
+func (m *mockScenarioUsecase) Reset() {
+	m.reset = true
+}
+
+func newScenarioMux(su *mockScenarioUsecase) *http.ServeMux {
+	sh := handler.NewScenarioHandler("test/config.json", su)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /__admin/scenarios", sh.List)
+	mux.HandleFunc("POST /__admin/scenarios/reset", sh.Reset)
+	mux.HandleFunc("PUT /__admin/scenarios/{name}/state", sh.SetState)
+	return mux
+}
+
+func TestScenarioHandler_List(t *testing.T) {
+	su := &mockScenarioUsecase{states: map[string]string{"job": "done"}}
+	w := httptest.NewRecorder()
+	newScenarioMux(su).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/__admin/scenarios", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var got struct {
+		Scenarios []model.Scenario `json:"scenarios"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(got.Scenarios) != 1 || got.Scenarios[0].Name != "job" || got.Scenarios[0].State != "done" {
+		t.Errorf("Unexpected scenarios: %+v", got.Scenarios)
+	}
+	if su.configPath != "test/config.json" {
+		t.Errorf("Expected config path test/config.json, got %s", su.configPath)
+	}
+}
+
+func TestScenarioHandler_SetState(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedState  string
+	}{
+		{
+			name:           "Set state",
+			path:           "/__admin/scenarios/job/state",
+			body:           `{"state": "done"}`,
+			expectedStatus: http.StatusOK,
+			expectedState:  "done",
+		},
+		{
+			name:           "Unknown scenario",
+			path:           "/__admin/scenarios/missing/state",
+			body:           `{"state": "done"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedState:  model.ScenarioStarted,
+		},
+		{
+			name:           "Missing state",
+			path:           "/__admin/scenarios/job/state",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedState:  model.ScenarioStarted,
+		},
+		{
+			name:           "Invalid JSON",
+			path:           "/__admin/scenarios/job/state",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+			expectedState:  model.ScenarioStarted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			su := &mockScenarioUsecase{states: map[string]string{"job": model.ScenarioStarted}}
+			w := httptest.NewRecorder()
+			newScenarioMux(su).ServeHTTP(w, httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if su.states["job"] != tt.expectedState {
+				t.Errorf("Expected state %s, got %s", tt.expectedState, su.states["job"])
+			}
+		})
+	}
+}
+
+func TestScenarioHandler_Reset(t *testing.T) {
+	su := &mockScenarioUsecase{}
+	w := httptest.NewRecorder()
+	newScenarioMux(su).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/__admin/scenarios/reset", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if !su.reset {
+		t.Error("Expected scenarios to be reset")
+	}
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha1"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -72,5 +76,62 @@ func (c ConfigRepository) loadFile(path string) ([]model.Endpoint, error) {
 	if err != nil {
 		return nil, err
 	}
+	for i := range endpoints {
+		if endpoints[i].ID == "" {
+			endpoints[i].ID = stableID(path, i)
+		}
+	}
 	return endpoints, nil
 }
+
+// stableID derives a UUID-formatted ID from the file path and the position of the endpoint in it,
+// so that a stub keeps the same ID every time the configuration is reloaded.
+func stableID(path string, index int) string {
+	sum := sha1.Sum(fmt.Appendf(nil, "%s#%d", filepath.Clean(path), index))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// Save writes the file through a temporary file and a rename,
+// so that a concurrent Load never sees a partially written configuration.
+func (c ConfigRepository) Save(path string, endpoints []model.Endpoint) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(endpoints); err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error(fmt.Sprintf("Failed to remove temporary file: %s", err))
+		}
+	}()
+	if _, err := b.WriteTo(tmp); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c ConfigRepository) SaveBody(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, body, 0644)
+}
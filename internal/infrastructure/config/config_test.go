@@ -130,8 +130,9 @@ func TestConfigRepository_Load(t *testing.T) {
 			// 追加の検証
 			if tt.name == "valid single JSON file" {
 				assert.Equal(t, "test1", endpoints[0].Name)
+				assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`, endpoints[0].ID)
 				assert.Equal(t, "/test1", endpoints[0].Request.URL)
-				assert.Equal(t, "GET", endpoints[0].Request.Method)
+				assert.Equal(t, model.Method("GET"), endpoints[0].Request.Method)
 				assert.Equal(t, 200, endpoints[0].Response.Status)
 			}
 		})
@@ -144,7 +145,7 @@ func TestEndpointValidation(t *testing.T) {
 		Description: "test endpoint",
 		Request: model.Request{
 			URL:    "/test",
-			Method: "GET",
+			Method: model.Method("GET"),
 			QueryParameters: map[string]model.Matcher{
 				"param1": {
 					EqualTo: "value1",
@@ -209,3 +210,64 @@ func TestEndpointValidation(t *testing.T) {
 	assert.Equal(t, validEndpoint.Response.Status, endpoints[0].Response.Status)
 	assert.Equal(t, validEndpoint.Response.Body, endpoints[0].Response.Body)
 }
+
+func TestConfigRepository_LoadStableID(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := createTestFile(t, tmpDir, "ids.json", `[
+		{"request": {"url": "/a", "method": "GET"}, "response": {"status": 200, "body": "a"}},
+		{"id": "explicit", "request": {"url": "/b", "method": "GET"}, "response": {"status": 200, "body": "b"}}
+	]`)
+
+	repo := NewConfigRepository()
+	first, err := repo.Load(path)
+	assert.NoError(t, err)
+	second, err := repo.Load(path)
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, first[0].ID)
+	assert.Equal(t, first[0].ID, second[0].ID)
+	assert.Equal(t, "explicit", first[1].ID)
+}
+
+func TestConfigRepository_Save(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := NewConfigRepository()
+	path := filepath.Join(tmpDir, "recorded", "get-users.json")
+	endpoints := []model.Endpoint{
+		{
+			ID:   "recorded",
+			Name: "GET /users",
+			Request: model.Request{
+				URLPath: "/users",
+				Method:  model.Method("GET"),
+			},
+			Response: model.Response{
+				Status: 200,
+				Body:   "[]",
+			},
+		},
+	}
+
+	saved, err := repo.Save(path, endpoints)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	// the saved file can be loaded back
+	loaded, err := repo.Load(filepath.Join(tmpDir, "recorded"))
+	assert.NoError(t, err)
+	assert.Equal(t, endpoints, loaded)
+
+	// an existing file is left untouched
+	saved, err = repo.Save(path, []model.Endpoint{{ID: "other"}})
+	assert.NoError(t, err)
+	assert.False(t, saved)
+	loaded, err = repo.Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "recorded", loaded[0].ID)
+
+	bodyPath := filepath.Join(tmpDir, "body", "get-users.json")
+	assert.NoError(t, repo.SaveBody(bodyPath, []byte("[]")))
+	b, err := os.ReadFile(bodyPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(b))
+}
@@ -0,0 +1,52 @@
+package journal
+
+import (
+	"sync"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// JournalRepository is a bounded in-memory request journal.
+// Once it holds maxEntries requests, the oldest one is dropped for each new request.
+type JournalRepository struct {
+	mu         sync.RWMutex
+	maxEntries int
+	entries    []model.LoggedRequest
+	start      int // index of the oldest entry once the buffer is full
+}
+
+func NewJournalRepository(maxEntries int) *JournalRepository {
+	return &JournalRepository{
+		maxEntries: maxEntries,
+	}
+}
+
+func (j *JournalRepository) Add(request model.LoggedRequest) {
+	if j.maxEntries <= 0 {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.entries) < j.maxEntries {
+		j.entries = append(j.entries, request)
+		return
+	}
+	j.entries[j.start] = request
+	j.start = (j.start + 1) % j.maxEntries
+}
+
+func (j *JournalRepository) List() []model.LoggedRequest {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	ret := make([]model.LoggedRequest, 0, len(j.entries))
+	ret = append(ret, j.entries[j.start:]...)
+	ret = append(ret, j.entries[:j.start]...)
+	return ret
+}
+
+func (j *JournalRepository) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = nil
+	j.start = 0
+}
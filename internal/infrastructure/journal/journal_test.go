@@ -0,0 +1,65 @@
+package journal
+
+import (
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(requests []model.LoggedRequest) []string {
+	ret := []string{}
+	for _, r := range requests {
+		ret = append(ret, r.ID)
+	}
+	return ret
+}
+
+func TestJournalRepository(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		add        []string
+		want       []string
+	}{
+		{
+			name:       "within capacity",
+			maxEntries: 3,
+			add:        []string{"1", "2"},
+			want:       []string{"1", "2"},
+		},
+		{
+			name:       "oldest entries are dropped",
+			maxEntries: 3,
+			add:        []string{"1", "2", "3", "4", "5"},
+			want:       []string{"3", "4", "5"},
+		},
+		{
+			name:       "disabled journal",
+			maxEntries: 0,
+			add:        []string{"1", "2"},
+			want:       []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewJournalRepository(tt.maxEntries)
+			for _, id := range tt.add {
+				repo.Add(model.LoggedRequest{ID: id})
+			}
+			assert.Equal(t, tt.want, ids(repo.List()))
+		})
+	}
+}
+
+func TestJournalRepository_Clear(t *testing.T) {
+	repo := NewJournalRepository(2)
+	repo.Add(model.LoggedRequest{ID: "1"})
+	repo.Add(model.LoggedRequest{ID: "2"})
+	repo.Add(model.LoggedRequest{ID: "3"})
+	repo.Clear()
+	assert.Empty(t, repo.List())
+
+	repo.Add(model.LoggedRequest{ID: "4"})
+	assert.Equal(t, []string{"4"}, ids(repo.List()))
+}
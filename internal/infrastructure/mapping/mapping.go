@@ -0,0 +1,83 @@
+package mapping
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+)
+
+// MappingRepository is an in-memory store of stub mappings.
+// Mappings are kept in insertion order and are lost when the process exits.
+type MappingRepository struct {
+	mu       sync.RWMutex
+	mappings []model.Endpoint
+}
+
+func NewMappingRepository() *MappingRepository {
+	return &MappingRepository{}
+}
+
+func (m *MappingRepository) List() []model.Endpoint {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.mappings)
+}
+
+func (m *MappingRepository) Get(id string) (model.Endpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if i := m.index(id); i != -1 {
+		return m.mappings[i], nil
+	}
+	return model.Endpoint{}, repository.ErrMappingNotFound
+}
+
+// Create adds the endpoint unless a mapping already has its ID.
+// The check and the insert happen under one lock, so that concurrent creates of the same ID cannot both succeed.
+func (m *MappingRepository) Create(endpoint model.Endpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.index(endpoint.ID) != -1 {
+		return fmt.Errorf("%w: %s", repository.ErrMappingExists, endpoint.ID)
+	}
+	m.mappings = append(m.mappings, endpoint)
+	return nil
+}
+
+// Update replaces the mapping that has the same ID in place.
+func (m *MappingRepository) Update(endpoint model.Endpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.index(endpoint.ID)
+	if i == -1 {
+		return repository.ErrMappingNotFound
+	}
+	m.mappings[i] = endpoint
+	return nil
+}
+
+func (m *MappingRepository) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.index(id)
+	if i == -1 {
+		return repository.ErrMappingNotFound
+	}
+	m.mappings = slices.Delete(m.mappings, i, i+1)
+	return nil
+}
+
+func (m *MappingRepository) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mappings = nil
+}
+
+func (m *MappingRepository) index(id string) int {
+	return slices.IndexFunc(m.mappings, func(e model.Endpoint) bool {
+		return e.ID == id
+	})
+}
@@ -0,0 +1,74 @@
+package mapping
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappingRepository(t *testing.T) {
+	repo := NewMappingRepository()
+
+	assert.NoError(t, repo.Create(model.Endpoint{ID: "a", Name: "first"}))
+	assert.NoError(t, repo.Create(model.Endpoint{ID: "b", Name: "second"}))
+	assert.Equal(t, []model.Endpoint{{ID: "a", Name: "first"}, {ID: "b", Name: "second"}}, repo.List())
+
+	// 同じIDでは作成できない
+	assert.ErrorIs(t, repo.Create(model.Endpoint{ID: "a", Name: "duplicate"}), repository.ErrMappingExists)
+
+	// 更新すると順序を保ったまま置き換えられる
+	assert.NoError(t, repo.Update(model.Endpoint{ID: "a", Name: "replaced"}))
+	assert.Equal(t, []model.Endpoint{{ID: "a", Name: "replaced"}, {ID: "b", Name: "second"}}, repo.List())
+	assert.ErrorIs(t, repo.Update(model.Endpoint{ID: "missing"}), repository.ErrMappingNotFound)
+
+	got, err := repo.Get("b")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", got.Name)
+
+	_, err = repo.Get("missing")
+	assert.ErrorIs(t, err, repository.ErrMappingNotFound)
+
+	assert.NoError(t, repo.Delete("a"))
+	assert.ErrorIs(t, repo.Delete("a"), repository.ErrMappingNotFound)
+	assert.Equal(t, []model.Endpoint{{ID: "b", Name: "second"}}, repo.List())
+
+	repo.Reset()
+	assert.Empty(t, repo.List())
+}
+
+func TestMappingRepository_ListReturnsCopy(t *testing.T) {
+	repo := NewMappingRepository()
+	assert.NoError(t, repo.Create(model.Endpoint{ID: "a", Name: "first"}))
+
+	list := repo.List()
+	list[0].Name = "modified"
+
+	got, err := repo.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", got.Name)
+}
+
+func TestMappingRepository_ConcurrentCreate(t *testing.T) {
+	repo := NewMappingRepository()
+
+	// 同じIDの同時作成は一つだけが成功する
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if repo.Create(model.Endpoint{ID: "a", Name: fmt.Sprint(i)}) == nil {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), created.Load())
+	assert.Len(t, repo.List(), 1)
+}
@@ -0,0 +1,98 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIRepository loads OpenAPI documents and keeps the parsed ones,
+// so that a document is parsed again only when its file changes.
+type OpenAPIRepository struct {
+	mu    sync.Mutex
+	cache map[string]cachedOpenAPI
+}
+
+type cachedOpenAPI struct {
+	modTime time.Time
+	size    int64
+	doc     model.OpenAPI
+}
+
+func NewOpenAPIRepository() *OpenAPIRepository {
+	return &OpenAPIRepository{cache: make(map[string]cachedOpenAPI)}
+}
+
+// Load reads an OpenAPI document written in JSON or, with a .yaml or .yml extension, in YAML.
+// The parsed document is reused while the modification time and size of the file stay the same.
+func (o *OpenAPIRepository) Load(path string) (model.OpenAPI, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return model.OpenAPI{}, fmt.Errorf("failed to read OpenAPI document: %v", err)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if c, ok := o.cache[path]; ok && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.doc, nil
+	}
+	doc, err := parse(path)
+	if err != nil {
+		return model.OpenAPI{}, err
+	}
+	o.cache[path] = cachedOpenAPI{modTime: info.ModTime(), size: info.Size(), doc: doc}
+	return doc, nil
+}
+
+func parse(path string) (model.OpenAPI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return model.OpenAPI{}, fmt.Errorf("failed to read OpenAPI document: %v", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return model.OpenAPI{}, fmt.Errorf("failed to parse OpenAPI document %s: %v", path, err)
+		}
+		if data, err = json.Marshal(jsonCompatible(doc)); err != nil {
+			return model.OpenAPI{}, fmt.Errorf("failed to parse OpenAPI document %s: %v", path, err)
+		}
+	}
+	doc, err := model.ParseOpenAPI(data, filepath.Dir(path))
+	if err != nil {
+		return model.OpenAPI{}, fmt.Errorf("failed to parse OpenAPI document %s: %v", path, err)
+	}
+	return doc, nil
+}
+
+// jsonCompatible converts the maps decoded from YAML, whose keys need not be strings
+// (response codes such as 200 are integers), to maps that encoding/json accepts.
+func jsonCompatible(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = jsonCompatible(e)
+		}
+		return v
+	case map[any]any:
+		ret := make(map[string]any, len(v))
+		for k, e := range v {
+			ret[fmt.Sprint(k)] = jsonCompatible(e)
+		}
+		return ret
+	case []any:
+		for i, e := range v {
+			v[i] = jsonCompatible(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,132 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIRepository_Load(t *testing.T) {
+	// テスト用の一時ディレクトリを作成
+	tmpDir := t.TempDir()
+	yamlDoc := `openapi: 3.0.3
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        200:
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: schemas.json#/User
+`
+	jsonDoc := `{"openapi": "3.1.0", "paths": {"/users/{id}": {"get": {"responses": {"200": {"description": "ok"}}}}}}`
+	schemas := `{"User": {"type": "object", "required": ["id"]}}`
+	files := map[string]string{
+		"api.yaml":     yamlDoc,
+		"api.json":     jsonDoc,
+		"schemas.json": schemas,
+		"swagger.json": `{"swagger": "2.0", "paths": {}}`,
+		"broken.yml":   "openapi: [",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	repo := NewOpenAPIRepository()
+	tests := []struct {
+		name       string
+		path       string
+		wantErr    bool
+		wantErrors []model.OpenAPIError
+	}{
+		{
+			// YAMLの数値のキーと別ファイルへの$refを解決できる
+			name:       "YAML document",
+			path:       filepath.Join(tmpDir, "api.yaml"),
+			wantErrors: []model.OpenAPIError{{In: "body", Name: "$", Message: `missing required property "id"`}},
+		},
+		{
+			name: "JSON document",
+			path: filepath.Join(tmpDir, "api.json"),
+		},
+		{
+			name:    "Swagger 2 document",
+			path:    filepath.Join(tmpDir, "swagger.json"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid YAML",
+			path:    filepath.Join(tmpDir, "broken.yml"),
+			wantErr: true,
+		},
+		{
+			name:    "non-existent path",
+			path:    filepath.Join(tmpDir, "nonexistent.yaml"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := repo.Load(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			got := doc.ValidateResponse(
+				model.OpenAPIRequest{Method: "GET", Path: "/users/1"},
+				model.OpenAPIResponse{Status: 200, Headers: map[string][]string{"Content-Type": {"application/json"}}, Body: `{}`},
+			)
+			assert.Equal(t, tt.wantErrors, got)
+		})
+	}
+}
+
+func TestOpenAPIRepository_LoadCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.json")
+	write := func(doc string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set modification time: %v", err)
+		}
+	}
+	users := `{"openapi": "3.0.3", "paths": {"/users": {"get": {"responses": {"200": {"description": "ok"}}}}}}`
+	orders := `{"openapi": "3.0.3", "paths": {"/orders": {"get": {"responses": {"200": {"description": "ok"}}}}}}`
+	request := model.OpenAPIRequest{Method: "GET", Path: "/users"}
+	modTime := time.Now().Add(-time.Hour)
+
+	repo := NewOpenAPIRepository()
+	write(users, modTime)
+	doc, err := repo.Load(path)
+	assert.NoError(t, err)
+	assert.Empty(t, doc.ValidateRequest(request))
+
+	// 更新日時とサイズが同じ場合は解析済みのドキュメントを使う
+	write(strings.Replace(users, "/users", "/items", 1), modTime)
+	doc, err = repo.Load(path)
+	assert.NoError(t, err)
+	assert.Empty(t, doc.ValidateRequest(request))
+
+	// ファイルが変更された場合は読み直す
+	write(orders, modTime.Add(time.Minute))
+	doc, err = repo.Load(path)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, doc.ValidateRequest(request))
+}
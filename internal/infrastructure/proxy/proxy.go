@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// hopByHopHeaders are meaningful only for a single connection and must not be forwarded.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+type ProxyRepository struct {
+	client *http.Client
+}
+
+func NewProxyRepository() ProxyRepository {
+	return ProxyRepository{
+		client: &http.Client{
+			// relay redirects to the client instead of following them
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (p ProxyRepository) Forward(ctx context.Context, request model.ProxyRequest) (model.ProxyResponse, error) {
+	target := strings.TrimRight(request.BaseURL, "/") + request.URL
+	req, err := http.NewRequestWithContext(ctx, request.Method, target, bytes.NewReader(request.Body))
+	if err != nil {
+		return model.ProxyResponse{}, fmt.Errorf("failed to create proxy request: %w", err)
+	}
+	for k, values := range request.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	removeHopByHopHeaders(req.Header)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return model.ProxyResponse{}, fmt.Errorf("failed to forward request to %s: %w", target, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error(fmt.Sprintf("Failed to close proxy response body: %s", err))
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return model.ProxyResponse{}, fmt.Errorf("failed to read proxy response body: %w", err)
+	}
+	removeHopByHopHeaders(resp.Header)
+	return model.ProxyResponse{
+		Status:  resp.StatusCode,
+		Headers: resp.Header,
+		Body:    body,
+	}, nil
+}
+
+func removeHopByHopHeaders(h http.Header) {
+	for _, k := range hopByHopHeaders {
+		h.Del(k)
+	}
+}
@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyRepository_Forward(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Uri", r.URL.RequestURI())
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+		w.Header().Set("X-Connection-Token", r.Header.Get("Proxy-Authorization"))
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write(append([]byte("echo:"), body...))
+	}))
+	defer upstream.Close()
+
+	repo := NewProxyRepository()
+	resp, err := repo.Forward(context.Background(), model.ProxyRequest{
+		BaseURL: upstream.URL + "/",
+		Method:  http.MethodPost,
+		URL:     "/orders?tenant=acme",
+		Headers: map[string][]string{
+			"X-Tenant":            {"acme"},
+			"Proxy-Authorization": {"secret"},
+		},
+		Body: []byte("payload"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.Status)
+	assert.Equal(t, "echo:payload", string(resp.Body))
+	h := http.Header(resp.Headers)
+	assert.Equal(t, http.MethodPost, h.Get("X-Method"))
+	assert.Equal(t, "/orders?tenant=acme", h.Get("X-Uri"))
+	assert.Equal(t, "acme", h.Get("X-Tenant"))
+	assert.Empty(t, h.Get("X-Connection-Token"), "hop-by-hop headers must not be forwarded")
+
+	// redirects are relayed, not followed
+	resp, err = repo.Forward(context.Background(), model.ProxyRequest{
+		BaseURL: upstream.URL,
+		Method:  http.MethodGet,
+		URL:     "/redirect",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.Status)
+	assert.Equal(t, "/elsewhere", http.Header(resp.Headers).Get("Location"))
+}
+
+func TestProxyRepository_ForwardError(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+
+	_, err := NewProxyRepository().Forward(context.Background(), model.ProxyRequest{
+		BaseURL: upstream.URL,
+		Method:  http.MethodGet,
+		URL:     "/",
+	})
+	assert.Error(t, err)
+}
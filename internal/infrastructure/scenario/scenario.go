@@ -0,0 +1,62 @@
+package scenario
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// ScenarioRepository is an in-memory store of scenario states.
+// States are lost when the process exits.
+type ScenarioRepository struct {
+	mu     sync.Mutex
+	states map[string]string
+}
+
+func NewScenarioRepository() *ScenarioRepository {
+	return &ScenarioRepository{
+		states: make(map[string]string),
+	}
+}
+
+func (s *ScenarioRepository) State(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state(name)
+}
+
+func (s *ScenarioRepository) SetState(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[name] = state
+}
+
+func (s *ScenarioRepository) CompareAndSwap(name, oldState, newState string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state(name) != oldState {
+		return false
+	}
+	s.states[name] = newState
+	return true
+}
+
+func (s *ScenarioRepository) States() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.states)
+}
+
+func (s *ScenarioRepository) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.states)
+}
+
+func (s *ScenarioRepository) state(name string) string {
+	if state, ok := s.states[name]; ok {
+		return state
+	}
+	return model.ScenarioStarted
+}
@@ -0,0 +1,49 @@
+package scenario
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScenarioRepository(t *testing.T) {
+	repo := NewScenarioRepository()
+
+	// 一度も遷移していないシナリオは初期状態
+	assert.Equal(t, model.ScenarioStarted, repo.State("job"))
+	assert.Empty(t, repo.States())
+
+	assert.True(t, repo.CompareAndSwap("job", model.ScenarioStarted, "done"))
+	assert.Equal(t, "done", repo.State("job"))
+	assert.False(t, repo.CompareAndSwap("job", model.ScenarioStarted, "failed"))
+	assert.Equal(t, "done", repo.State("job"))
+
+	repo.SetState("login", "logged-in")
+	assert.Equal(t, map[string]string{"job": "done", "login": "logged-in"}, repo.States())
+
+	repo.Reset()
+	assert.Equal(t, model.ScenarioStarted, repo.State("job"))
+	assert.Empty(t, repo.States())
+}
+
+func TestScenarioRepository_CompareAndSwapIsAtomic(t *testing.T) {
+	repo := NewScenarioRepository()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	swapped := 0
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if repo.CompareAndSwap("job", model.ScenarioStarted, "done") {
+				mu.Lock()
+				swapped++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, swapped)
+}
@@ -0,0 +1,185 @@
+package usecase_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEndpointUsecase_EndpointMatcherAuth(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "secret.key")
+	if err := os.WriteFile(keyFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token := func(secret, claims string) string {
+		input := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+			base64.RawURLEncoding.EncodeToString([]byte(claims))
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(input))
+		return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:secret"))
+
+	endpoints := []model.Endpoint{
+		{
+			ID: "admin",
+			Request: model.Request{
+				Method:               model.Method("GET"),
+				URLPath:              "/admin",
+				BasicAuthCredentials: &model.BasicAuthCredentials{Username: "admin", Password: "secret"},
+			},
+			Response: model.Response{Status: 200, Body: "ok"},
+		},
+		{
+			ID: "orders",
+			Request: model.Request{
+				Method:  model.Method("GET"),
+				URLPath: "/orders",
+				JWT: &model.JWTMatcher{
+					KeyFile: keyFile,
+					Claims:  map[string]model.Matcher{"scope": {Contains: "orders:read"}},
+				},
+			},
+			Response: model.Response{Status: 200, Body: "ok"},
+		},
+		{
+			ID:       "me",
+			Request:  model.Request{Method: model.Method("GET"), URLPath: "/me"},
+			Response: model.Response{Status: 200, Body: "ok"},
+		},
+	}
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantID        string
+		wantClaims    map[string]any
+		wantErr       bool
+	}{
+		{
+			name:          "Basic認証のマッチング",
+			path:          "/admin",
+			authorization: basic,
+			wantID:        "admin",
+		},
+		{
+			name:          "Basic認証のパスワードが一致しない",
+			path:          "/admin",
+			authorization: "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:wrong")),
+			wantErr:       true,
+		},
+		{
+			name:          "署名を検証したJWTのクレーム",
+			path:          "/orders",
+			authorization: "Bearer " + token("secret", `{"sub":"alice","scope":"orders:read orders:write"}`),
+			wantID:        "orders",
+			wantClaims:    map[string]any{"sub": "alice", "scope": "orders:read orders:write"},
+		},
+		{
+			name:          "JWTの署名が一致しない",
+			path:          "/orders",
+			authorization: "Bearer " + token("other", `{"sub":"alice","scope":"orders:read"}`),
+			wantErr:       true,
+		},
+		{
+			name:          "JWTマッチャーがなくてもクレームを参照できる",
+			path:          "/me",
+			authorization: "Bearer " + token("other", `{"sub":"bob"}`),
+			wantID:        "me",
+			wantClaims:    map[string]any{"sub": "bob"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arg usecase.EndpointMatcherArgs
+			arg.Request.Method = "GET"
+			arg.Request.UrlPath = tt.path
+			arg.Request.Headers = map[string][]string{"Authorization": {tt.authorization}}
+			arg.Request.Body = io.NopCloser(strings.NewReader(""))
+
+			eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, newMockScenarioRepository())
+			got, err := eu.EndpointMatcher(arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Endpoint.ID != tt.wantID {
+				t.Errorf("EndpointUsecase.EndpointMatcher() matched %q, want %q", got.Endpoint.ID, tt.wantID)
+			}
+			if diff := cmp.Diff(tt.wantClaims, got.Data.Claims); diff != "" {
+				t.Errorf("Claims mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEndpointUsecase_EndpointMatcherJWTKeyCache(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "secret.key")
+	write := func(secret string, modTime time.Time) {
+		if err := os.WriteFile(keyFile, []byte(secret), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(keyFile, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	token := func(secret string) string {
+		input := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+			base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`))
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(input))
+		return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	endpoints := []model.Endpoint{{
+		ID: "orders",
+		Request: model.Request{
+			Method:  model.Method("GET"),
+			URLPath: "/orders",
+			JWT:     &model.JWTMatcher{KeyFile: keyFile},
+		},
+		Response: model.Response{Status: 200, Body: "ok"},
+	}}
+	eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, newMockScenarioRepository())
+	match := func(secret string) error {
+		var arg usecase.EndpointMatcherArgs
+		arg.Request.Method = "GET"
+		arg.Request.UrlPath = "/orders"
+		arg.Request.Headers = map[string][]string{"Authorization": {"Bearer " + token(secret)}}
+		arg.Request.Body = io.NopCloser(strings.NewReader(""))
+		_, err := eu.EndpointMatcher(arg)
+		return err
+	}
+	modTime := time.Now().Add(-time.Hour)
+
+	write("secret1", modTime)
+	if err := match("secret1"); err != nil {
+		t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v", err)
+	}
+
+	// 更新日時とサイズが同じ場合は読み込み済みの鍵を使う
+	write("secret2", modTime)
+	if err := match("secret1"); err != nil {
+		t.Errorf("EndpointUsecase.EndpointMatcher() with the cached key error = %v", err)
+	}
+
+	// ファイルが変更された場合は読み直す
+	write("secret2", modTime.Add(time.Minute))
+	if err := match("secret2"); err != nil {
+		t.Errorf("EndpointUsecase.EndpointMatcher() with the new key error = %v", err)
+	}
+	if err := match("secret1"); err == nil {
+		t.Error("EndpointUsecase.EndpointMatcher() matched with the old key")
+	}
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// jsonEscapeFunc is the name the JSON engine registers its escaper under.
+// It cannot be written in a template, so a stub cannot call it by accident.
+const jsonEscapeFunc = "_jsonEscape"
+
+// BodyTemplate renders the response body. It is a text/template, or an html/template for HTML responses.
+type BodyTemplate interface {
+	Execute(w io.Writer, data any) error
+}
+
+// parseBodyTemplate parses the response body with the given engine, see model.Response.BodyTemplateEngine.
+func parseBodyTemplate(engine, body string, funcs map[string]any) (BodyTemplate, error) {
+	switch engine {
+	case model.TemplateEngineText:
+		return template.New("response").Funcs(funcs).Parse(body)
+	case model.TemplateEngineJSON:
+		funcs[jsonEscapeFunc] = jsonEscape
+		tpl, err := template.New("response").Funcs(funcs).Parse(body)
+		if err != nil {
+			return nil, err
+		}
+		if err := escapeJSONStrings(tpl); err != nil {
+			return nil, err
+		}
+		return tpl, nil
+	case model.TemplateEngineHTML:
+		return htmltemplate.New("response").Funcs(funcs).Parse(body)
+	default:
+		return nil, fmt.Errorf("unknown template engine: %s", engine)
+	}
+}
+
+// jsonEscape writes a value as the contents of a JSON string, leaving HTML characters as they are.
+func jsonEscape(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(templateString(v)); err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(buf.String(), "\n")
+	return s[1 : len(s)-1], nil
+}
+
+// escapeJSONStrings rewrites the parsed templates so that every action written inside a JSON string
+// is escaped with jsonEscape. Actions outside strings, such as a number or {{toJson .Claims}},
+// are written as they are.
+func escapeJSONStrings(tpl *template.Template) error {
+	for _, t := range tpl.Templates() {
+		if t.Tree == nil || t.Root == nil {
+			continue
+		}
+		if _, err := (jsonEscaper{tree: t.Tree}).list(t.Root, jsonContext{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonContext is where the template text stands within the JSON document.
+type jsonContext struct {
+	inString bool
+	escaped  bool // the previous character in the string is a backslash
+}
+
+// after returns the context at the end of text.
+func (c jsonContext) after(text []byte) jsonContext {
+	for _, b := range text {
+		switch {
+		case !c.inString:
+			c.inString = b == '"'
+		case c.escaped:
+			c.escaped = false
+		case b == '\\':
+			c.escaped = true
+		case b == '"':
+			c.inString = false
+		}
+	}
+	return c
+}
+
+type jsonEscaper struct {
+	tree *parse.Tree
+}
+
+func (e jsonEscaper) list(l *parse.ListNode, c jsonContext) (jsonContext, error) {
+	if l == nil {
+		return c, nil
+	}
+	var err error
+	for _, n := range l.Nodes {
+		if c, err = e.node(n, c); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func (e jsonEscaper) node(n parse.Node, c jsonContext) (jsonContext, error) {
+	switch n := n.(type) {
+	case *parse.TextNode:
+		return c.after(n.Text), nil
+	case *parse.ActionNode:
+		// {{$x := ...}} writes nothing
+		if c.inString && len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{parse.NewIdentifier(jsonEscapeFunc).SetTree(e.tree).SetPos(n.Pos)},
+			})
+		}
+		c.escaped = false
+		return c, nil
+	case *parse.IfNode:
+		return e.branch(&n.BranchNode, "if", c)
+	case *parse.WithNode:
+		return e.branch(&n.BranchNode, "with", c)
+	case *parse.RangeNode:
+		// the body may run any number of times, so it has to end where it starts
+		end, err := e.list(n.List, c)
+		if err != nil {
+			return end, err
+		}
+		if end != c {
+			return end, e.errorf(n, "{{range}} ends in a different JSON context than it starts")
+		}
+		if end, err = e.list(n.ElseList, c); err != nil {
+			return end, err
+		}
+		if end != c {
+			return end, e.errorf(n, "{{range}} branches end in different JSON contexts")
+		}
+		return c, nil
+	default:
+		// comments, {{break}}, {{continue}} and {{template}}, whose output is not escaped
+		return c, nil
+	}
+}
+
+// branch walks both branches of an {{if}} or {{with}}, which have to end in the same context.
+func (e jsonEscaper) branch(n *parse.BranchNode, action string, c jsonContext) (jsonContext, error) {
+	end, err := e.list(n.List, c)
+	if err != nil {
+		return end, err
+	}
+	elseEnd, err := e.list(n.ElseList, c)
+	if err != nil {
+		return elseEnd, err
+	}
+	if end != elseEnd {
+		return end, e.errorf(n, "{{%s}} branches end in different JSON contexts", action)
+	}
+	return end, nil
+}
+
+func (e jsonEscaper) errorf(n parse.Node, format string, args ...any) error {
+	location, _ := e.tree.ErrorContext(n)
+	return fmt.Errorf("template: %s: %s", location, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,102 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+func TestEndpointUsecase_ResponseCreatorEngine(t *testing.T) {
+	data := usecase.TemplateData{
+		Path:        map[string]string{"id": "42"},
+		Query:       map[string]string{"q": `say "hi" & <bye>\`},
+		QueryValues: map[string][]string{"tag": {"a", `b"c`}},
+		Claims:      map[string]any{"roles": []any{"admin", "dev"}},
+	}
+	jsonHeaders := map[string]model.ResponseHeader{"Content-Type": {"application/json"}}
+	tests := []struct {
+		name     string
+		response model.Response
+		body     string
+		want     string
+		wantErr  bool // when parsing
+	}{
+		{
+			name: "Content-Typeなしはそのまま出力",
+			body: `{{.Query.q}}`,
+			want: `say "hi" & <bye>\`,
+		},
+		{
+			name:     "JSONの文字列内はJSONとしてエスケープ",
+			response: model.Response{Headers: jsonHeaders},
+			body:     `{"id": {{.Path.id}}, "q": "{{.Query.q}}", "roles": {{toJson .Claims.roles}}}`,
+			want:     `{"id": 42, "q": "say \"hi\" & <bye>\\", "roles": ["admin","dev"]}`,
+		},
+		{
+			name:     "エスケープされた引用符は文字列を閉じない",
+			response: model.Response{Headers: jsonHeaders},
+			body:     `{"q": "\"{{.Query.q}}\""}`,
+			want:     `{"q": "\"say \"hi\" & <bye>\\\""}`,
+		},
+		{
+			name:     "分岐と繰り返し",
+			response: model.Response{Headers: jsonHeaders},
+			body:     `{"q": {{if .Query.q}}"{{.Query.q}}"{{else}}null{{end}}, "tags": [{{range $i, $v := .QueryValues.tag}}{{if $i}}, {{end}}"{{$v}}"{{end}}]}`,
+			want:     `{"q": "say \"hi\" & <bye>\\", "tags": ["a", "b\"c"]}`,
+		},
+		{
+			name:     "分岐の終わりで文字列の内外が異なる",
+			response: model.Response{Headers: jsonHeaders},
+			body:     `{"q": {{if .Query.q}}"{{.Query.q}}{{end}}"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "繰り返しの終わりで文字列の内外が異なる",
+			response: model.Response{Headers: jsonHeaders},
+			body:     `[{{range .QueryValues.tag}}"{{.}}{{end}}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "HTMLは文脈に応じてエスケープ",
+			response: model.Response{Headers: map[string]model.ResponseHeader{"Content-Type": {"text/html; charset=utf-8"}}},
+			body:     `<p>{{.Query.q}}</p>`,
+			want:     `<p>say &#34;hi&#34; &amp; &lt;bye&gt;\</p>`,
+		},
+		{
+			name:     "templateEngineの指定はContent-Typeより優先",
+			response: model.Response{TemplateEngine: model.TemplateEngineText, Headers: jsonHeaders},
+			body:     `{"q": "{{.Query.q}}"}`,
+			want:     `{"q": "say "hi" & <bye>\"}`,
+		},
+		{
+			name:     "未知のテンプレートエンジン",
+			response: model.Response{TemplateEngine: "mustache"},
+			body:     `{{.Query.q}}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eu := usecase.NewEndpointUsecase(&mockConfigRepository{}, &mockMappingRepository{}, newMockScenarioRepository())
+			got, err := eu.ResponseCreator(usecase.ResponseCreatorArgs{
+				Endpoint:     model.Endpoint{Response: tt.response},
+				ResponseBody: tt.body,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EndpointUsecase.ResponseCreator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var b strings.Builder
+			if err := got.Template.Execute(&b, data); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Execute() = %s, want %s", b.String(), tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package usecase
 
 import (
+	"encoding/json"
 	"fmt"
-	"html/template"
 	"io"
 	"log/slog"
 	"net/url"
 	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
 
 	"github.com/dev-shimada/gostubby/internal/domain/model"
 	"github.com/dev-shimada/gostubby/internal/domain/repository"
@@ -14,121 +17,310 @@ import (
 
 type EndpointUsecase struct {
 	cr repository.ConfigRepository
+	mr repository.MappingRepository
+	sr repository.ScenarioRepository
 }
 
-func NewEndpointUsecase(cr repository.ConfigRepository) EndpointUsecase {
+func NewEndpointUsecase(cr repository.ConfigRepository, mr repository.MappingRepository, sr repository.ScenarioRepository) EndpointUsecase {
 	return EndpointUsecase{
 		cr: cr,
+		mr: mr,
+		sr: sr,
 	}
 }
 
 type EndpointMatcherArgs struct {
-	Request struct {
-		UrlRawPath     string
-		UrlPath        string
-		Body           io.ReadCloser
-		Method         string
-		Headers        map[string][]string
-		RawQueryValues url.Values
-		QueryValues    url.Values
-	}
+	Request    MatcherRequest
 	ConfigPath string
 }
+
+// MatcherRequest is the incoming HTTP request passed to EndpointMatcher.
+type MatcherRequest struct {
+	UrlRawPath     string
+	UrlPath        string
+	Body           io.ReadCloser
+	Method         string
+	Headers        map[string][]string
+	RawQueryValues url.Values
+	QueryValues    url.Values
+	Scheme         string // http or https
+	Host           string // Host header without the port, in lower case
+	Port           string // local port the request was received on
+	URL            string // full URL, with the scheme and the Host header
+	RemoteAddr     string // address of the client
+	ReceivedAt     time.Time
+}
 type EndpointMatcherResult struct {
 	Endpoint       model.Endpoint
 	ResponseBody   string
 	ResponseStatus int
-	Data           struct {
-		Path    map[string]string
-		Query   map[string]string
-		Headers map[string][]string
+	Match          model.MatchInfo // why the endpoint was chosen
+	Data           TemplateData
+}
+
+// TemplateData is the data the response body and header templates are executed with.
+type TemplateData struct {
+	Path        map[string]string
+	Query       map[string]string   // first value of each query parameter
+	QueryValues map[string][]string // all values of each query parameter
+	Headers     map[string][]string
+	Form        map[string]string   // first value of each form field
+	FormValues  map[string][]string // all values of each form field
+	Claims      map[string]any      // claims of the bearer token, see model.JWTMatcher
+	// SchemaErrors explains why the body failed matchesJsonSchema, see schemaErrors
+	SchemaErrors []model.SchemaError
+	Request      TemplateRequest
+}
+
+// TemplateRequest is the incoming request as the templates see it, so that a stub can echo it.
+type TemplateRequest struct {
+	Method     string
+	URL        string // full URL, such as https://example.com:8443/users?id=1
+	Path       string
+	Scheme     string // http or https
+	Host       string // Host header without the port, in lower case
+	Port       string
+	RemoteAddr string              // address of the client, such as 192.0.2.1:51234
+	Header     map[string]string   // first value of each header
+	Headers    map[string][]string // all values of each header
+	Cookies    map[string]string
+	Body       string            // raw body
+	JSON       any               // body parsed as JSON, or nil when it is not JSON
+	Form       map[string]string // first value of each field of a form body
+	Time       string            // when the request was received, in RFC 3339 and UTC
+}
+
+func templateRequest(req incomingRequest) TemplateRequest {
+	var body any
+	dec := json.NewDecoder(strings.NewReader(req.Body))
+	// numbers are kept as written, so that a large ID is not turned into 1.23e+18
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil || dec.More() {
+		body = nil
+	}
+	return TemplateRequest{
+		Method:     req.Method,
+		URL:        req.URL,
+		Path:       req.Path,
+		Scheme:     req.Scheme,
+		Host:       req.Host,
+		Port:       req.Port,
+		RemoteAddr: req.RemoteAddr,
+		Header:     firstValues(req.Headers),
+		Headers:    req.Headers,
+		Cookies:    firstValues(cookies(req.Headers)),
+		Body:       req.Body,
+		JSON:       body,
+		Form:       firstValues(req.Form),
+		Time:       req.ReceivedAt.UTC().Format(time.RFC3339Nano),
 	}
 }
 
+func firstValues(values map[string][]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			ret[k] = v[0]
+		}
+	}
+	return ret
+}
+
 func (eu EndpointUsecase) EndpointMatcher(arg EndpointMatcherArgs) (EndpointMatcherResult, error) {
-	endpoints, err := eu.cr.Load(arg.ConfigPath)
+	loaded, err := eu.cr.Load(arg.ConfigPath)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to load configuration: %v", err))
 		return EndpointMatcherResult{}, err
 	}
-	for _, e := range endpoints {
-		var responseBody string
-		switch {
-		case e.Response.BodyFileName != "":
-			file, err := os.Open(e.Response.BodyFileName)
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to open body file: %s", err))
-				return EndpointMatcherResult{}, err
+	// mappings registered through the admin API come first among endpoints of the same priority and specificity
+	endpoints := append(eu.mr.List(), loaded...)
+
+	body, err := io.ReadAll(arg.Request.Body)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to read request body: %s", err))
+		return EndpointMatcherResult{}, err
+	}
+	req := incomingRequest{
+		Method:         arg.Request.Method,
+		RawPath:        arg.Request.UrlRawPath,
+		Path:           arg.Request.UrlPath,
+		RawQueryValues: arg.Request.RawQueryValues,
+		QueryValues:    arg.Request.QueryValues,
+		Headers:        arg.Request.Headers,
+		Body:           string(body),
+		Scheme:         arg.Request.Scheme,
+		Host:           arg.Request.Host,
+		Port:           arg.Request.Port,
+		URL:            arg.Request.URL,
+		RemoteAddr:     arg.Request.RemoteAddr,
+		ReceivedAt:     arg.Request.ReceivedAt,
+	}
+	req.Form, req.Parts = parseForm(req.Headers, req.Body)
+	endpoints = byPriority(endpoints)
+	results := make([]matchResult, 0, len(endpoints))
+	// every endpoint is evaluated so that the ones the winner shadows can be reported.
+	// The state of a scenario is read once, so that the winner's transition does not affect the others.
+	states := make(map[string]string)
+	winner, asGet := -1, -1
+	var responseBody string
+	for i, e := range endpoints {
+		var state string
+		if e.ScenarioName != "" {
+			if _, ok := states[e.ScenarioName]; !ok {
+				states[e.ScenarioName] = eu.sr.State(e.ScenarioName)
 			}
-			defer func() {
-				if err := file.Close(); err != nil {
-					slog.Error(fmt.Sprintf("Failed to close file: %s", err))
-				}
-			}()
-			body, err := io.ReadAll(file)
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to read body file: %s", err))
+			state = states[e.ScenarioName]
+		}
+		m := matchEndpoint(e, req, state)
+		if winner < 0 && m.matched() {
+			// the body is loaded before the scenario moves, so that an endpoint that cannot respond leaves it as it is
+			if responseBody, err = loadResponseBody(e); err != nil {
 				return EndpointMatcherResult{}, err
 			}
-			responseBody = string(body)
-		case e.Response.Body != "":
-			responseBody = e.Response.Body
-		default:
-			slog.Error("Response body is empty")
-			return EndpointMatcherResult{}, fmt.Errorf("response body is empty")
+			if !eu.transition(e, state) {
+				// another request moved the scenario since its state was read
+				m.Scenario = false
+				m.ScenarioState = eu.sr.State(e.ScenarioName)
+				states[e.ScenarioName] = m.ScenarioState
+			}
+		}
+		results = append(results, m)
+		switch {
+		case winner < 0 && m.matched():
+			winner = i
+		case asGet < 0 && m.matchedAsGet():
+			asGet = i
+		}
+	}
+	// a HEAD request is answered by a GET endpoint only if no endpoint accepts HEAD itself
+	if winner < 0 && asGet >= 0 {
+		e := endpoints[asGet]
+		if responseBody, err = loadResponseBody(e); err != nil {
+			return EndpointMatcherResult{}, err
 		}
+		if eu.transition(e, results[asGet].ScenarioState) {
+			winner = asGet
+		} else {
+			results[asGet].Scenario = false
+			results[asGet].ScenarioState = eu.sr.State(e.ScenarioName)
+		}
+	}
+	if winner >= 0 {
+		return matchedEndpoint(endpoints, results, winner, req, responseBody), nil
+	}
+	return EndpointMatcherResult{}, &NoMatchError{NearMisses: nearMisses(endpoints, results, req)}
+}
 
-		// pathMatcher
-		isMatchPath, pathMap := e.PathMatcher(arg.Request.UrlRawPath, arg.Request.UrlPath)
-		// queryMatcher
-		isMatchQuery, queryMap := e.QueryMatcher(arg.Request.RawQueryValues, arg.Request.QueryValues)
-		body, err := io.ReadAll(arg.Request.Body)
+func matchedEndpoint(endpoints []model.Endpoint, results []matchResult, winner int, req incomingRequest, responseBody string) EndpointMatcherResult {
+	e, m := endpoints[winner], results[winner]
+	info := matchInfo(endpoints, results, winner)
+	slog.Info(fmt.Sprintf("Matched endpoint: %s (%s)", e.Name, info.Reason))
+	return EndpointMatcherResult{
+		Endpoint:       e,
+		ResponseBody:   responseBody,
+		ResponseStatus: e.Response.Status,
+		Match:          info,
+		Data: TemplateData{
+			Path:         m.PathMap,
+			Query:        m.QueryMap,
+			QueryValues:  req.QueryValues,
+			Headers:      m.HeadersMap,
+			Form:         firstValues(req.Form),
+			FormValues:   req.Form,
+			Claims:       m.Claims,
+			SchemaErrors: schemaErrors(endpoints, results, winner, req),
+			Request:      templateRequest(req),
+		},
+	}
+}
+
+// transition moves the scenario of the matched endpoint to its new state.
+// It reports false if the endpoint requires a state and the scenario is no longer in it.
+func (eu EndpointUsecase) transition(e model.Endpoint, state string) bool {
+	if e.ScenarioName == "" || e.NewScenarioState == "" {
+		return true
+	}
+	if !requiresScenarioState(e) {
+		eu.sr.SetState(e.ScenarioName, e.NewScenarioState)
+	} else if !eu.sr.CompareAndSwap(e.ScenarioName, state, e.NewScenarioState) {
+		return false
+	}
+	slog.Info(fmt.Sprintf("Scenario %s moved from %s to %s", e.ScenarioName, state, e.NewScenarioState))
+	return true
+}
+
+// loadResponseBody returns the response body template of the endpoint,
+// reading it from bodyFileName when one is set.
+func loadResponseBody(e model.Endpoint) (string, error) {
+	switch {
+	case e.Response.Fault != "":
+		// no response is sent
+		return "", nil
+	case e.Response.ProxyBaseURL != "":
+		// the body comes from the proxied server
+		return "", nil
+	case e.Response.BodyFileName != "":
+		file, err := os.Open(e.Response.BodyFileName)
 		if err != nil {
-			slog.Error(fmt.Sprintf("Failed to read request body: %s", err))
-			return EndpointMatcherResult{}, err
+			slog.Error(fmt.Sprintf("Failed to open body file: %s", err))
+			return "", err
 		}
-		isMatchBody := e.BodyMatcher(string(body))
-		isMatchHeaders, headersMap := e.HeaderMatcher(arg.Request.Headers)
-		if arg.Request.Method == e.Request.Method && isMatchPath && isMatchQuery && isMatchBody && isMatchHeaders {
-			slog.Info(fmt.Sprintf("Matched endpoint: %s", e.Name))
-			return EndpointMatcherResult{
-				Endpoint:       e,
-				ResponseBody:   responseBody,
-				ResponseStatus: e.Response.Status,
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
-					Path:    pathMap,
-					Query:   queryMap,
-					Headers: headersMap,
-				},
-			}, nil
+		defer func() {
+			if err := file.Close(); err != nil {
+				slog.Error(fmt.Sprintf("Failed to close file: %s", err))
+			}
+		}()
+		body, err := io.ReadAll(file)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to read body file: %s", err))
+			return "", err
 		}
+		return string(body), nil
+	case e.Response.Body != "":
+		return e.Response.Body, nil
+	default:
+		slog.Error("Response body is empty")
+		return "", fmt.Errorf("response body is empty")
 	}
-	return EndpointMatcherResult{}, fmt.Errorf("no matching endpoint found")
 }
 
 type ResponseCreatorArgs struct {
 	Request struct {
 		UrlQuery url.Values
+		Body     string // request body that the jsonPath template function selects from
 	}
 	Endpoint     model.Endpoint
 	ResponseBody string
 	PathMap      map[string]string
 }
 type ResponseCreatorResult struct {
-	Template *template.Template
+	Template BodyTemplate
+	Headers  map[string][]*texttemplate.Template
 }
 
 func (eu EndpointUsecase) ResponseCreator(arg ResponseCreatorArgs) (ResponseCreatorResult, error) {
-	tpl, err := template.New("response").Parse(arg.ResponseBody)
+	funcs := templateFuncs(arg.Request.Body)
+	tpl, err := parseBodyTemplate(arg.Endpoint.Response.BodyTemplateEngine(), arg.ResponseBody, funcs)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to parse response template: %s", err))
 		return ResponseCreatorResult{}, err
 	}
+	headers := make(map[string][]*texttemplate.Template, len(arg.Endpoint.Response.Headers))
+	for k, values := range arg.Endpoint.Response.Headers {
+		for _, v := range values {
+			htpl, err := texttemplate.New(k).Funcs(funcs).Parse(v)
+			if err != nil {
+				slog.Error(fmt.Sprintf("Failed to parse response header template %s: %s", k, err))
+				return ResponseCreatorResult{}, err
+			}
+			headers[k] = append(headers[k], htpl)
+		}
+	}
 	return ResponseCreatorResult{
 		Template: tpl,
+		Headers:  headers,
 	}, nil
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/dev-shimada/gostubby/internal/domain/repository"
 	"github.com/dev-shimada/gostubby/internal/usecase"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 // モックリポジトリ実装
@@ -24,9 +25,94 @@ func (m *mockConfigRepository) Load(path string) ([]model.Endpoint, error) {
 	return m.endpoints, m.err
 }
 
+type mockMappingRepository struct {
+	endpoints []model.Endpoint
+}
+
+func (m *mockMappingRepository) List() []model.Endpoint {
+	return m.endpoints
+}
+
+func (m *mockMappingRepository) Get(id string) (model.Endpoint, error) {
+	for _, e := range m.endpoints {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return model.Endpoint{}, repository.ErrMappingNotFound
+}
+
+func (m *mockMappingRepository) Create(endpoint model.Endpoint) error {
+	if _, err := m.Get(endpoint.ID); err == nil {
+		return repository.ErrMappingExists
+	}
+	m.endpoints = append(m.endpoints, endpoint)
+	return nil
+}
+
+func (m *mockMappingRepository) Update(endpoint model.Endpoint) error {
+	for i, e := range m.endpoints {
+		if e.ID == endpoint.ID {
+			m.endpoints[i] = endpoint
+			return nil
+		}
+	}
+	return repository.ErrMappingNotFound
+}
+
+func (m *mockMappingRepository) Delete(id string) error {
+	for i, e := range m.endpoints {
+		if e.ID == id {
+			m.endpoints = append(m.endpoints[:i], m.endpoints[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrMappingNotFound
+}
+
+func (m *mockMappingRepository) Reset() {
+	m.endpoints = nil
+}
+
+type mockScenarioRepository struct {
+	states map[string]string
+}
+
+func newMockScenarioRepository() *mockScenarioRepository {
+	return &mockScenarioRepository{states: map[string]string{}}
+}
+
+func (m *mockScenarioRepository) State(name string) string {
+	if state, ok := m.states[name]; ok {
+		return state
+	}
+	return model.ScenarioStarted
+}
+
+func (m *mockScenarioRepository) SetState(name, state string) {
+	m.states[name] = state
+}
+
+func (m *mockScenarioRepository) CompareAndSwap(name, oldState, newState string) bool {
+	if m.State(name) != oldState {
+		return false
+	}
+	m.states[name] = newState
+	return true
+}
+
+func (m *mockScenarioRepository) States() map[string]string {
+	return m.states
+}
+
+func (m *mockScenarioRepository) Reset() {
+	clear(m.states)
+}
+
 func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 	type fields struct {
 		cr repository.ConfigRepository
+		mr repository.MappingRepository
 	}
 	type args struct {
 		arg usecase.EndpointMatcherArgs
@@ -46,7 +132,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "Test Endpoint",
 							Request: model.Request{
-								Method:          "GET",
+								Method:          model.Method("GET"),
 								URLPathTemplate: "/users/{id}",
 								PathParameters: map[string]model.Matcher{
 									"id": {
@@ -64,15 +150,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/users/123",
 						UrlPath:    "/users/123",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -85,7 +163,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				Endpoint: model.Endpoint{
 					Name: "Test Endpoint",
 					Request: model.Request{
-						Method:          "GET",
+						Method:          model.Method("GET"),
 						URLPathTemplate: "/users/{id}",
 						PathParameters: map[string]model.Matcher{
 							"id": {
@@ -100,11 +178,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				},
 				ResponseBody:   `{"id": "123", "name": "Test User"}`,
 				ResponseStatus: 200,
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
+				Data: usecase.TemplateData{
 					Path: map[string]string{
 						"id": "123",
 					},
@@ -121,7 +195,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "Regex Test Endpoint",
 							Request: model.Request{
-								Method:          "GET",
+								Method:          model.Method("GET"),
 								URLPathTemplate: "/products/{id}",
 								PathParameters: map[string]model.Matcher{
 									"id": {
@@ -139,15 +213,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/products/456",
 						UrlPath:    "/products/456",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -160,7 +226,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				Endpoint: model.Endpoint{
 					Name: "Regex Test Endpoint",
 					Request: model.Request{
-						Method:          "GET",
+						Method:          model.Method("GET"),
 						URLPathTemplate: "/products/{id}",
 						PathParameters: map[string]model.Matcher{
 							"id": {
@@ -175,11 +241,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				},
 				ResponseBody:   `{"id": "456", "name": "Test Product"}`,
 				ResponseStatus: 200,
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
+				Data: usecase.TemplateData{
 					Path: map[string]string{
 						"id": "456",
 					},
@@ -196,7 +258,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "Query Test Endpoint",
 							Request: model.Request{
-								Method:          "GET",
+								Method:          model.Method("GET"),
 								URLPathTemplate: "/search",
 								QueryParameters: map[string]model.Matcher{
 									"q": {
@@ -217,15 +279,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/search",
 						UrlPath:    "/search",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -246,7 +300,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				Endpoint: model.Endpoint{
 					Name: "Query Test Endpoint",
 					Request: model.Request{
-						Method:          "GET",
+						Method:          model.Method("GET"),
 						URLPathTemplate: "/search",
 						QueryParameters: map[string]model.Matcher{
 							"q": {
@@ -264,16 +318,16 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				},
 				ResponseBody:   `{"results": [{"name": "Test Result"}]}`,
 				ResponseStatus: 200,
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
+				Data: usecase.TemplateData{
 					Path: map[string]string{},
 					Query: map[string]string{
 						"q":    "test-query",
 						"page": "1",
 					},
+					QueryValues: map[string][]string{
+						"q":    {"test-query"},
+						"page": {"1"},
+					},
 				},
 			},
 			wantErr: false,
@@ -286,7 +340,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "Body Test Endpoint",
 							Request: model.Request{
-								Method:          "POST",
+								Method:          model.Method("POST"),
 								URLPathTemplate: "/api/users",
 								Body: model.Matcher{
 									Contains:       "email",
@@ -303,15 +357,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/api/users",
 						UrlPath:    "/api/users",
 						Body:       io.NopCloser(strings.NewReader(`{"name":"John","email":"john@example.com"}`)),
@@ -324,7 +370,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				Endpoint: model.Endpoint{
 					Name: "Body Test Endpoint",
 					Request: model.Request{
-						Method:          "POST",
+						Method:          model.Method("POST"),
 						URLPathTemplate: "/api/users",
 						Body: model.Matcher{
 							Contains:       "email",
@@ -338,17 +384,150 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				},
 				ResponseBody:   `{"id": "789", "status": "created"}`,
 				ResponseStatus: 201,
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
+				Data: usecase.TemplateData{
 					Path:  map[string]string{},
 					Query: map[string]string{},
 				},
 			},
 			wantErr: false,
 		},
+		{
+			name: "管理APIで登録したマッピングが優先される",
+			fields: fields{
+				cr: &mockConfigRepository{
+					endpoints: []model.Endpoint{
+						{
+							Name: "File Endpoint",
+							Request: model.Request{
+								Method:  model.Method("GET"),
+								URLPath: "/users",
+							},
+							Response: model.Response{
+								Status: 200,
+								Body:   "from file",
+							},
+						},
+					},
+				},
+				mr: &mockMappingRepository{
+					endpoints: []model.Endpoint{
+						{
+							ID:   "admin-1",
+							Name: "Admin Endpoint",
+							Request: model.Request{
+								Method:  model.Method("GET"),
+								URLPath: "/users",
+							},
+							Response: model.Response{
+								Status: 200,
+								Body:   "from admin",
+							},
+						},
+					},
+				},
+			},
+			args: args{
+				arg: usecase.EndpointMatcherArgs{
+					Request: usecase.MatcherRequest{
+						UrlRawPath: "/users",
+						UrlPath:    "/users",
+						Body:       io.NopCloser(strings.NewReader("")),
+						Method:     "GET",
+					},
+					ConfigPath: "test-config.json",
+				},
+			},
+			want: usecase.EndpointMatcherResult{
+				Endpoint: model.Endpoint{
+					ID:   "admin-1",
+					Name: "Admin Endpoint",
+					Request: model.Request{
+						Method:  model.Method("GET"),
+						URLPath: "/users",
+					},
+					Response: model.Response{
+						Status: 200,
+						Body:   "from admin",
+					},
+				},
+				ResponseBody:   "from admin",
+				ResponseStatus: 200,
+				Data: usecase.TemplateData{
+					Query: map[string]string{},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "2番目以降のエンドポイントでもリクエストボディを照合できる",
+			fields: fields{
+				cr: &mockConfigRepository{
+					endpoints: []model.Endpoint{
+						{
+							Name: "First Endpoint",
+							Request: model.Request{
+								Method:  model.Method("POST"),
+								URLPath: "/orders",
+								Body: model.Matcher{
+									Contains: "first",
+								},
+							},
+							Response: model.Response{
+								Status: 200,
+								Body:   "first",
+							},
+						},
+						{
+							Name: "Second Endpoint",
+							Request: model.Request{
+								Method:  model.Method("POST"),
+								URLPath: "/orders",
+								Body: model.Matcher{
+									Contains: "second",
+								},
+							},
+							Response: model.Response{
+								Status: 201,
+								Body:   "second",
+							},
+						},
+					},
+				},
+			},
+			args: args{
+				arg: usecase.EndpointMatcherArgs{
+					Request: usecase.MatcherRequest{
+						UrlRawPath: "/orders",
+						UrlPath:    "/orders",
+						Body:       io.NopCloser(strings.NewReader(`{"type": "second"}`)),
+						Method:     "POST",
+					},
+					ConfigPath: "test-config.json",
+				},
+			},
+			want: usecase.EndpointMatcherResult{
+				Endpoint: model.Endpoint{
+					Name: "Second Endpoint",
+					Request: model.Request{
+						Method:  model.Method("POST"),
+						URLPath: "/orders",
+						Body: model.Matcher{
+							Contains: "second",
+						},
+					},
+					Response: model.Response{
+						Status: 201,
+						Body:   "second",
+					},
+				},
+				ResponseBody:   "second",
+				ResponseStatus: 201,
+				Data: usecase.TemplateData{
+					Query: map[string]string{},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "マッチするエンドポイントがない場合",
 			fields: fields{
@@ -357,7 +536,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "Test Endpoint",
 							Request: model.Request{
-								Method:          "GET",
+								Method:          model.Method("GET"),
 								URLPathTemplate: "/users/{id}",
 								PathParameters: map[string]model.Matcher{
 									"id": {
@@ -375,15 +554,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/users/456", // 存在しないID
 						UrlPath:    "/users/456",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -405,15 +576,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/users/123",
 						UrlPath:    "/users/123",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -433,7 +596,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "File Body Test Endpoint",
 							Request: model.Request{
-								Method:          "GET",
+								Method:          model.Method("GET"),
 								URLPathTemplate: "/api/data",
 							},
 							Response: model.Response{
@@ -446,15 +609,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/api/data",
 						UrlPath:    "/api/data",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -467,7 +622,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				Endpoint: model.Endpoint{
 					Name: "File Body Test Endpoint",
 					Request: model.Request{
-						Method:          "GET",
+						Method:          model.Method("GET"),
 						URLPathTemplate: "/api/data",
 					},
 					Response: model.Response{
@@ -477,11 +632,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 				},
 				ResponseBody:   "{\"id\": \"test123\", \"message\": \"This is from a test file\"}\n",
 				ResponseStatus: 200,
-				Data: struct {
-					Path    map[string]string
-					Query   map[string]string
-					Headers map[string][]string
-				}{
+				Data: usecase.TemplateData{
 					Path:  map[string]string{},
 					Query: map[string]string{},
 				},
@@ -496,7 +647,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "File Open Error Test",
 							Request: model.Request{
-								Method:          "GET",
+								Method:          model.Method("GET"),
 								URLPathTemplate: "/api/error",
 							},
 							Response: model.Response{
@@ -509,15 +660,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/api/error",
 						UrlPath:    "/api/error",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -537,7 +680,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 						{
 							Name: "Empty Response Test",
 							Request: model.Request{
-								Method:          "GET",
+								Method:          model.Method("GET"),
 								URLPathTemplate: "/api/empty",
 							},
 							Response: model.Response{
@@ -550,15 +693,7 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			},
 			args: args{
 				arg: usecase.EndpointMatcherArgs{
-					Request: struct {
-						UrlRawPath     string
-						UrlPath        string
-						Body           io.ReadCloser
-						Method         string
-						Headers        map[string][]string
-						RawQueryValues url.Values
-						QueryValues    url.Values
-					}{
+					Request: usecase.MatcherRequest{
 						UrlRawPath: "/api/empty",
 						UrlPath:    "/api/empty",
 						Body:       io.NopCloser(strings.NewReader("")),
@@ -570,17 +705,69 @@ func TestEndpointUsecase_EndpointMatcher(t *testing.T) {
 			want:    usecase.EndpointMatcherResult{},
 			wantErr: true,
 		},
+		{
+			name: "プロキシするエンドポイントはボディが空でもマッチする",
+			fields: fields{
+				cr: &mockConfigRepository{
+					endpoints: []model.Endpoint{
+						{
+							Name: "Proxy Test",
+							Request: model.Request{
+								Method:         model.Method("DELETE"),
+								URLPathPattern: "/api/.*",
+							},
+							Response: model.Response{
+								ProxyBaseURL: "http://localhost:8081",
+							},
+						},
+					},
+				},
+			},
+			args: args{
+				arg: usecase.EndpointMatcherArgs{
+					Request: usecase.MatcherRequest{
+						UrlRawPath: "/api/users",
+						UrlPath:    "/api/users",
+						Body:       io.NopCloser(strings.NewReader("")),
+						Method:     "DELETE",
+					},
+					ConfigPath: "test-config.json",
+				},
+			},
+			want: usecase.EndpointMatcherResult{
+				Endpoint: model.Endpoint{
+					Name: "Proxy Test",
+					Request: model.Request{
+						Method:         model.Method("DELETE"),
+						URLPathPattern: "/api/.*",
+					},
+					Response: model.Response{
+						ProxyBaseURL: "http://localhost:8081",
+					},
+				},
+				Data: usecase.TemplateData{
+					Query: map[string]string{},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			eu := usecase.NewEndpointUsecase(tt.fields.cr)
+			mr := tt.fields.mr
+			if mr == nil {
+				mr = &mockMappingRepository{}
+			}
+			eu := usecase.NewEndpointUsecase(tt.fields.cr, mr, newMockScenarioRepository())
 			got, err := eu.EndpointMatcher(tt.args.arg)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("EndpointUsecase.EndpointMatcher() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !tt.wantErr {
-				diff := cmp.Diff(tt.want, got)
+				// the reason for the match is covered by TestEndpointUsecase_EndpointMatcherPriority,
+				// the request by TestEndpointUsecase_EndpointMatcherTemplateRequest
+				diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(usecase.EndpointMatcherResult{}, "Match"), cmpopts.IgnoreFields(usecase.TemplateData{}, "Request"))
 				if diff != "" {
 					t.Errorf("EndpointUsecase.EndpointMatcher() mismatch (-want +got):\n%s", diff)
 				}
@@ -612,6 +799,7 @@ func TestEndpointUsecase_ResponseCreator(t *testing.T) {
 				arg: usecase.ResponseCreatorArgs{
 					Request: struct {
 						UrlQuery url.Values
+						Body     string
 					}{
 						UrlQuery: url.Values{
 							"param": []string{"value"},
@@ -675,7 +863,7 @@ func TestEndpointUsecase_ResponseCreator(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			eu := usecase.NewEndpointUsecase(tt.fields.cr)
+			eu := usecase.NewEndpointUsecase(tt.fields.cr, &mockMappingRepository{}, newMockScenarioRepository())
 			got, err := eu.ResponseCreator(tt.args.arg)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("EndpointUsecase.ResponseCreator() error = %v, wantErr %v", err, tt.wantErr)
@@ -722,3 +910,53 @@ func TestEndpointUsecase_ResponseCreator(t *testing.T) {
 		})
 	}
 }
+
+func TestEndpointUsecase_ResponseCreatorHeaders(t *testing.T) {
+	eu := usecase.NewEndpointUsecase(&mockConfigRepository{}, &mockMappingRepository{}, newMockScenarioRepository())
+	got, err := eu.ResponseCreator(usecase.ResponseCreatorArgs{
+		Endpoint: model.Endpoint{
+			Response: model.Response{
+				Headers: map[string]model.ResponseHeader{
+					"Location":   {"/users/{{.Path.id}}"},
+					"Set-Cookie": {"a=1", "b=2"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("EndpointUsecase.ResponseCreator() error = %v", err)
+	}
+	data := struct {
+		Path map[string]string
+	}{
+		Path: map[string]string{"id": "123"},
+	}
+	rendered := map[string][]string{}
+	for k, tpls := range got.Headers {
+		for _, tpl := range tpls {
+			var buf strings.Builder
+			if err := tpl.Execute(&buf, data); err != nil {
+				t.Fatalf("Failed to execute header template: %v", err)
+			}
+			rendered[k] = append(rendered[k], buf.String())
+		}
+	}
+	want := map[string][]string{
+		"Location":   {"/users/123"},
+		"Set-Cookie": {"a=1", "b=2"},
+	}
+	if diff := cmp.Diff(want, rendered); diff != "" {
+		t.Errorf("EndpointUsecase.ResponseCreator() headers mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = eu.ResponseCreator(usecase.ResponseCreatorArgs{
+		Endpoint: model.Endpoint{
+			Response: model.Response{
+				Headers: map[string]model.ResponseHeader{"X-Broken": {"{{.Path.id"}},
+			},
+		},
+	})
+	if err == nil {
+		t.Errorf("EndpointUsecase.ResponseCreator() expected error for invalid header template")
+	}
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// parseForm reads the fields of an application/x-www-form-urlencoded or multipart/form-data body,
+// and the parts of a multipart one. The fields of a multipart body are its parts without a file name.
+func parseForm(headers map[string][]string, body string) (map[string][]string, []model.MultipartPart) {
+	mediaType, params, err := mime.ParseMediaType(http.Header(headers).Get("Content-Type"))
+	if err != nil {
+		return nil, nil
+	}
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		// malformed pairs are skipped, the rest is still usable
+		form, _ := url.ParseQuery(body)
+		return form, nil
+	case "multipart/form-data":
+		form := make(map[string][]string)
+		var parts []model.MultipartPart
+		r := multipart.NewReader(strings.NewReader(body), params["boundary"])
+		for {
+			p, err := r.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				slog.Error(fmt.Sprintf("Failed to read multipart body: %s", err))
+				break
+			}
+			b, err := io.ReadAll(p)
+			if err != nil {
+				slog.Error(fmt.Sprintf("Failed to read multipart body: %s", err))
+				break
+			}
+			part := model.MultipartPart{
+				Name:     p.FormName(),
+				FileName: p.FileName(),
+				Headers:  p.Header,
+				Body:     string(b),
+			}
+			if part.Name != "" && part.FileName == "" {
+				form[part.Name] = append(form[part.Name], part.Body)
+			}
+			parts = append(parts, part)
+		}
+		return form, parts
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,134 @@
+package usecase_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEndpointUsecase_EndpointMatcherForm(t *testing.T) {
+	var upload bytes.Buffer
+	mw := multipart.NewWriter(&upload)
+	if err := mw.WriteField("title", "Holiday"); err != nil {
+		t.Fatal(err)
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="file"; filename="beach.png"`)
+	h.Set("Content-Type", "image/png")
+	fw, err := mw.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte("\x89PNG")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints := []model.Endpoint{
+		{
+			ID: "token",
+			Request: model.Request{
+				Method:  model.Method("POST"),
+				URLPath: "/token",
+				FormParameters: map[string]model.Matcher{
+					"grant_type": {EqualTo: "password"},
+					"scope":      {Includes: []model.Matcher{{EqualTo: "read"}}},
+				},
+			},
+			Response: model.Response{Status: 200, Body: "token"},
+		},
+		{
+			ID: "upload",
+			Request: model.Request{
+				Method:  model.Method("POST"),
+				URLPath: "/photos",
+				MultipartPatterns: []model.MultipartPattern{
+					{Name: model.Matcher{EqualTo: "file"}, FileName: model.Matcher{Matches: `\.png$`}},
+				},
+			},
+			Response: model.Response{Status: 201, Body: "uploaded"},
+		},
+	}
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantID      string
+		wantData    usecase.TemplateData
+		wantErr     bool
+	}{
+		{
+			name:        "フォームパラメータのマッチング",
+			path:        "/token",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=password&scope=write&scope=read&username=alice",
+			wantID:      "token",
+			wantData: usecase.TemplateData{
+				Form:       map[string]string{"grant_type": "password", "scope": "write", "username": "alice"},
+				FormValues: map[string][]string{"grant_type": {"password"}, "scope": {"write", "read"}, "username": {"alice"}},
+			},
+		},
+		{
+			name:        "フォームパラメータが一致しない",
+			path:        "/token",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials&scope=read",
+			wantErr:     true,
+		},
+		{
+			name:        "フォームでないボディ",
+			path:        "/token",
+			contentType: "application/json",
+			body:        `{"grant_type": "password", "scope": "read"}`,
+			wantErr:     true,
+		},
+		{
+			name:        "マルチパートのファイルアップロード",
+			path:        "/photos",
+			contentType: mw.FormDataContentType(),
+			body:        upload.String(),
+			wantID:      "upload",
+			wantData: usecase.TemplateData{
+				Form:       map[string]string{"title": "Holiday"},
+				FormValues: map[string][]string{"title": {"Holiday"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arg usecase.EndpointMatcherArgs
+			arg.Request.Method = "POST"
+			arg.Request.UrlPath = tt.path
+			arg.Request.Headers = map[string][]string{"Content-Type": {tt.contentType}}
+			arg.Request.Body = io.NopCloser(strings.NewReader(tt.body))
+
+			eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, newMockScenarioRepository())
+			got, err := eu.EndpointMatcher(arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Endpoint.ID != tt.wantID {
+				t.Errorf("EndpointUsecase.EndpointMatcher() matched %q, want %q", got.Endpoint.ID, tt.wantID)
+			}
+			if diff := cmp.Diff(tt.wantData.Form, got.Data.Form); diff != "" {
+				t.Errorf("Form mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantData.FormValues, got.Data.FormValues); diff != "" {
+				t.Errorf("FormValues mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+)
+
+var ErrInvalidRequestMatcher = errors.New("invalid request matcher")
+
+type JournalUsecase struct {
+	jr repository.JournalRepository
+}
+
+func NewJournalUsecase(jr repository.JournalRepository) JournalUsecase {
+	return JournalUsecase{
+		jr: jr,
+	}
+}
+
+// JournalFilter narrows down the requests returned by JournalUsecase.List.
+// Zero values mean "no restriction".
+type JournalFilter struct {
+	Method    string
+	Path      string
+	Unmatched bool
+	Since     time.Time
+	Limit     int
+}
+
+// Record adds a request to the journal, assigning an ID and a timestamp when they are missing.
+func (ju JournalUsecase) Record(request model.LoggedRequest) {
+	if request.ID == "" {
+		request.ID = newID()
+	}
+	if request.LoggedAt.IsZero() {
+		request.LoggedAt = time.Now()
+	}
+	ju.jr.Add(request)
+}
+
+// List returns the recorded requests that pass the filter, newest first.
+func (ju JournalUsecase) List(filter JournalFilter) []model.LoggedRequest {
+	ret := []model.LoggedRequest{}
+	requests := ju.jr.List()
+	for _, r := range slices.Backward(requests) {
+		switch {
+		case filter.Method != "" && r.Method != filter.Method:
+			continue
+		case filter.Path != "" && r.Path != filter.Path:
+			continue
+		case filter.Unmatched && r.MatchedEndpointID != "":
+			continue
+		case !filter.Since.IsZero() && r.LoggedAt.Before(filter.Since):
+			continue
+		}
+		ret = append(ret, r)
+		if filter.Limit > 0 && len(ret) == filter.Limit {
+			break
+		}
+	}
+	return ret
+}
+
+func (ju JournalUsecase) Clear() {
+	ju.jr.Clear()
+}
+
+// Find returns the recorded requests, oldest first, that satisfy the request matcher.
+// Unlike stub matching, an unset URL matches any request.
+func (ju JournalUsecase) Find(matcher model.Request) ([]model.LoggedRequest, error) {
+	e := model.Endpoint{Request: matcher}
+	if err := e.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequestMatcher, err)
+	}
+	anyPath := matcher.URL == "" && matcher.URLPattern == "" && matcher.URLPath == "" &&
+		matcher.URLPathPattern == "" && matcher.URLPathTemplate == ""
+
+	ret := []model.LoggedRequest{}
+	for _, r := range ju.jr.List() {
+		req := incomingRequest{
+			Method:         r.Method,
+			RawPath:        r.RawPath,
+			Path:           r.Path,
+			RawQueryValues: r.RawQuery,
+			QueryValues:    r.Query,
+			Headers:        r.Headers,
+			Body:           r.Body,
+			Scheme:         r.Scheme,
+			Host:           r.Host,
+			Port:           r.Port,
+		}
+		req.Form, req.Parts = parseForm(req.Headers, req.Body)
+		m := matchEndpoint(e, req, "")
+		m.Path = m.Path || anyPath
+		if m.matched() {
+			ret = append(ret, r)
+		}
+	}
+	return ret, nil
+}
+
+func (ju JournalUsecase) Count(matcher model.Request) (int, error) {
+	requests, err := ju.Find(matcher)
+	return len(requests), err
+}
@@ -0,0 +1,191 @@
+package usecase_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockJournalRepository struct {
+	requests []model.LoggedRequest
+}
+
+func (m *mockJournalRepository) Add(r model.LoggedRequest) {
+	m.requests = append(m.requests, r)
+}
+
+func (m *mockJournalRepository) List() []model.LoggedRequest {
+	return m.requests
+}
+
+func (m *mockJournalRepository) Clear() {
+	m.requests = nil
+}
+
+func loggedIDs(requests []model.LoggedRequest) []string {
+	ret := []string{}
+	for _, r := range requests {
+		ret = append(ret, r.ID)
+	}
+	return ret
+}
+
+var journalFixture = []model.LoggedRequest{
+	{
+		ID:                "1",
+		Method:            "POST",
+		Path:              "/orders",
+		Headers:           map[string][]string{"X-Tenant": {"acme"}},
+		Body:              `{"sku": "A1"}`,
+		MatchedEndpointID: "orders",
+		LoggedAt:          time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:       "2",
+		Method:   "GET",
+		Path:     "/orders",
+		Query:    url.Values{"page": {"1"}},
+		RawQuery: url.Values{"page": {"1"}},
+		LoggedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:                "3",
+		Method:            "POST",
+		Path:              "/orders",
+		Headers:           map[string][]string{"X-Tenant": {"other"}},
+		Body:              `{"sku": "B2"}`,
+		MatchedEndpointID: "orders",
+		LoggedAt:          time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:                "4",
+		Method:            "POST",
+		Path:              "/orders",
+		Headers:           map[string][]string{"X-Tenant": {"acme"}},
+		Body:              `{"sku": "C3"}`,
+		MatchedEndpointID: "orders",
+		LoggedAt:          time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func TestJournalUsecase_Record(t *testing.T) {
+	jr := &mockJournalRepository{}
+	ju := usecase.NewJournalUsecase(jr)
+	ju.Record(model.LoggedRequest{Method: "GET"})
+
+	if len(jr.requests) != 1 {
+		t.Fatalf("JournalUsecase.Record() recorded %d requests, want 1", len(jr.requests))
+	}
+	if jr.requests[0].ID == "" || jr.requests[0].LoggedAt.IsZero() {
+		t.Errorf("JournalUsecase.Record() did not assign ID and timestamp: %+v", jr.requests[0])
+	}
+}
+
+func TestJournalUsecase_List(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter usecase.JournalFilter
+		want   []string
+	}{
+		{
+			name: "フィルタなしは新しい順",
+			want: []string{"4", "3", "2", "1"},
+		},
+		{
+			name:   "メソッドで絞り込み",
+			filter: usecase.JournalFilter{Method: "GET"},
+			want:   []string{"2"},
+		},
+		{
+			name:   "マッチしなかったリクエストのみ",
+			filter: usecase.JournalFilter{Unmatched: true},
+			want:   []string{"2"},
+		},
+		{
+			name:   "指定日時以降と件数制限",
+			filter: usecase.JournalFilter{Since: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), Limit: 2},
+			want:   []string{"4", "3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ju := usecase.NewJournalUsecase(&mockJournalRepository{requests: journalFixture})
+			if diff := cmp.Diff(tt.want, loggedIDs(ju.List(tt.filter))); diff != "" {
+				t.Errorf("JournalUsecase.List() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJournalUsecase_Find(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher model.Request
+		want    []string
+	}{
+		{
+			name:    "空のマッチャーはすべてにマッチ",
+			matcher: model.Request{},
+			want:    []string{"1", "2", "3", "4"},
+		},
+		{
+			name: "メソッド、パス、ヘッダーでの照合",
+			matcher: model.Request{
+				Method:  model.Method("POST"),
+				URLPath: "/orders",
+				Headers: map[string]model.Matcher{
+					"X-Tenant": {EqualTo: "acme"},
+				},
+			},
+			want: []string{"1", "4"},
+		},
+		{
+			name: "ボディでの照合",
+			matcher: model.Request{
+				Body: model.Matcher{Contains: "B2"},
+			},
+			want: []string{"3"},
+		},
+		{
+			name: "クエリパラメータでの照合",
+			matcher: model.Request{
+				QueryParameters: map[string]model.Matcher{
+					"page": {EqualTo: "1"},
+				},
+			},
+			want: []string{"2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ju := usecase.NewJournalUsecase(&mockJournalRepository{requests: journalFixture})
+			got, err := ju.Find(tt.matcher)
+			if err != nil {
+				t.Fatalf("JournalUsecase.Find() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, loggedIDs(got)); diff != "" {
+				t.Errorf("JournalUsecase.Find() mismatch (-want +got):\n%s", diff)
+			}
+			if count, err := ju.Count(tt.matcher); err != nil || count != len(tt.want) {
+				t.Errorf("JournalUsecase.Count() = %d, %v, want %d", count, err, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestJournalUsecase_FindInvalidMatcher(t *testing.T) {
+	ju := usecase.NewJournalUsecase(&mockJournalRepository{requests: journalFixture})
+	// 不正な正規表現はパニックせずエラーになる
+	_, err := ju.Find(model.Request{Body: model.Matcher{Matches: "["}})
+	if !errors.Is(err, usecase.ErrInvalidRequestMatcher) {
+		t.Errorf("JournalUsecase.Find() error = %v, want ErrInvalidRequestMatcher", err)
+	}
+	if _, err := ju.Count(model.Request{Headers: map[string]model.Matcher{"X-Tenant": {Contains: 1}}}); !errors.Is(err, usecase.ErrInvalidRequestMatcher) {
+		t.Errorf("JournalUsecase.Count() error = %v, want ErrInvalidRequestMatcher", err)
+	}
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+)
+
+var ErrInvalidMapping = errors.New("invalid mapping")
+
+type MappingUsecase struct {
+	mr repository.MappingRepository
+}
+
+func NewMappingUsecase(mr repository.MappingRepository) MappingUsecase {
+	return MappingUsecase{
+		mr: mr,
+	}
+}
+
+func (mu MappingUsecase) List() []model.Endpoint {
+	return mu.mr.List()
+}
+
+func (mu MappingUsecase) Get(id string) (model.Endpoint, error) {
+	return mu.mr.Get(id)
+}
+
+// Create registers a new mapping. An ID is generated when the endpoint does not have one.
+func (mu MappingUsecase) Create(endpoint model.Endpoint) (model.Endpoint, error) {
+	if err := endpoint.Validate(); err != nil {
+		return model.Endpoint{}, fmt.Errorf("%w: %w", ErrInvalidMapping, err)
+	}
+	if endpoint.ID == "" {
+		endpoint.ID = newID()
+	}
+	if err := mu.mr.Create(endpoint); err != nil {
+		return model.Endpoint{}, err
+	}
+	slog.Info(fmt.Sprintf("Created mapping: %s", endpoint.ID))
+	return endpoint, nil
+}
+
+// Update replaces the mapping with the given ID. The ID in the path always wins over the one in the body.
+func (mu MappingUsecase) Update(id string, endpoint model.Endpoint) (model.Endpoint, error) {
+	if err := endpoint.Validate(); err != nil {
+		return model.Endpoint{}, fmt.Errorf("%w: %w", ErrInvalidMapping, err)
+	}
+	endpoint.ID = id
+	if err := mu.mr.Update(endpoint); err != nil {
+		return model.Endpoint{}, err
+	}
+	slog.Info(fmt.Sprintf("Updated mapping: %s", id))
+	return endpoint, nil
+}
+
+func (mu MappingUsecase) Delete(id string) error {
+	if err := mu.mr.Delete(id); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Deleted mapping: %s", id))
+	return nil
+}
+
+func (mu MappingUsecase) Reset() {
+	mu.mr.Reset()
+	slog.Info("Reset mappings")
+}
+
+// newID returns a random (version 4) UUID.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
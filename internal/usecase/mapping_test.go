@@ -0,0 +1,96 @@
+package usecase_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMappingUsecase_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []model.Endpoint
+		endpoint model.Endpoint
+		wantID   *regexp.Regexp
+		wantErr  error
+	}{
+		{
+			name:     "IDが未指定の場合は採番される",
+			endpoint: model.Endpoint{Name: "new"},
+			wantID:   regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		},
+		{
+			name:     "指定したIDが使われる",
+			endpoint: model.Endpoint{ID: "my-id", Name: "new"},
+			wantID:   regexp.MustCompile(`^my-id$`),
+		},
+		{
+			name:     "IDが重複する場合はエラー",
+			existing: []model.Endpoint{{ID: "my-id"}},
+			endpoint: model.Endpoint{ID: "my-id", Name: "new"},
+			wantErr:  repository.ErrMappingExists,
+		},
+		{
+			name:     "不正な正規表現はエラー",
+			endpoint: model.Endpoint{Request: model.Request{Headers: map[string]model.Matcher{"X-Id": {Matches: "("}}}},
+			wantErr:  usecase.ErrInvalidMapping,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := &mockMappingRepository{endpoints: tt.existing}
+			mu := usecase.NewMappingUsecase(mr)
+			got, err := mu.Create(tt.endpoint)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MappingUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !tt.wantID.MatchString(got.ID) {
+				t.Errorf("MappingUsecase.Create() ID = %q, want %v", got.ID, tt.wantID)
+			}
+			stored, err := mr.Get(got.ID)
+			if err != nil {
+				t.Fatalf("created mapping is not stored: %v", err)
+			}
+			if diff := cmp.Diff(got, stored); diff != "" {
+				t.Errorf("stored mapping mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMappingUsecase_UpdateDelete(t *testing.T) {
+	mr := &mockMappingRepository{endpoints: []model.Endpoint{{ID: "a", Name: "before"}}}
+	mu := usecase.NewMappingUsecase(mr)
+
+	got, err := mu.Update("a", model.Endpoint{ID: "ignored", Name: "after"})
+	if err != nil {
+		t.Fatalf("MappingUsecase.Update() error = %v", err)
+	}
+	if diff := cmp.Diff(model.Endpoint{ID: "a", Name: "after"}, got); diff != "" {
+		t.Errorf("MappingUsecase.Update() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := mu.Update("missing", model.Endpoint{}); !errors.Is(err, repository.ErrMappingNotFound) {
+		t.Errorf("MappingUsecase.Update() error = %v, want %v", err, repository.ErrMappingNotFound)
+	}
+	if _, err := mu.Update("a", model.Endpoint{Request: model.Request{Body: model.Matcher{Contains: 1}}}); !errors.Is(err, usecase.ErrInvalidMapping) {
+		t.Errorf("MappingUsecase.Update() error = %v, want %v", err, usecase.ErrInvalidMapping)
+	}
+
+	if err := mu.Delete("a"); err != nil {
+		t.Fatalf("MappingUsecase.Delete() error = %v", err)
+	}
+	if err := mu.Delete("a"); !errors.Is(err, repository.ErrMappingNotFound) {
+		t.Errorf("MappingUsecase.Delete() error = %v, want %v", err, repository.ErrMappingNotFound)
+	}
+	if len(mu.List()) != 0 {
+		t.Errorf("MappingUsecase.List() = %v, want empty", mu.List())
+	}
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// incomingRequest is the part of an HTTP request that the stub matchers look at.
+type incomingRequest struct {
+	Method         string
+	RawPath        string
+	Path           string
+	RawQueryValues url.Values
+	QueryValues    url.Values
+	Headers        map[string][]string
+	Body           string
+	Scheme         string
+	Host           string
+	Port           string
+	Form           map[string][]string // fields of a form body
+	Parts          []model.MultipartPart
+	URL            string
+	RemoteAddr     string
+	ReceivedAt     time.Time
+}
+
+// matchResult holds the outcome of each matcher for a single endpoint.
+type matchResult struct {
+	Method    bool
+	HeadAsGet bool // the request is HEAD and the endpoint accepts GET
+	Path      bool
+	Query     bool
+	Headers   bool
+	Body      bool
+	// the following are true when the endpoint does not define them
+	Cookies   bool
+	Host      bool
+	Scheme    bool
+	Port      bool
+	Form      bool
+	Multipart bool
+	BasicAuth bool
+	JWT       bool
+	// Scenario is false when the endpoint requires a scenario state other than the current one
+	Scenario bool
+
+	ScenarioState string // current state of the endpoint's scenario, if it belongs to one
+	PathMap       map[string]string
+	QueryMap      map[string]string
+	HeadersMap    map[string][]string
+	Claims        map[string]any // claims of the request's JWT, if it has one
+}
+
+// matchedAsGet reports whether a HEAD request matched the endpoint when taken as GET.
+func (m matchResult) matchedAsGet() bool {
+	m.Method = m.Method || m.HeadAsGet
+	return m.matched()
+}
+
+func (m matchResult) matched() bool {
+	return m.Method && m.Path && m.Query && m.Headers && m.Body &&
+		m.Cookies && m.Host && m.Scheme && m.Port && m.Form && m.Multipart &&
+		m.BasicAuth && m.JWT && m.Scenario
+}
+
+// matchEndpoint matches the request against the endpoint.
+// scenarioState is the current state of the endpoint's scenario, or empty if it does not belong to one.
+func matchEndpoint(e model.Endpoint, req incomingRequest, scenarioState string) matchResult {
+	var ret matchResult
+	ret.Scenario = !requiresScenarioState(e) || e.RequiredScenarioState == scenarioState
+	ret.ScenarioState = scenarioState
+	ret.Method = e.MethodMatcher(req.Method)
+	ret.HeadAsGet = !ret.Method && req.Method == http.MethodHead && e.MethodMatcher(http.MethodGet)
+	ret.Path, ret.PathMap = e.PathMatcher(req.RawPath, req.Path)
+	ret.Query, ret.QueryMap = e.QueryMatcher(req.RawQueryValues, req.QueryValues)
+	ret.Headers, ret.HeadersMap = e.HeaderMatcher(req.Headers)
+	ret.Body = e.BodyMatcher(req.Body)
+	ret.Cookies = e.CookieMatcher(cookies(req.Headers))
+	ret.Host = e.HostMatcher(req.Host)
+	ret.Scheme = e.SchemeMatcher(req.Scheme)
+	ret.Port = e.PortMatcher(req.Port)
+	ret.Form = e.FormMatcher(req.Form)
+	ret.Multipart = e.MultipartMatcher(req.Parts)
+	ret.BasicAuth = e.BasicAuthMatcher(req.Headers)
+	ret.JWT, ret.Claims = e.JWTMatcher(req.Headers, jwtKey(e))
+	return ret
+}
+
+// jwtKeys keeps the key files read by jwtKey, so that a key file is read again only when it changes.
+var jwtKeys = struct {
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}{cache: make(map[string]cachedKey)}
+
+type cachedKey struct {
+	modTime time.Time
+	size    int64
+	key     []byte
+}
+
+// jwtKey reads the key file of the endpoint's jwt matcher, if it has one.
+// The key is reused while the modification time and size of the file stay the same.
+func jwtKey(e model.Endpoint) []byte {
+	if e.Request.JWT == nil || e.Request.JWT.KeyFile == "" {
+		return nil
+	}
+	path := e.Request.JWT.KeyFile
+	info, err := os.Stat(path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to read JWT key file: %s", err))
+		return nil
+	}
+	jwtKeys.mu.Lock()
+	defer jwtKeys.mu.Unlock()
+	if c, ok := jwtKeys.cache[path]; ok && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.key
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to read JWT key file: %s", err))
+		return nil
+	}
+	jwtKeys.cache[path] = cachedKey{modTime: info.ModTime(), size: info.Size(), key: key}
+	return key
+}
+
+// cookies returns the values of each cookie in the Cookie headers.
+func cookies(headers map[string][]string) map[string][]string {
+	ret := make(map[string][]string)
+	for _, c := range (&http.Request{Header: headers}).Cookies() {
+		ret[c.Name] = append(ret[c.Name], c.Value)
+	}
+	return ret
+}
+
+func requiresScenarioState(e model.Endpoint) bool {
+	return e.ScenarioName != "" && e.RequiredScenarioState != ""
+}
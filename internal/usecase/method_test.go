@@ -0,0 +1,113 @@
+package usecase_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+)
+
+func TestEndpointUsecase_EndpointMatcherMethod(t *testing.T) {
+	endpoints := []model.Endpoint{
+		{
+			ID:       "get-user",
+			Request:  model.Request{Method: model.Method("GET"), URLPath: "/users/1"},
+			Response: model.Response{Status: 200, Body: "user"},
+		},
+		{
+			ID:       "head-user",
+			Request:  model.Request{Method: model.Method("HEAD"), URLPath: "/users/1"},
+			Response: model.Response{Status: 204, Body: "-"},
+		},
+		{
+			ID:       "get-orders",
+			Request:  model.Request{Method: model.Method("GET"), URLPath: "/orders"},
+			Response: model.Response{Status: 200, Body: "orders"},
+		},
+		{
+			ID:       "update-order",
+			Request:  model.Request{Method: model.Method("PUT", "PATCH"), URLPath: "/orders"},
+			Response: model.Response{Status: 200, Body: "updated"},
+		},
+		{
+			ID:       "any",
+			Request:  model.Request{Method: model.Method(model.MethodAny), URLPath: "/health"},
+			Response: model.Response{Status: 200, Body: "ok"},
+		},
+		{
+			ID:       "no-method",
+			Request:  model.Request{URLPath: "/ping"},
+			Response: model.Response{Status: 200, Body: "pong"},
+		},
+		{
+			ID:       "not-delete",
+			Request:  model.Request{Method: model.MethodMatcher{Matcher: model.Matcher{Not: &model.Matcher{EqualTo: "DELETE"}}}, URLPath: "/items"},
+			Response: model.Response{Status: 200, Body: "items"},
+		},
+	}
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		wantID  string
+		wantErr bool
+	}{
+		{name: "HEADのスタブがあればそれを優先する", method: "HEAD", path: "/users/1", wantID: "head-user"},
+		{name: "HEADはGETのスタブにフォールバックする", method: "HEAD", path: "/orders", wantID: "get-orders"},
+		{name: "メソッドのリスト", method: "PATCH", path: "/orders", wantID: "update-order"},
+		{name: "リストにないメソッド", method: "DELETE", path: "/orders", wantErr: true},
+		{name: "ANY", method: "OPTIONS", path: "/health", wantID: "any"},
+		{name: "メソッドの指定なし", method: "POST", path: "/ping", wantID: "no-method"},
+		{name: "メソッドのマッチャー", method: "POST", path: "/items", wantID: "not-delete"},
+		{name: "メソッドのマッチャーが一致しない", method: "DELETE", path: "/items", wantErr: true},
+		{name: "POSTはGETとHEADのスタブにマッチしない", method: "POST", path: "/users/1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arg usecase.EndpointMatcherArgs
+			arg.Request.Method = tt.method
+			arg.Request.UrlPath = tt.path
+			arg.Request.Body = io.NopCloser(strings.NewReader(""))
+
+			eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, newMockScenarioRepository())
+			got, err := eu.EndpointMatcher(arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Endpoint.ID != tt.wantID {
+				t.Errorf("EndpointUsecase.EndpointMatcher() matched %q, want %q", got.Endpoint.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestEndpointUsecase_EndpointMatcherHeadNearMiss(t *testing.T) {
+	endpoints := []model.Endpoint{
+		{
+			ID:       "get-orders",
+			Request:  model.Request{Method: model.Method("GET"), URLPath: "/orders", QueryParameters: map[string]model.Matcher{"page": {EqualTo: "1"}}},
+			Response: model.Response{Status: 200, Body: "orders"},
+		},
+	}
+	var arg usecase.EndpointMatcherArgs
+	arg.Request.Method = "HEAD"
+	arg.Request.UrlPath = "/orders"
+	arg.Request.Body = io.NopCloser(strings.NewReader(""))
+
+	eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, newMockScenarioRepository())
+	_, err := eu.EndpointMatcher(arg)
+	var nm *usecase.NoMatchError
+	if !errors.As(err, &nm) {
+		t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, want NoMatchError", err)
+	}
+	// the GET endpoint accepts HEAD, so only the query is reported
+	if got := nm.NearMisses[0]; got.Score != 4 || len(got.Mismatches) != 1 || got.Mismatches[0].Matcher != "query.page" {
+		t.Errorf("Unexpected near miss: %+v", got)
+	}
+}
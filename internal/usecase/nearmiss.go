@@ -0,0 +1,424 @@
+package usecase
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// maxNearMisses is the number of closest endpoints reported when no endpoint matches.
+const maxNearMisses = 3
+
+// maxActualLength limits how much of an actual value (typically the body) is reported.
+const maxActualLength = 1024
+
+// matcherDimensions is the number of dimensions an endpoint is scored on.
+const matcherDimensions = 5
+
+// NoMatchError is returned by EndpointMatcher when no endpoint matches the request.
+// NearMisses holds the closest endpoints, best first.
+// It is also returned by OpenAPIUsecase.ValidateRequest, with the reasons why the request breaks the contract in OpenAPIErrors.
+type NoMatchError struct {
+	NearMisses    []NearMiss
+	OpenAPIErrors []model.OpenAPIError
+}
+
+func (e *NoMatchError) Error() string {
+	if len(e.OpenAPIErrors) > 0 {
+		return "request does not satisfy the OpenAPI document"
+	}
+	return "no matching endpoint found"
+}
+
+// NearMiss describes how close an endpoint came to matching a request.
+type NearMiss struct {
+	EndpointID   string     `json:"id"`
+	EndpointName string     `json:"name"`
+	Score        int        `json:"score"` // number of dimensions (method, path, query, headers, body, cookies, host, scheme, port, form, multipart, basic auth, jwt and scenario state) that matched
+	MaxScore     int        `json:"maxScore"`
+	Mismatches   []Mismatch `json:"mismatches"`
+}
+
+// Mismatch is a single matcher that rejected the request.
+type Mismatch struct {
+	Matcher  string `json:"matcher"` // e.g. "method", "path", "query.page", "header.Accept", "cookie.session", "host", "body", "scenario.job"
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (m matchResult) score() int {
+	score := 0
+	for _, ok := range []bool{m.Method || m.HeadAsGet, m.Path, m.Query, m.Headers, m.Body} {
+		if ok {
+			score++
+		}
+	}
+	return score
+}
+
+// nearMisses returns the closest endpoints to the request, best first.
+// Endpoints are ranked by score, then by the number of failing matchers,
+// then by how many constraints they define, so that a specific stub that nearly matched comes first.
+func nearMisses(endpoints []model.Endpoint, results []matchResult, req incomingRequest) []NearMiss {
+	type candidate struct {
+		nearMiss    NearMiss
+		specificity int
+	}
+	candidates := make([]candidate, 0, len(endpoints))
+	for i, e := range endpoints {
+		candidates = append(candidates, candidate{
+			nearMiss:    diagnose(e, results[i], req),
+			specificity: specificity(e),
+		})
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(
+			cmp.Compare(b.nearMiss.Score, a.nearMiss.Score),
+			cmp.Compare(len(a.nearMiss.Mismatches), len(b.nearMiss.Mismatches)),
+			cmp.Compare(b.specificity, a.specificity),
+		)
+	})
+	ret := make([]NearMiss, 0, len(candidates))
+	for _, c := range candidates {
+		ret = append(ret, c.nearMiss)
+	}
+	if len(ret) > maxNearMisses {
+		ret = ret[:maxNearMisses]
+	}
+	return ret
+}
+
+// diagnose lists the matchers of the endpoint that rejected the request, with expected and actual values.
+func diagnose(e model.Endpoint, m matchResult, req incomingRequest) NearMiss {
+	ret := NearMiss{
+		EndpointID:   e.ID,
+		EndpointName: e.Name,
+		Score:        m.score(),
+		MaxScore:     matcherDimensions,
+		Mismatches:   []Mismatch{},
+	}
+	// cookies, host, scheme, port, form, multipart, basic auth and jwt only count for endpoints that define them
+	for _, d := range []struct {
+		defined    bool
+		matched    bool
+		mismatches func() []Mismatch
+	}{
+		{len(e.Request.Cookies) > 0, m.Cookies, func() []Mismatch { return cookieMismatches(e, req) }},
+		{!reflect.ValueOf(e.Request.Host).IsZero(), m.Host, func() []Mismatch { return valueMismatch("host", e.Request.Host, req.Host) }},
+		{!reflect.ValueOf(e.Request.Scheme).IsZero(), m.Scheme, func() []Mismatch { return valueMismatch("scheme", e.Request.Scheme, req.Scheme) }},
+		{!reflect.ValueOf(e.Request.Port).IsZero(), m.Port, func() []Mismatch { return valueMismatch("port", e.Request.Port, req.Port) }},
+		{len(e.Request.FormParameters) > 0, m.Form, func() []Mismatch { return formMismatches(e, req) }},
+		{len(e.Request.MultipartPatterns) > 0, m.Multipart, func() []Mismatch { return multipartMismatches(e, req) }},
+		{e.Request.BasicAuthCredentials != nil, m.BasicAuth, func() []Mismatch { return basicAuthMismatch(e, req) }},
+		{e.Request.JWT != nil, m.JWT, func() []Mismatch { return jwtMismatch(e, req) }},
+	} {
+		if !d.defined {
+			continue
+		}
+		ret.MaxScore++
+		if d.matched {
+			ret.Score++
+		} else {
+			ret.Mismatches = append(ret.Mismatches, d.mismatches()...)
+		}
+	}
+	// the scenario state only counts for endpoints that require one
+	if requiresScenarioState(e) {
+		ret.MaxScore++
+		if m.Scenario {
+			ret.Score++
+		} else {
+			ret.Mismatches = append(ret.Mismatches, Mismatch{
+				Matcher:  "scenario." + e.ScenarioName,
+				Expected: e.RequiredScenarioState,
+				Actual:   m.ScenarioState,
+			})
+		}
+	}
+	if !m.Method && !m.HeadAsGet {
+		ret.Mismatches = append(ret.Mismatches, Mismatch{
+			Matcher:  "method",
+			Expected: describeMethod(e.Request.Method),
+			Actual:   req.Method,
+		})
+	}
+	if !m.Path {
+		ret.Mismatches = append(ret.Mismatches, pathMismatches(e, req)...)
+	}
+	if !m.Query {
+		for _, k := range slices.Sorted(maps.Keys(e.Request.QueryParameters)) {
+			single := model.Endpoint{Request: model.Request{
+				QueryParameters: map[string]model.Matcher{k: e.Request.QueryParameters[k]},
+			}}
+			if ok, _ := single.QueryMatcher(req.RawQueryValues, req.QueryValues); ok {
+				continue
+			}
+			ret.Mismatches = append(ret.Mismatches, Mismatch{
+				Matcher:  "query." + k,
+				Expected: describeMatcher(e.Request.QueryParameters[k]),
+				Actual:   describeValues(req.RawQueryValues[k]),
+			})
+		}
+	}
+	if !m.Headers {
+		for _, k := range slices.Sorted(maps.Keys(e.Request.Headers)) {
+			single := model.Endpoint{Request: model.Request{
+				Headers: map[string]model.Matcher{k: e.Request.Headers[k]},
+			}}
+			if ok, _ := single.HeaderMatcher(req.Headers); ok {
+				continue
+			}
+			ret.Mismatches = append(ret.Mismatches, Mismatch{
+				Matcher:  "header." + k,
+				Expected: describeMatcher(e.Request.Headers[k]),
+				Actual:   describeValues(req.Headers[k]),
+			})
+		}
+	}
+	if !m.Body {
+		ret.Mismatches = append(ret.Mismatches, Mismatch{
+			Matcher:  "body",
+			Expected: describeMatcher(e.Request.Body),
+			Actual:   truncate(req.Body),
+		})
+		if errs := e.Request.Body.JSONSchemaErrors(req.Body); len(errs) > 0 {
+			messages := make([]string, 0, len(errs))
+			for _, err := range errs {
+				messages = append(messages, err.String())
+			}
+			ret.Mismatches = append(ret.Mismatches, Mismatch{
+				Matcher:  "body.jsonSchema",
+				Expected: "a body that validates against the schema",
+				Actual:   truncate(strings.Join(messages, "; ")),
+			})
+		}
+	}
+	return ret
+}
+
+func cookieMismatches(e model.Endpoint, req incomingRequest) []Mismatch {
+	got := cookies(req.Headers)
+	var ret []Mismatch
+	for _, k := range slices.Sorted(maps.Keys(e.Request.Cookies)) {
+		single := model.Endpoint{Request: model.Request{
+			Cookies: map[string]model.Matcher{k: e.Request.Cookies[k]},
+		}}
+		if single.CookieMatcher(got) {
+			continue
+		}
+		ret = append(ret, Mismatch{
+			Matcher:  "cookie." + k,
+			Expected: describeMatcher(e.Request.Cookies[k]),
+			Actual:   describeValues(got[k]),
+		})
+	}
+	return ret
+}
+
+func formMismatches(e model.Endpoint, req incomingRequest) []Mismatch {
+	var ret []Mismatch
+	for _, k := range slices.Sorted(maps.Keys(e.Request.FormParameters)) {
+		single := model.Endpoint{Request: model.Request{
+			FormParameters: map[string]model.Matcher{k: e.Request.FormParameters[k]},
+		}}
+		if single.FormMatcher(req.Form) {
+			continue
+		}
+		ret = append(ret, Mismatch{
+			Matcher:  "form." + k,
+			Expected: describeMatcher(e.Request.FormParameters[k]),
+			Actual:   describeValues(req.Form[k]),
+		})
+	}
+	return ret
+}
+
+func multipartMismatches(e model.Endpoint, req incomingRequest) []Mismatch {
+	names := make([]string, 0, len(req.Parts))
+	for _, p := range req.Parts {
+		if p.FileName != "" {
+			names = append(names, fmt.Sprintf("%s (%s)", p.Name, p.FileName))
+		} else {
+			names = append(names, p.Name)
+		}
+	}
+	var ret []Mismatch
+	for i, p := range e.Request.MultipartPatterns {
+		single := model.Endpoint{Request: model.Request{MultipartPatterns: []model.MultipartPattern{p}}}
+		if single.MultipartMatcher(req.Parts) {
+			continue
+		}
+		expected, err := json.Marshal(p)
+		if err != nil {
+			expected = []byte(fmt.Sprint(p))
+		}
+		ret = append(ret, Mismatch{
+			Matcher:  fmt.Sprintf("multipart[%d]", i),
+			Expected: string(expected),
+			Actual:   describeValues(names),
+		})
+	}
+	return ret
+}
+
+// basicAuthMismatch reports the user names only, so that passwords do not end up in the log.
+func basicAuthMismatch(e model.Endpoint, req incomingRequest) []Mismatch {
+	actual := model.BasicAuthUsername(req.Headers)
+	if actual == "" {
+		actual = "(absent)"
+	}
+	return []Mismatch{{Matcher: "basicAuth", Expected: e.Request.BasicAuthCredentials.Username, Actual: actual}}
+}
+
+func jwtMismatch(e model.Endpoint, req incomingRequest) []Mismatch {
+	expected, err := json.Marshal(e.Request.JWT)
+	if err != nil {
+		expected = []byte(fmt.Sprint(e.Request.JWT))
+	}
+	actual := "(absent)"
+	if t, ok := model.RequestJWT(req.Headers, e.Request.JWT.Header); ok {
+		if b, err := json.Marshal(map[string]any{"header": t.Header, "claims": t.Claims}); err == nil {
+			actual = string(b)
+		}
+		if e.Request.JWT.KeyFile != "" {
+			if err := t.Verify(jwtKey(e), e.Request.JWT.Algorithms); err != nil {
+				actual += " (" + err.Error() + ")"
+			}
+		}
+	}
+	return []Mismatch{{Matcher: "jwt", Expected: string(expected), Actual: truncate(actual)}}
+}
+
+func valueMismatch(name string, expected model.Matcher, actual string) []Mismatch {
+	if actual == "" {
+		actual = "(absent)"
+	}
+	return []Mismatch{{Matcher: name, Expected: describeMatcher(expected), Actual: actual}}
+}
+
+// specificity counts the constraints an endpoint puts on a request.
+func specificity(e model.Endpoint) int {
+	r := e.Request
+	n := len(r.PathParameters) + len(r.QueryParameters) + len(r.Headers) + len(r.Cookies) +
+		len(r.FormParameters) + len(r.MultipartPatterns)
+	if r.BasicAuthCredentials != nil {
+		n++
+	}
+	if r.JWT != nil {
+		n += 1 + len(r.JWT.Headers) + len(r.JWT.Claims)
+	}
+	if !r.MatchesAnyMethod() {
+		n++
+	}
+	if r.URL != "" || r.URLPattern != "" || r.URLPath != "" || r.URLPathPattern != "" || r.URLPathTemplate != "" {
+		n++
+	}
+	for _, m := range []model.Matcher{r.Body, r.Host, r.Scheme, r.Port} {
+		if !reflect.ValueOf(m).IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+func pathMismatches(e model.Endpoint, req incomingRequest) []Mismatch {
+	r := e.Request
+	switch {
+	case r.URL != "":
+		return []Mismatch{{Matcher: "url", Expected: r.URL, Actual: req.RawPath}}
+	case r.URLPattern != "":
+		return []Mismatch{{Matcher: "urlPattern", Expected: r.URLPattern, Actual: req.RawPath}}
+	case r.URLPath != "":
+		return []Mismatch{{Matcher: "urlPath", Expected: r.URLPath, Actual: req.Path}}
+	case r.URLPathPattern != "":
+		return []Mismatch{{Matcher: "urlPathPattern", Expected: r.URLPathPattern, Actual: req.Path}}
+	case r.URLPathTemplate != "":
+	default:
+		return []Mismatch{{Matcher: "path", Expected: "(no URL configured)", Actual: req.Path}}
+	}
+
+	// the template itself matches, so report the path parameters that do not
+	template := model.Endpoint{Request: model.Request{URLPathTemplate: r.URLPathTemplate}}
+	if ok, _ := template.PathMatcher(req.RawPath, req.Path); !ok {
+		return []Mismatch{{Matcher: "urlPathTemplate", Expected: r.URLPathTemplate, Actual: req.Path}}
+	}
+	var ret []Mismatch
+	for _, k := range slices.Sorted(maps.Keys(r.PathParameters)) {
+		single := model.Endpoint{Request: model.Request{
+			URLPathTemplate: r.URLPathTemplate,
+			PathParameters:  map[string]model.Matcher{k: r.PathParameters[k]},
+		}}
+		if ok, _ := single.PathMatcher(req.RawPath, req.Path); ok {
+			continue
+		}
+		ret = append(ret, Mismatch{
+			Matcher:  "path." + k,
+			Expected: describeMatcher(r.PathParameters[k]),
+			Actual:   pathParameter(r.URLPathTemplate, req.Path, k),
+		})
+	}
+	return ret
+}
+
+// pathParameter extracts the value at the position of the placeholder from the path.
+func pathParameter(template, path, name string) string {
+	units := strings.Split(strings.TrimRight(template, "/"), "/")
+	got := strings.Split(strings.TrimRight(path, "/"), "/")
+	if i := slices.Index(units, fmt.Sprintf("{%s}", name)); i != -1 && i < len(got) {
+		return got[i]
+	}
+	return "(absent)"
+}
+
+func describeMatcher(m model.Matcher) string {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Sprint(m)
+	}
+	return string(b)
+}
+
+// describeMethod shows a single method as is, and a list of methods or a matcher as JSON.
+func describeMethod(method model.MethodMatcher) string {
+	b, err := json.Marshal(method)
+	if err != nil {
+		return fmt.Sprint(method)
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		return s
+	}
+	return string(b)
+}
+
+func describeValues(values []string) string {
+	switch len(values) {
+	case 0:
+		return "(absent)"
+	case 1:
+		return values[0]
+	default:
+		return strings.Join(values, ", ")
+	}
+}
+
+func truncate(s string) string {
+	if len(s) <= maxActualLength {
+		return s
+	}
+	// cut on a rune boundary
+	cut := 0
+	for i := range s {
+		if i > maxActualLength {
+			break
+		}
+		cut = i
+	}
+	return s[:cut] + "..."
+}
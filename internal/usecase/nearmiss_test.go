@@ -0,0 +1,172 @@
+package usecase_test
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEndpointUsecase_EndpointMatcherNearMiss(t *testing.T) {
+	cr := &mockConfigRepository{
+		endpoints: []model.Endpoint{
+			{
+				ID:   "far",
+				Name: "Far Endpoint",
+				Request: model.Request{
+					Method:  model.Method("POST"),
+					URLPath: "/orders",
+				},
+				Response: model.Response{Status: 200, Body: "far"},
+			},
+			{
+				ID:   "close",
+				Name: "Close Endpoint",
+				Request: model.Request{
+					Method:          model.Method("GET"),
+					URLPathTemplate: "/users/{id}",
+					PathParameters: map[string]model.Matcher{
+						"id": {EqualTo: "123"},
+					},
+					QueryParameters: map[string]model.Matcher{
+						"page": {Matches: "^[0-9]+$"},
+						"sort": {EqualTo: "asc"},
+					},
+					Headers: map[string]model.Matcher{
+						"Accept": {EqualTo: "application/json"},
+					},
+				},
+				Response: model.Response{Status: 200, Body: "close"},
+			},
+		},
+	}
+	var arg usecase.EndpointMatcherArgs
+	arg.Request.Method = "GET"
+	arg.Request.UrlRawPath = "/users/456"
+	arg.Request.UrlPath = "/users/456"
+	arg.Request.Body = io.NopCloser(strings.NewReader(""))
+	arg.Request.RawQueryValues = url.Values{"page": {"x"}, "sort": {"asc"}}
+	arg.Request.QueryValues = url.Values{"page": {"x"}, "sort": {"asc"}}
+	arg.Request.Headers = map[string][]string{"Accept": {"application/json"}}
+
+	eu := usecase.NewEndpointUsecase(cr, &mockMappingRepository{}, newMockScenarioRepository())
+	_, err := eu.EndpointMatcher(arg)
+
+	var nm *usecase.NoMatchError
+	if !errors.As(err, &nm) {
+		t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, want *usecase.NoMatchError", err)
+	}
+	want := []usecase.NearMiss{
+		{
+			EndpointID:   "close",
+			EndpointName: "Close Endpoint",
+			Score:        3,
+			MaxScore:     5,
+			Mismatches: []usecase.Mismatch{
+				{Matcher: "path.id", Expected: `{"equalTo":"123"}`, Actual: "456"},
+				{Matcher: "query.page", Expected: `{"matches":"^[0-9]+$"}`, Actual: "x"},
+			},
+		},
+		{
+			EndpointID:   "far",
+			EndpointName: "Far Endpoint",
+			Score:        3,
+			MaxScore:     5,
+			Mismatches: []usecase.Mismatch{
+				{Matcher: "method", Expected: "POST", Actual: "GET"},
+				{Matcher: "urlPath", Expected: "/orders", Actual: "/users/456"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, nm.NearMisses); diff != "" {
+		t.Errorf("NearMisses mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEndpointUsecase_EndpointMatcherVirtualHost(t *testing.T) {
+	cr := &mockConfigRepository{
+		endpoints: []model.Endpoint{
+			{
+				ID:   "billing",
+				Name: "Billing",
+				Request: model.Request{
+					Method:  model.Method("GET"),
+					URLPath: "/health",
+					Host:    model.Matcher{EqualTo: "billing.local"},
+					Scheme:  model.Matcher{EqualTo: "https"},
+					Cookies: map[string]model.Matcher{
+						"session": {Not: &model.Matcher{Absent: true}},
+					},
+				},
+				Response: model.Response{Status: 200, Body: "billing"},
+			},
+			{
+				ID:   "users",
+				Name: "Users",
+				Request: model.Request{
+					Method:  model.Method("GET"),
+					URLPath: "/health",
+					Host:    model.Matcher{EqualTo: "users.local"},
+					Port:    model.Matcher{EqualTo: 8080},
+				},
+				Response: model.Response{Status: 200, Body: "users"},
+			},
+		},
+	}
+	newArg := func(scheme, host, port string, headers map[string][]string) usecase.EndpointMatcherArgs {
+		var arg usecase.EndpointMatcherArgs
+		arg.Request.Method = "GET"
+		arg.Request.UrlPath = "/health"
+		arg.Request.Body = io.NopCloser(strings.NewReader(""))
+		arg.Request.Headers = headers
+		arg.Request.Scheme = scheme
+		arg.Request.Host = host
+		arg.Request.Port = port
+		return arg
+	}
+	eu := usecase.NewEndpointUsecase(cr, &mockMappingRepository{}, newMockScenarioRepository())
+
+	got, err := eu.EndpointMatcher(newArg("http", "users.local", "8080", nil))
+	if err != nil || got.Endpoint.ID != "users" {
+		t.Errorf("EndpointMatcher() = %v, %v, want users", got.Endpoint.ID, err)
+	}
+	got, err = eu.EndpointMatcher(newArg("https", "billing.local", "8443", map[string][]string{"Cookie": {"theme=dark; session=abc"}}))
+	if err != nil || got.Endpoint.ID != "billing" {
+		t.Errorf("EndpointMatcher() = %v, %v, want billing", got.Endpoint.ID, err)
+	}
+
+	_, err = eu.EndpointMatcher(newArg("http", "billing.local", "8080", map[string][]string{"Cookie": {"theme=dark"}}))
+	var nm *usecase.NoMatchError
+	if !errors.As(err, &nm) {
+		t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, want *usecase.NoMatchError", err)
+	}
+	want := []usecase.NearMiss{
+		{
+			EndpointID:   "users",
+			EndpointName: "Users",
+			Score:        6,
+			MaxScore:     7,
+			Mismatches: []usecase.Mismatch{
+				{Matcher: "host", Expected: `{"equalTo":"users.local"}`, Actual: "billing.local"},
+			},
+		},
+		{
+			EndpointID:   "billing",
+			EndpointName: "Billing",
+			Score:        6,
+			MaxScore:     8,
+			Mismatches: []usecase.Mismatch{
+				{Matcher: "cookie.session", Expected: `{"not":{"absent":true}}`, Actual: "(absent)"},
+				{Matcher: "scheme", Expected: `{"equalTo":"https"}`, Actual: "http"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, nm.NearMisses); diff != "" {
+		t.Errorf("NearMisses mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+)
+
+// OpenAPIOptions configures the validation of requests and stub responses against an OpenAPI document.
+type OpenAPIOptions struct {
+	Path              string // validation is enabled when set
+	ValidateResponses bool   // also check the responses rendered from stubs
+}
+
+type OpenAPIUsecase struct {
+	or repository.OpenAPIRepository
+	oo OpenAPIOptions
+}
+
+func NewOpenAPIUsecase(or repository.OpenAPIRepository, oo OpenAPIOptions) OpenAPIUsecase {
+	return OpenAPIUsecase{
+		or: or,
+		oo: oo,
+	}
+}
+
+// ValidateRequest checks the request against the OpenAPI document.
+// A request that breaks the contract is rejected with a NoMatchError that lists the violations.
+func (ou OpenAPIUsecase) ValidateRequest(req model.OpenAPIRequest) error {
+	if ou.oo.Path == "" {
+		return nil
+	}
+	doc, err := ou.or.Load(ou.oo.Path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load OpenAPI document: %v", err))
+		return err
+	}
+	if errs := doc.ValidateRequest(req); len(errs) > 0 {
+		return &NoMatchError{OpenAPIErrors: errs}
+	}
+	return nil
+}
+
+// ValidateResponse checks a response rendered from a stub against the OpenAPI document,
+// if response validation is enabled.
+func (ou OpenAPIUsecase) ValidateResponse(req model.OpenAPIRequest, resp model.OpenAPIResponse) ([]model.OpenAPIError, error) {
+	if ou.oo.Path == "" || !ou.oo.ValidateResponses {
+		return nil, nil
+	}
+	doc, err := ou.or.Load(ou.oo.Path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load OpenAPI document: %v", err))
+		return nil, err
+	}
+	return doc.ValidateResponse(req, resp), nil
+}
@@ -0,0 +1,125 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockOpenAPIRepository struct {
+	doc string
+	err error
+}
+
+func (m mockOpenAPIRepository) Load(path string) (model.OpenAPI, error) {
+	if m.err != nil {
+		return model.OpenAPI{}, m.err
+	}
+	return model.ParseOpenAPI([]byte(m.doc), "")
+}
+
+const testOpenAPI = `{
+	"openapi": "3.0.3",
+	"paths": {
+		"/users/{id}": {
+			"get": {
+				"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+				"responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "object", "required": ["id"]}}}}}
+			}
+		}
+	}
+}`
+
+func TestOpenAPIUsecase_ValidateRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		or          mockOpenAPIRepository
+		oo          usecase.OpenAPIOptions
+		path        string
+		want        []model.OpenAPIError
+		wantErr     bool
+		wantNoMatch bool
+	}{
+		{
+			name: "OpenAPIドキュメントが指定されていない",
+			or:   mockOpenAPIRepository{err: errors.New("not loaded")},
+			path: "/users/abc",
+		},
+		{
+			name: "契約を満たすリクエスト",
+			or:   mockOpenAPIRepository{doc: testOpenAPI},
+			oo:   usecase.OpenAPIOptions{Path: "openapi.json"},
+			path: "/users/1",
+		},
+		{
+			name:        "契約を満たさないリクエスト",
+			or:          mockOpenAPIRepository{doc: testOpenAPI},
+			oo:          usecase.OpenAPIOptions{Path: "openapi.json"},
+			path:        "/users/abc",
+			want:        []model.OpenAPIError{{In: "path", Name: "id", Message: "must be integer, not string"}},
+			wantErr:     true,
+			wantNoMatch: true,
+		},
+		{
+			name:    "OpenAPIドキュメントを読み込めない",
+			or:      mockOpenAPIRepository{err: errors.New("no such file")},
+			oo:      usecase.OpenAPIOptions{Path: "openapi.json"},
+			path:    "/users/1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ou := usecase.NewOpenAPIUsecase(tt.or, tt.oo)
+			err := ou.ValidateRequest(model.OpenAPIRequest{Method: "GET", Path: tt.path})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OpenAPIUsecase.ValidateRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var nm *usecase.NoMatchError
+			if errors.As(err, &nm) != tt.wantNoMatch {
+				t.Fatalf("OpenAPIUsecase.ValidateRequest() error = %v, want NoMatchError %v", err, tt.wantNoMatch)
+			}
+			if nm == nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, nm.OpenAPIErrors); diff != "" {
+				t.Errorf("OpenAPIErrors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOpenAPIUsecase_ValidateResponse(t *testing.T) {
+	req := model.OpenAPIRequest{Method: "GET", Path: "/users/1"}
+	resp := model.OpenAPIResponse{Status: 200, Headers: map[string][]string{"Content-Type": {"application/json"}}, Body: `{}`}
+	tests := []struct {
+		name string
+		oo   usecase.OpenAPIOptions
+		want []model.OpenAPIError
+	}{
+		{
+			name: "レスポンスの検証が無効",
+			oo:   usecase.OpenAPIOptions{Path: "openapi.json"},
+		},
+		{
+			name: "レスポンスの検証が有効",
+			oo:   usecase.OpenAPIOptions{Path: "openapi.json", ValidateResponses: true},
+			want: []model.OpenAPIError{{In: "body", Name: "$", Message: `missing required property "id"`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ou := usecase.NewOpenAPIUsecase(mockOpenAPIRepository{doc: testOpenAPI}, tt.oo)
+			got, err := ou.ValidateResponse(req, resp)
+			if err != nil {
+				t.Fatalf("OpenAPIUsecase.ValidateResponse() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("OpenAPIUsecase.ValidateResponse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
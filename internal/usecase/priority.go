@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+// byPriority returns the endpoints in the order they are tried: by priority, lowest first,
+// then by specificity, most constraints first, then in the order they were loaded.
+func byPriority(endpoints []model.Endpoint) []model.Endpoint {
+	ret := slices.Clone(endpoints)
+	slices.SortStableFunc(ret, func(a, b model.Endpoint) int {
+		return cmp.Or(
+			cmp.Compare(a.EffectivePriority(), b.EffectivePriority()),
+			cmp.Compare(specificity(b), specificity(a)),
+		)
+	})
+	return ret
+}
+
+// matchInfo explains why the winner was chosen over the other endpoints that matched the request.
+// endpoints must be in the order of byPriority.
+func matchInfo(endpoints []model.Endpoint, results []matchResult, winner int) model.MatchInfo {
+	e := endpoints[winner]
+	ret := model.MatchInfo{
+		Priority:    e.EffectivePriority(),
+		Specificity: specificity(e),
+	}
+	asGet := !results[winner].matched()
+	runnerUp := -1
+	for i, m := range results {
+		if i == winner || !(m.matched() || asGet && m.matchedAsGet()) {
+			continue
+		}
+		ret.Shadowed = append(ret.Shadowed, endpoints[i].ID)
+		if runnerUp < 0 {
+			runnerUp = i
+		}
+	}
+	switch {
+	case runnerUp < 0:
+		ret.Reason = "the only matching endpoint"
+	case endpoints[runnerUp].EffectivePriority() != ret.Priority:
+		ret.Reason = fmt.Sprintf("priority %d wins over priority %d of %s",
+			ret.Priority, endpoints[runnerUp].EffectivePriority(), endpointLabel(endpoints[runnerUp]))
+	case specificity(endpoints[runnerUp]) != ret.Specificity:
+		ret.Reason = fmt.Sprintf("%d constraints win over %d of %s at priority %d",
+			ret.Specificity, specificity(endpoints[runnerUp]), endpointLabel(endpoints[runnerUp]), ret.Priority)
+	default:
+		ret.Reason = fmt.Sprintf("loaded before %s with the same priority and constraints", endpointLabel(endpoints[runnerUp]))
+	}
+	if asGet {
+		ret.Reason = "HEAD answered by a GET endpoint, " + ret.Reason
+	}
+	return ret
+}
+
+// schemaErrors returns why the body failed the JSON schema of the first endpoint, up to the winner,
+// that missed the request only because of its body. This lets a fallback endpoint of a lower priority
+// answer an invalid body with the validation errors. The winner itself may check the schema with not.
+func schemaErrors(endpoints []model.Endpoint, results []matchResult, winner int, req incomingRequest) []model.SchemaError {
+	for i := range winner + 1 {
+		m := results[i]
+		m.Body = true
+		if !m.matchedAsGet() {
+			continue
+		}
+		if errs := endpoints[i].Request.Body.JSONSchemaErrors(req.Body); len(errs) > 0 {
+			return errs
+		}
+	}
+	return nil
+}
+
+// endpointLabel names the endpoint in log messages and match reasons.
+func endpointLabel(e model.Endpoint) string {
+	if e.Name != "" {
+		return fmt.Sprintf("%q", e.Name)
+	}
+	return e.ID
+}
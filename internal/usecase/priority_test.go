@@ -0,0 +1,208 @@
+package usecase_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEndpointUsecase_EndpointMatcherPriority(t *testing.T) {
+	catchAll := model.Endpoint{
+		ID:       "catch-all",
+		Name:     "catch-all",
+		Priority: 10,
+		Request:  model.Request{Method: model.Method(model.MethodAny), URLPathPattern: "/.*"},
+		Response: model.Response{Status: 404, Body: "not found"},
+	}
+	user := model.Endpoint{
+		ID:       "user",
+		Name:     "user",
+		Request:  model.Request{Method: model.Method("GET"), URLPathTemplate: "/users/{id}"},
+		Response: model.Response{Status: 200, Body: "user"},
+	}
+	admin := model.Endpoint{
+		ID:   "admin",
+		Name: "admin",
+		Request: model.Request{
+			Method:          model.Method("GET"),
+			URLPathTemplate: "/users/{id}",
+			PathParameters:  map[string]model.Matcher{"id": {EqualTo: "0"}},
+		},
+		Response: model.Response{Status: 200, Body: "admin"},
+	}
+	maintenance := model.Endpoint{
+		ID:       "maintenance",
+		Name:     "maintenance",
+		Priority: 1,
+		Request:  model.Request{Method: model.Method("GET"), URLPathPattern: "/users/.*"},
+		Response: model.Response{Status: 503, Body: "maintenance"},
+	}
+	duplicate := user
+	duplicate.ID = "duplicate"
+	duplicate.Name = "duplicate"
+
+	tests := []struct {
+		name      string
+		endpoints []model.Endpoint
+		mappings  []model.Endpoint
+		path      string
+		wantID    string
+		wantMatch model.MatchInfo
+	}{
+		{
+			name:      "唯一マッチしたスタブ",
+			endpoints: []model.Endpoint{user},
+			path:      "/users/1",
+			wantID:    "user",
+			wantMatch: model.MatchInfo{Priority: 5, Specificity: 2, Reason: "the only matching endpoint"},
+		},
+		{
+			name:      "優先度の高いスタブが読み込み順に関係なく選ばれる",
+			endpoints: []model.Endpoint{catchAll, user, maintenance},
+			path:      "/users/1",
+			wantID:    "maintenance",
+			wantMatch: model.MatchInfo{
+				Priority:    1,
+				Specificity: 2,
+				Shadowed:    []string{"user", "catch-all"},
+				Reason:      `priority 1 wins over priority 5 of "user"`,
+			},
+		},
+		{
+			name:      "優先度が同じ場合は制約の多いスタブが選ばれる",
+			endpoints: []model.Endpoint{user, admin},
+			path:      "/users/0",
+			wantID:    "admin",
+			wantMatch: model.MatchInfo{
+				Priority:    5,
+				Specificity: 3,
+				Shadowed:    []string{"user"},
+				Reason:      `3 constraints win over 2 of "user" at priority 5`,
+			},
+		},
+		{
+			name:      "優先度と制約の数が同じ場合は先に読み込まれたスタブが選ばれる",
+			endpoints: []model.Endpoint{duplicate, user},
+			path:      "/users/1",
+			wantID:    "duplicate",
+			wantMatch: model.MatchInfo{
+				Priority:    5,
+				Specificity: 2,
+				Shadowed:    []string{"user"},
+				Reason:      `loaded before "user" with the same priority and constraints`,
+			},
+		},
+		{
+			name:      "管理APIのマッピングは同じ条件の設定ファイルより先に試される",
+			endpoints: []model.Endpoint{user},
+			mappings:  []model.Endpoint{duplicate},
+			path:      "/users/1",
+			wantID:    "duplicate",
+			wantMatch: model.MatchInfo{
+				Priority:    5,
+				Specificity: 2,
+				Shadowed:    []string{"user"},
+				Reason:      `loaded before "user" with the same priority and constraints`,
+			},
+		},
+		{
+			name:      "優先度の低いスタブはほかにマッチしない場合に選ばれる",
+			endpoints: []model.Endpoint{catchAll, user},
+			path:      "/orders",
+			wantID:    "catch-all",
+			wantMatch: model.MatchInfo{Priority: 10, Specificity: 1, Reason: "the only matching endpoint"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arg usecase.EndpointMatcherArgs
+			arg.Request.Method = "GET"
+			arg.Request.UrlPath = tt.path
+			arg.Request.Body = io.NopCloser(strings.NewReader(""))
+
+			mr := &mockMappingRepository{endpoints: tt.mappings}
+			eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: tt.endpoints}, mr, newMockScenarioRepository())
+			got, err := eu.EndpointMatcher(arg)
+			if err != nil {
+				t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v", err)
+			}
+			if got.Endpoint.ID != tt.wantID {
+				t.Errorf("EndpointUsecase.EndpointMatcher() matched %q, want %q", got.Endpoint.ID, tt.wantID)
+			}
+			if diff := cmp.Diff(tt.wantMatch, got.Match); diff != "" {
+				t.Errorf("Match mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEndpointUsecase_EndpointMatcherSchemaErrors(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"required":   []any{"name"},
+		"properties": map[string]any{"age": map[string]any{"type": "integer"}},
+	}
+	endpoints := []model.Endpoint{
+		{
+			ID:       "invalid-user",
+			Priority: 10,
+			Request:  model.Request{Method: model.Method("POST"), URLPath: "/users"},
+			Response: model.Response{Status: 400, Body: "invalid"},
+		},
+		{
+			ID:       "create-user",
+			Request:  model.Request{Method: model.Method("POST"), URLPath: "/users", Body: model.Matcher{MatchesJSONSchema: schema}},
+			Response: model.Response{Status: 201, Body: "created"},
+		},
+		{
+			ID:       "create-order",
+			Request:  model.Request{Method: model.Method("POST"), URLPath: "/orders", Body: model.Matcher{MatchesJSONSchema: map[string]any{"required": []any{"sku"}}}},
+			Response: model.Response{Status: 201, Body: "created"},
+		},
+	}
+	tests := []struct {
+		name             string
+		body             string
+		wantID           string
+		wantSchemaErrors []model.SchemaError
+	}{
+		{
+			name:   "スキーマに合うボディ",
+			body:   `{"name": "Alice", "age": 30}`,
+			wantID: "create-user",
+		},
+		{
+			name:   "スキーマに合わないボディは優先度の低いスタブがエラーとともに受け取る",
+			body:   `{"age": "thirty"}`,
+			wantID: "invalid-user",
+			wantSchemaErrors: []model.SchemaError{
+				{Path: "$", Message: `missing required property "name"`},
+				{Path: "$.age", Message: "must be integer, not string"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arg usecase.EndpointMatcherArgs
+			arg.Request.Method = "POST"
+			arg.Request.UrlPath = "/users"
+			arg.Request.Body = io.NopCloser(strings.NewReader(tt.body))
+
+			eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, newMockScenarioRepository())
+			got, err := eu.EndpointMatcher(arg)
+			if err != nil {
+				t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v", err)
+			}
+			if got.Endpoint.ID != tt.wantID {
+				t.Errorf("EndpointUsecase.EndpointMatcher() matched %q, want %q", got.Endpoint.ID, tt.wantID)
+			}
+			if diff := cmp.Diff(tt.wantSchemaErrors, got.Data.SchemaErrors); diff != "" {
+				t.Errorf("SchemaErrors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
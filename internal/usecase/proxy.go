@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+)
+
+// RecordOptions configures record mode, in which unmatched requests are forwarded to a real server
+// and the exchanges are saved as stub files.
+type RecordOptions struct {
+	TargetBaseURL     string   // record mode is enabled when set
+	StubDir           string   // directory the stub files are written to
+	BodyDir           string   // directory large response bodies are written to
+	MatchHeaders      []string // request headers that become equalTo matchers
+	Dedupe            bool     // record repeated identical requests only once
+	BodyFileThreshold int      // response bodies larger than this many bytes are stored via bodyFileName
+}
+
+// recordedResponseHeaders are not copied into recorded stubs, since they describe the original transfer.
+var recordedResponseHeaders = []string{"Content-Length", "Content-Encoding", "Date"}
+
+type ProxyUsecase struct {
+	pr   repository.ProxyRepository
+	sw   repository.StubWriter
+	ro   RecordOptions
+	seen *sync.Map // signatures of the requests recorded so far
+}
+
+func NewProxyUsecase(pr repository.ProxyRepository, sw repository.StubWriter, ro RecordOptions) ProxyUsecase {
+	return ProxyUsecase{
+		pr:   pr,
+		sw:   sw,
+		ro:   ro,
+		seen: &sync.Map{},
+	}
+}
+
+type ProxyArgs struct {
+	Method         string
+	URL            string // path and query of the original request
+	Path           string
+	RawQueryValues url.Values
+	Headers        map[string][]string
+	Body           []byte
+}
+
+func (pu ProxyUsecase) Recording() bool {
+	return pu.ro.TargetBaseURL != ""
+}
+
+// ErrRecordNotSaved is returned along with the forwarded response when the exchange could not be saved as a stub.
+var ErrRecordNotSaved = errors.New("failed to save recorded stub")
+
+// Record forwards the request to the record target and saves the exchange as a stub.
+// When the stub cannot be saved, the response is returned with an error wrapping ErrRecordNotSaved,
+// and an identical request is recorded again next time.
+func (pu ProxyUsecase) Record(ctx context.Context, arg ProxyArgs) (model.ProxyResponse, error) {
+	headers := http.Header(maps.Clone(arg.Headers))
+	// let the transport negotiate compression so that the recorded body is plain
+	headers.Del("Accept-Encoding")
+	resp, err := pu.pr.Forward(ctx, model.ProxyRequest{
+		BaseURL: pu.ro.TargetBaseURL,
+		Method:  arg.Method,
+		URL:     arg.URL,
+		Headers: headers,
+		Body:    arg.Body,
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to forward request for recording: %s", err))
+		return model.ProxyResponse{}, err
+	}
+
+	sig := pu.signature(arg)
+	if pu.ro.Dedupe {
+		if _, loaded := pu.seen.LoadOrStore(sig, struct{}{}); loaded {
+			return resp, nil
+		}
+	} else {
+		sig = newID()
+	}
+	if err := pu.save(arg, resp, stubName(arg.Method, arg.Path, sig)); err != nil {
+		slog.Error(fmt.Sprintf("Failed to save recorded stub: %s", err))
+		pu.seen.Delete(sig)
+		return resp, fmt.Errorf("%w: %w", ErrRecordNotSaved, err)
+	}
+	return resp, nil
+}
+
+// save writes the exchange to the stub file named name, and the response body to a body file
+// when it is large or binary. A binary body cannot be kept in a JSON string without changing its bytes.
+func (pu ProxyUsecase) save(arg ProxyArgs, resp model.ProxyResponse, name string) error {
+	e := pu.recordedEndpoint(arg, resp)
+	if len(resp.Body) > pu.ro.BodyFileThreshold || !utf8.Valid(resp.Body) {
+		bodyPath := filepath.Join(pu.ro.BodyDir, name+bodyExtension(resp.Headers))
+		if err := pu.sw.SaveBody(bodyPath, []byte(escapeTemplate(string(resp.Body)))); err != nil {
+			return err
+		}
+		e.Response.BodyFileName = bodyPath
+	} else {
+		e.Response.Body = escapeTemplate(string(resp.Body))
+	}
+	stubPath := filepath.Join(pu.ro.StubDir, name+".json")
+	saved, err := pu.sw.Save(stubPath, []model.Endpoint{e})
+	if err != nil {
+		return err
+	}
+	if saved {
+		slog.Info(fmt.Sprintf("Recorded stub: %s", stubPath))
+	}
+	return nil
+}
+
+// Proxy forwards the request to the proxyBaseUrl of the matched endpoint and returns the response as is.
+// The request headers and the path are adjusted as configured in the endpoint.
+func (pu ProxyUsecase) Proxy(ctx context.Context, e model.Endpoint, arg ProxyArgs) (model.ProxyResponse, error) {
+	headers := http.Header(maps.Clone(arg.Headers))
+	for _, k := range e.Response.RemoveProxyRequestHeaders {
+		headers.Del(k)
+	}
+	for k, v := range e.Response.AdditionalProxyRequestHeaders {
+		headers.Set(k, v)
+	}
+	target := arg.URL
+	if prefix := e.Response.ProxyURLPrefixToRemove; prefix != "" && strings.HasPrefix(target, prefix) {
+		target = strings.TrimPrefix(target, prefix)
+		if !strings.HasPrefix(target, "/") {
+			target = "/" + target
+		}
+		target = strings.TrimSuffix(e.Response.ProxyURLPrefixReplacement, "/") + target
+	}
+	resp, err := pu.pr.Forward(ctx, model.ProxyRequest{
+		BaseURL: e.Response.ProxyBaseURL,
+		Method:  arg.Method,
+		URL:     target,
+		Headers: headers,
+		Body:    arg.Body,
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to proxy request to %s: %s", e.Response.ProxyBaseURL, err))
+		return model.ProxyResponse{}, err
+	}
+	return resp, nil
+}
+
+func (pu ProxyUsecase) recordedEndpoint(arg ProxyArgs, resp model.ProxyResponse) model.Endpoint {
+	e := model.Endpoint{
+		ID:   newID(),
+		Name: fmt.Sprintf("%s %s", arg.Method, arg.URL),
+		Request: model.Request{
+			Method:  model.Method(arg.Method),
+			URLPath: arg.Path,
+		},
+		Response: model.Response{
+			Status: resp.Status,
+		},
+	}
+	if len(arg.RawQueryValues) > 0 {
+		e.Request.QueryParameters = make(map[string]model.Matcher, len(arg.RawQueryValues))
+		for k, values := range arg.RawQueryValues {
+			if len(values) == 1 {
+				e.Request.QueryParameters[k] = model.Matcher{EqualTo: values[0]}
+				continue
+			}
+			// a repeated parameter matches only the same set of values
+			m := model.Matcher{HasExactly: make([]model.Matcher, 0, len(values))}
+			for _, v := range values {
+				m.HasExactly = append(m.HasExactly, model.Matcher{EqualTo: v})
+			}
+			e.Request.QueryParameters[k] = m
+		}
+	}
+	for _, k := range pu.ro.MatchHeaders {
+		v := http.Header(arg.Headers).Get(k)
+		if v == "" {
+			continue
+		}
+		if e.Request.Headers == nil {
+			e.Request.Headers = make(map[string]model.Matcher)
+		}
+		e.Request.Headers[http.CanonicalHeaderKey(k)] = model.Matcher{EqualTo: v}
+	}
+	if len(arg.Body) > 0 {
+		e.Request.Body = model.Matcher{EqualTo: string(arg.Body)}
+	}
+	for k, values := range resp.Headers {
+		if slices.Contains(recordedResponseHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		if e.Response.Headers == nil {
+			e.Response.Headers = make(map[string]model.ResponseHeader)
+		}
+		for _, v := range values {
+			e.Response.Headers[k] = append(e.Response.Headers[k], escapeTemplate(v))
+		}
+	}
+	return e
+}
+
+// signature identifies a request by the parts that end up in the recorded matchers.
+func (pu ProxyUsecase) signature(arg ProxyArgs) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n", arg.Method, arg.URL)
+	for _, k := range pu.ro.MatchHeaders {
+		fmt.Fprintf(h, "%s: %s\n", http.CanonicalHeaderKey(k), http.Header(arg.Headers).Get(k))
+	}
+	h.Write(arg.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// stubName builds a file name such as "get-users-1-3f2a9c1d0b7e" for a recorded stub.
+func stubName(method, path, id string) string {
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(method+"-"+path), "-"), "-")
+	if len(slug) > 64 {
+		slug = slug[:64]
+	}
+	return fmt.Sprintf("%s-%s", slug, strings.ReplaceAll(id, "-", "")[:12])
+}
+
+func bodyExtension(headers map[string][]string) string {
+	ct := http.Header(headers).Get("Content-Type")
+	switch {
+	case strings.Contains(ct, "json"):
+		return ".json"
+	case strings.Contains(ct, "xml"):
+		return ".xml"
+	case strings.Contains(ct, "html"):
+		return ".html"
+	case strings.HasPrefix(ct, "text/"):
+		return ".txt"
+	default:
+		return ".bin"
+	}
+}
+
+// escapeTemplate makes recorded content safe to use as a response template,
+// so that a literal "{{" in the real response is not interpreted as an action.
+func escapeTemplate(s string) string {
+	if !utf8.ValidString(s) {
+		return s
+	}
+	return strings.ReplaceAll(s, "{{", `{{"{{"}}`)
+}
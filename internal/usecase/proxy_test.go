@@ -0,0 +1,364 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	imagepng "image/png"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type mockProxyRepository struct {
+	requests []model.ProxyRequest
+	resp     model.ProxyResponse
+	err      error
+}
+
+func (m *mockProxyRepository) Forward(ctx context.Context, request model.ProxyRequest) (model.ProxyResponse, error) {
+	m.requests = append(m.requests, request)
+	return m.resp, m.err
+}
+
+type mockStubWriter struct {
+	stubs  map[string][]model.Endpoint
+	bodies map[string][]byte
+	err    error
+}
+
+func newMockStubWriter() *mockStubWriter {
+	return &mockStubWriter{stubs: map[string][]model.Endpoint{}, bodies: map[string][]byte{}}
+}
+
+func (m *mockStubWriter) Save(path string, endpoints []model.Endpoint) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	if _, ok := m.stubs[path]; ok {
+		return false, nil
+	}
+	m.stubs[path] = endpoints
+	return true, nil
+}
+
+func (m *mockStubWriter) SaveBody(path string, body []byte) error {
+	m.bodies[path] = body
+	return nil
+}
+
+func recordArgs(body string) usecase.ProxyArgs {
+	return usecase.ProxyArgs{
+		Method:         "POST",
+		URL:            "/orders?tenant=acme&tag=a&tag=b",
+		Path:           "/orders",
+		RawQueryValues: url.Values{"tenant": {"acme"}, "tag": {"a", "b"}},
+		Headers: map[string][]string{
+			"Content-Type":    {"application/json"},
+			"Accept-Encoding": {"gzip"},
+			"X-Request-Id":    {"random"},
+		},
+		Body: []byte(body),
+	}
+}
+
+func TestProxyUsecase_Record(t *testing.T) {
+	pr := &mockProxyRepository{
+		resp: model.ProxyResponse{
+			Status: 201,
+			Headers: map[string][]string{
+				"Content-Type":   {"application/json"},
+				"Content-Length": {"27"},
+				"Set-Cookie":     {"a=1", "b=2"},
+			},
+			Body: []byte(`{"id": 1, "tpl": "{{x}}"}`),
+		},
+	}
+	sw := newMockStubWriter()
+	pu := usecase.NewProxyUsecase(pr, sw, usecase.RecordOptions{
+		TargetBaseURL:     "http://upstream",
+		StubDir:           "configs",
+		BodyDir:           "body",
+		MatchHeaders:      []string{"content-type"},
+		Dedupe:            true,
+		BodyFileThreshold: 1024,
+	})
+	if !pu.Recording() {
+		t.Fatal("ProxyUsecase.Recording() = false, want true")
+	}
+
+	resp, err := pu.Record(context.Background(), recordArgs(`{"sku": "A1"}`))
+	if err != nil {
+		t.Fatalf("ProxyUsecase.Record() error = %v", err)
+	}
+	if resp.Status != 201 {
+		t.Errorf("ProxyUsecase.Record() status = %d, want 201", resp.Status)
+	}
+	if got := pr.requests[0]; got.BaseURL != "http://upstream" || got.URL != "/orders?tenant=acme&tag=a&tag=b" || http.Header(got.Headers).Get("Accept-Encoding") != "" {
+		t.Errorf("Unexpected forwarded request: %+v", got)
+	}
+
+	if len(sw.stubs) != 1 {
+		t.Fatalf("Expected 1 recorded stub, got %d", len(sw.stubs))
+	}
+	for path, endpoints := range sw.stubs {
+		if filepath.Dir(path) != "configs" || !strings.HasPrefix(filepath.Base(path), "post-orders-") || filepath.Ext(path) != ".json" {
+			t.Errorf("Unexpected stub path %s", path)
+		}
+		want := model.Endpoint{
+			Name: "POST /orders?tenant=acme&tag=a&tag=b",
+			Request: model.Request{
+				Method:  model.Method("POST"),
+				URLPath: "/orders",
+				QueryParameters: map[string]model.Matcher{
+					"tenant": {EqualTo: "acme"},
+					"tag":    {HasExactly: []model.Matcher{{EqualTo: "a"}, {EqualTo: "b"}}},
+				},
+				Headers: map[string]model.Matcher{
+					"Content-Type": {EqualTo: "application/json"},
+				},
+				Body: model.Matcher{EqualTo: `{"sku": "A1"}`},
+			},
+			Response: model.Response{
+				Status: 201,
+				Body:   `{"id": 1, "tpl": "{{"{{"}}x}}"}`,
+				Headers: map[string]model.ResponseHeader{
+					"Content-Type": {"application/json"},
+					"Set-Cookie":   {"a=1", "b=2"},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, endpoints[0], cmpopts.IgnoreFields(model.Endpoint{}, "ID")); diff != "" {
+			t.Errorf("Recorded stub mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// an identical request is forwarded again but not recorded twice
+	if _, err := pu.Record(context.Background(), recordArgs(`{"sku": "A1"}`)); err != nil {
+		t.Fatalf("ProxyUsecase.Record() error = %v", err)
+	}
+	if len(pr.requests) != 2 || len(sw.stubs) != 1 {
+		t.Errorf("Expected 2 forwarded requests and 1 stub, got %d and %d", len(pr.requests), len(sw.stubs))
+	}
+}
+
+func TestProxyUsecase_RecordSaveFailure(t *testing.T) {
+	pr := &mockProxyRepository{resp: model.ProxyResponse{Status: 200, Body: []byte("ok")}}
+	sw := newMockStubWriter()
+	sw.err = errors.New("disk full")
+	pu := usecase.NewProxyUsecase(pr, sw, usecase.RecordOptions{
+		TargetBaseURL:     "http://upstream",
+		StubDir:           "configs",
+		Dedupe:            true,
+		BodyFileThreshold: 1024,
+	})
+	resp, err := pu.Record(context.Background(), recordArgs(""))
+	if !errors.Is(err, usecase.ErrRecordNotSaved) {
+		t.Fatalf("ProxyUsecase.Record() error = %v, want ErrRecordNotSaved", err)
+	}
+	if resp.Status != 200 {
+		t.Errorf("ProxyUsecase.Record() status = %d, want 200", resp.Status)
+	}
+
+	// the failed request is not remembered as recorded, so it is recorded once saving works again
+	sw.err = nil
+	if _, err := pu.Record(context.Background(), recordArgs("")); err != nil {
+		t.Fatalf("ProxyUsecase.Record() error = %v", err)
+	}
+	if len(sw.stubs) != 1 {
+		t.Errorf("Expected 1 stub, got %d", len(sw.stubs))
+	}
+}
+
+func TestProxyUsecase_RecordWithoutDedupe(t *testing.T) {
+	pr := &mockProxyRepository{
+		resp: model.ProxyResponse{
+			Status:  200,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    []byte(`{"items": [1, 2, 3, 4, 5]}`),
+		},
+	}
+	sw := newMockStubWriter()
+	pu := usecase.NewProxyUsecase(pr, sw, usecase.RecordOptions{
+		TargetBaseURL:     "http://upstream",
+		StubDir:           "configs",
+		BodyDir:           "body",
+		BodyFileThreshold: 10,
+	})
+	for range 2 {
+		if _, err := pu.Record(context.Background(), recordArgs("")); err != nil {
+			t.Fatalf("ProxyUsecase.Record() error = %v", err)
+		}
+	}
+	if len(sw.stubs) != 2 || len(sw.bodies) != 2 {
+		t.Fatalf("Expected 2 stubs and 2 body files, got %d and %d", len(sw.stubs), len(sw.bodies))
+	}
+	for _, endpoints := range sw.stubs {
+		e := endpoints[0]
+		if e.Response.Body != "" || filepath.Dir(e.Response.BodyFileName) != "body" || filepath.Ext(e.Response.BodyFileName) != ".json" {
+			t.Errorf("Expected body to be stored in a body file, got %+v", e.Response)
+		}
+		if string(sw.bodies[e.Response.BodyFileName]) != `{"items": [1, 2, 3, 4, 5]}` {
+			t.Errorf("Unexpected body file content %q", sw.bodies[e.Response.BodyFileName])
+		}
+		if !cmp.Equal(e.Request.Body, model.Matcher{}) {
+			t.Errorf("Expected no body matcher for an empty request body, got %+v", e.Request.Body)
+		}
+	}
+}
+
+func TestProxyUsecase_RecordBinaryBody(t *testing.T) {
+	var png bytes.Buffer
+	if err := imagepng.Encode(&png, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	pr := &mockProxyRepository{
+		resp: model.ProxyResponse{
+			Status:  200,
+			Headers: map[string][]string{"Content-Type": {"image/png"}},
+			Body:    png.Bytes(),
+		},
+	}
+	sw := newMockStubWriter()
+	pu := usecase.NewProxyUsecase(pr, sw, usecase.RecordOptions{
+		TargetBaseURL:     "http://upstream",
+		StubDir:           "configs",
+		BodyDir:           "body",
+		Dedupe:            true,
+		BodyFileThreshold: 1024,
+	})
+	if _, err := pu.Record(context.Background(), recordArgs("")); err != nil {
+		t.Fatalf("ProxyUsecase.Record() error = %v", err)
+	}
+	if len(sw.stubs) != 1 || len(sw.bodies) != 1 {
+		t.Fatalf("Expected 1 stub and 1 body file, got %d and %d", len(sw.stubs), len(sw.bodies))
+	}
+	for _, endpoints := range sw.stubs {
+		// a binary body is stored in a body file however small it is, so that its bytes are kept as they are
+		e := endpoints[0]
+		if e.Response.Body != "" || filepath.Dir(e.Response.BodyFileName) != "body" || filepath.Ext(e.Response.BodyFileName) != ".bin" {
+			t.Errorf("Expected body to be stored in a body file, got %+v", e.Response)
+		}
+		if !bytes.Equal(sw.bodies[e.Response.BodyFileName], png.Bytes()) {
+			t.Errorf("Unexpected body file content %q, want %q", sw.bodies[e.Response.BodyFileName], png.Bytes())
+		}
+	}
+}
+
+func TestProxyUsecase_Proxy(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    model.Response
+		url         string
+		wantURL     string
+		wantHeaders http.Header
+	}{
+		{
+			name:     "リクエストをそのまま転送する",
+			response: model.Response{ProxyBaseURL: "http://backend"},
+			url:      "/api/users?page=2",
+			wantURL:  "/api/users?page=2",
+			wantHeaders: http.Header{
+				"Authorization": {"Bearer stub"},
+				"X-Request-Id":  {"random"},
+			},
+		},
+		{
+			name: "ヘッダーの追加と削除、パスのプレフィックスの除去",
+			response: model.Response{
+				ProxyBaseURL:                  "http://backend",
+				AdditionalProxyRequestHeaders: map[string]string{"Authorization": "Bearer real", "X-Env": "local"},
+				RemoveProxyRequestHeaders:     []string{"x-request-id"},
+				ProxyURLPrefixToRemove:        "/api",
+			},
+			url:     "/api/users?page=2",
+			wantURL: "/users?page=2",
+			wantHeaders: http.Header{
+				"Authorization": {"Bearer real"},
+				"X-Env":         {"local"},
+			},
+		},
+		{
+			name: "取り除いたプレフィックスを置き換える",
+			response: model.Response{
+				ProxyBaseURL:              "http://backend",
+				ProxyURLPrefixToRemove:    "/api/v1/",
+				ProxyURLPrefixReplacement: "/v2/",
+			},
+			url:     "/api/v1/users?page=2",
+			wantURL: "/v2/users?page=2",
+			wantHeaders: http.Header{
+				"Authorization": {"Bearer stub"},
+				"X-Request-Id":  {"random"},
+			},
+		},
+		{
+			name: "プレフィックスが一致しない場合はパスを変更しない",
+			response: model.Response{
+				ProxyBaseURL:              "http://backend",
+				ProxyURLPrefixToRemove:    "/v2",
+				ProxyURLPrefixReplacement: "/v3",
+			},
+			url:     "/api/users",
+			wantURL: "/api/users",
+			wantHeaders: http.Header{
+				"Authorization": {"Bearer stub"},
+				"X-Request-Id":  {"random"},
+			},
+		},
+		{
+			name: "パス全体がプレフィックスの場合はルートに転送する",
+			response: model.Response{
+				ProxyBaseURL:           "http://backend",
+				ProxyURLPrefixToRemove: "/api",
+			},
+			url:     "/api",
+			wantURL: "/",
+			wantHeaders: http.Header{
+				"Authorization": {"Bearer stub"},
+				"X-Request-Id":  {"random"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &mockProxyRepository{resp: model.ProxyResponse{Status: 200, Body: []byte("from backend")}}
+			pu := usecase.NewProxyUsecase(pr, newMockStubWriter(), usecase.RecordOptions{})
+			headers := map[string][]string{
+				"Authorization": {"Bearer stub"},
+				"X-Request-Id":  {"random"},
+			}
+			resp, err := pu.Proxy(context.Background(), model.Endpoint{Response: tt.response}, usecase.ProxyArgs{
+				Method:  "GET",
+				URL:     tt.url,
+				Headers: headers,
+			})
+			if err != nil {
+				t.Fatalf("ProxyUsecase.Proxy() error = %v", err)
+			}
+			if string(resp.Body) != "from backend" {
+				t.Errorf("ProxyUsecase.Proxy() body = %q, want %q", resp.Body, "from backend")
+			}
+			want := model.ProxyRequest{
+				BaseURL: "http://backend",
+				Method:  "GET",
+				URL:     tt.wantURL,
+				Headers: tt.wantHeaders,
+			}
+			if diff := cmp.Diff(want, pr.requests[0]); diff != "" {
+				t.Errorf("Forwarded request mismatch (-want +got):\n%s", diff)
+			}
+			if headers["Authorization"][0] != "Bearer stub" || len(headers) != 2 {
+				t.Errorf("The original request headers were modified: %v", headers)
+			}
+		})
+	}
+}
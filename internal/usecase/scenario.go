@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/domain/repository"
+)
+
+var ErrScenarioNotFound = errors.New("scenario not found")
+
+type ScenarioUsecase struct {
+	cr repository.ConfigRepository
+	mr repository.MappingRepository
+	sr repository.ScenarioRepository
+}
+
+func NewScenarioUsecase(cr repository.ConfigRepository, mr repository.MappingRepository, sr repository.ScenarioRepository) ScenarioUsecase {
+	return ScenarioUsecase{
+		cr: cr,
+		mr: mr,
+		sr: sr,
+	}
+}
+
+// List returns the scenarios referred to by the stubs, with their current state, sorted by name.
+// Scenarios that have been moved through SetState but are not referred to by any stub are included as well.
+func (su ScenarioUsecase) List(configPath string) ([]model.Scenario, error) {
+	known, err := su.scenarios(configPath)
+	if err != nil {
+		return nil, err
+	}
+	for name, state := range su.sr.States() {
+		if _, ok := known[name]; !ok {
+			known[name] = []string{model.ScenarioStarted}
+		}
+		if !slices.Contains(known[name], state) {
+			known[name] = append(known[name], state)
+		}
+	}
+	ret := make([]model.Scenario, 0, len(known))
+	for name, states := range known {
+		ret = append(ret, model.Scenario{
+			Name:           name,
+			State:          su.sr.State(name),
+			PossibleStates: states,
+		})
+	}
+	slices.SortFunc(ret, func(a, b model.Scenario) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return ret, nil
+}
+
+// SetState moves a scenario referred to by the stubs to the given state.
+func (su ScenarioUsecase) SetState(configPath, name, state string) error {
+	known, err := su.scenarios(configPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := known[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrScenarioNotFound, name)
+	}
+	su.sr.SetState(name, state)
+	slog.Info(fmt.Sprintf("Scenario %s set to %s", name, state))
+	return nil
+}
+
+// Reset moves every scenario back to its initial state.
+func (su ScenarioUsecase) Reset() {
+	su.sr.Reset()
+	slog.Info("Reset scenarios")
+}
+
+// scenarios collects the scenario names and the states they can be in from the stubs.
+func (su ScenarioUsecase) scenarios(configPath string) (map[string][]string, error) {
+	loaded, err := su.cr.Load(configPath)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return nil, err
+	}
+	ret := make(map[string][]string)
+	for _, e := range append(su.mr.List(), loaded...) {
+		if e.ScenarioName == "" {
+			continue
+		}
+		if _, ok := ret[e.ScenarioName]; !ok {
+			ret[e.ScenarioName] = []string{model.ScenarioStarted}
+		}
+		for _, state := range []string{e.RequiredScenarioState, e.NewScenarioState} {
+			if state != "" && !slices.Contains(ret[e.ScenarioName], state) {
+				ret[e.ScenarioName] = append(ret[e.ScenarioName], state)
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,201 @@
+package usecase_test
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+// jobScenario returns stubs in which GET /job/1 answers "pending" until POST /job/1/complete is received.
+func jobScenario() []model.Endpoint {
+	return []model.Endpoint{
+		{
+			ID:                    "pending",
+			Request:               model.Request{Method: model.Method("GET"), URLPath: "/job/1"},
+			Response:              model.Response{Status: 200, Body: "pending"},
+			ScenarioName:          "job",
+			RequiredScenarioState: model.ScenarioStarted,
+		},
+		{
+			ID:                    "complete",
+			Request:               model.Request{Method: model.Method("POST"), URLPath: "/job/1/complete"},
+			Response:              model.Response{Status: 204, Body: "completed"},
+			ScenarioName:          "job",
+			RequiredScenarioState: model.ScenarioStarted,
+			NewScenarioState:      "done",
+		},
+		{
+			ID:                    "done",
+			Request:               model.Request{Method: model.Method("GET"), URLPath: "/job/1"},
+			Response:              model.Response{Status: 200, Body: "done"},
+			ScenarioName:          "job",
+			RequiredScenarioState: "done",
+		},
+	}
+}
+
+func scenarioRequest(method, path string) usecase.EndpointMatcherArgs {
+	var arg usecase.EndpointMatcherArgs
+	arg.Request.Method = method
+	arg.Request.UrlRawPath = path
+	arg.Request.UrlPath = path
+	arg.Request.Body = io.NopCloser(strings.NewReader(""))
+	return arg
+}
+
+func TestEndpointUsecase_EndpointMatcherScenario(t *testing.T) {
+	sr := newMockScenarioRepository()
+	eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: jobScenario()}, &mockMappingRepository{}, sr)
+
+	steps := []struct {
+		name      string
+		method    string
+		path      string
+		wantBody  string
+		wantState string
+		wantErr   bool
+	}{
+		{name: "初期状態ではpendingを返す", method: "GET", path: "/job/1", wantBody: "pending", wantState: model.ScenarioStarted},
+		{name: "完了リクエストで状態が遷移する", method: "POST", path: "/job/1/complete", wantBody: "completed", wantState: "done"},
+		{name: "遷移後はdoneを返す", method: "GET", path: "/job/1", wantBody: "done", wantState: "done"},
+		{name: "必要な状態でないスタブにはマッチしない", method: "POST", path: "/job/1/complete", wantErr: true, wantState: "done"},
+	}
+	for _, step := range steps {
+		got, err := eu.EndpointMatcher(scenarioRequest(step.method, step.path))
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: EndpointUsecase.EndpointMatcher() error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if got.ResponseBody != step.wantBody {
+			t.Errorf("%s: ResponseBody = %q, want %q", step.name, got.ResponseBody, step.wantBody)
+		}
+		if state := sr.State("job"); state != step.wantState {
+			t.Errorf("%s: scenario state = %q, want %q", step.name, state, step.wantState)
+		}
+	}
+
+	var nm *usecase.NoMatchError
+	_, err := eu.EndpointMatcher(scenarioRequest("POST", "/job/1/complete"))
+	if !errors.As(err, &nm) {
+		t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, want *usecase.NoMatchError", err)
+	}
+	want := usecase.NearMiss{
+		EndpointID: "complete",
+		Score:      5,
+		MaxScore:   6,
+		Mismatches: []usecase.Mismatch{
+			{Matcher: "scenario.job", Expected: model.ScenarioStarted, Actual: "done"},
+		},
+	}
+	if diff := cmp.Diff(want, nm.NearMisses[0]); diff != "" {
+		t.Errorf("NearMisses[0] mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEndpointUsecase_EndpointMatcherScenarioShadowed(t *testing.T) {
+	endpoints := []model.Endpoint{
+		{
+			ID:                    "off",
+			Request:               model.Request{Method: model.Method("POST"), URLPath: "/toggle"},
+			Response:              model.Response{Status: 200, Body: "on"},
+			ScenarioName:          "toggle",
+			RequiredScenarioState: model.ScenarioStarted,
+			NewScenarioState:      "on",
+		},
+		{
+			ID:                    "on",
+			Request:               model.Request{Method: model.Method("POST"), URLPath: "/toggle"},
+			Response:              model.Response{Status: 200, Body: "off"},
+			ScenarioName:          "toggle",
+			RequiredScenarioState: "on",
+			NewScenarioState:      model.ScenarioStarted,
+		},
+	}
+	eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, newMockScenarioRepository())
+	for _, want := range []string{"on", "off", "on"} {
+		got, err := eu.EndpointMatcher(scenarioRequest("POST", "/toggle"))
+		if err != nil {
+			t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v", err)
+		}
+		if got.ResponseBody != want {
+			t.Errorf("ResponseBody = %q, want %q", got.ResponseBody, want)
+		}
+		// the other stub only matches the state the winner moved the scenario to
+		if len(got.Match.Shadowed) != 0 {
+			t.Errorf("Match.Shadowed = %v, want none", got.Match.Shadowed)
+		}
+	}
+}
+
+func TestEndpointUsecase_EndpointMatcherScenarioMissingBodyFile(t *testing.T) {
+	endpoints := jobScenario()
+	endpoints[1].Response = model.Response{Status: 200, BodyFileName: filepath.Join(t.TempDir(), "missing.json")}
+	sr := newMockScenarioRepository()
+	eu := usecase.NewEndpointUsecase(&mockConfigRepository{endpoints: endpoints}, &mockMappingRepository{}, sr)
+
+	// 応答できない場合は状態を遷移させず、再試行でも同じスタブにマッチする
+	for range 2 {
+		if _, err := eu.EndpointMatcher(scenarioRequest("POST", "/job/1/complete")); err == nil || errors.As(err, new(*usecase.NoMatchError)) {
+			t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v, want the error of the body file", err)
+		}
+		if state := sr.State("job"); state != model.ScenarioStarted {
+			t.Fatalf("scenario state = %q, want %q", state, model.ScenarioStarted)
+		}
+	}
+}
+
+func TestScenarioUsecase(t *testing.T) {
+	sr := newMockScenarioRepository()
+	mr := &mockMappingRepository{endpoints: []model.Endpoint{
+		{ID: "login", ScenarioName: "auth", NewScenarioState: "logged-in"},
+	}}
+	su := usecase.NewScenarioUsecase(&mockConfigRepository{endpoints: jobScenario()}, mr, sr)
+
+	got, err := su.List("configs")
+	if err != nil {
+		t.Fatalf("ScenarioUsecase.List() error = %v", err)
+	}
+	want := []model.Scenario{
+		{Name: "auth", State: model.ScenarioStarted, PossibleStates: []string{model.ScenarioStarted, "logged-in"}},
+		{Name: "job", State: model.ScenarioStarted, PossibleStates: []string{model.ScenarioStarted, "done"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ScenarioUsecase.List() mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := su.SetState("configs", "job", "done"); err != nil {
+		t.Fatalf("ScenarioUsecase.SetState() error = %v", err)
+	}
+	if err := su.SetState("configs", "missing", "done"); !errors.Is(err, usecase.ErrScenarioNotFound) {
+		t.Errorf("ScenarioUsecase.SetState() error = %v, want %v", err, usecase.ErrScenarioNotFound)
+	}
+	if state := sr.State("job"); state != "done" {
+		t.Errorf("scenario state = %q, want %q", state, "done")
+	}
+
+	// 設定ファイルにない状態に設定した場合も一覧に含まれる
+	if err := su.SetState("configs", "auth", "expired"); err != nil {
+		t.Fatalf("ScenarioUsecase.SetState() error = %v", err)
+	}
+	got, err = su.List("configs")
+	if err != nil {
+		t.Fatalf("ScenarioUsecase.List() error = %v", err)
+	}
+	want = []model.Scenario{
+		{Name: "auth", State: "expired", PossibleStates: []string{model.ScenarioStarted, "logged-in", "expired"}},
+		{Name: "job", State: "done", PossibleStates: []string{model.ScenarioStarted, "done"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ScenarioUsecase.List() mismatch (-want +got):\n%s", diff)
+	}
+
+	su.Reset()
+	if state := sr.State("job"); state != model.ScenarioStarted {
+		t.Errorf("scenario state after reset = %q, want %q", state, model.ScenarioStarted)
+	}
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+)
+
+const randomStringChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// templateFuncs returns the functions available in response body and header templates.
+// body is the request body that jsonPath selects from.
+func templateFuncs(body string) map[string]any {
+	return map[string]any{
+		"uuid":         newID,
+		"now":          func() string { return time.Now().UTC().Format(time.RFC3339) },
+		"formatDate":   formatDate,
+		"dateAdd":      dateAdd,
+		"randomInt":    randomInt,
+		"randomString": randomString,
+		"base64Encode": func(s any) string { return base64.StdEncoding.EncodeToString([]byte(templateString(s))) },
+		"base64Decode": base64Decode,
+		"urlEncode":    func(s any) string { return url.QueryEscape(templateString(s)) },
+		"urlDecode":    func(s any) (string, error) { return url.QueryUnescape(templateString(s)) },
+		"add":          arithmetic(func(a, b float64) (float64, error) { return a + b, nil }),
+		"sub":          arithmetic(func(a, b float64) (float64, error) { return a - b, nil }),
+		"mul":          arithmetic(func(a, b float64) (float64, error) { return a * b, nil }),
+		"div": arithmetic(func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return a / b, nil
+		}),
+		"mod": arithmetic(func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return math.Mod(a, b), nil
+		}),
+		"upper":   func(s any) string { return strings.ToUpper(templateString(s)) },
+		"lower":   func(s any) string { return strings.ToLower(templateString(s)) },
+		"title":   title,
+		"trim":    func(s any) string { return strings.TrimSpace(templateString(s)) },
+		"replace": func(old, new string, s any) string { return strings.ReplaceAll(templateString(s), old, new) },
+		"default": defaultValue,
+		"jsonPath": func(expression string) (string, error) {
+			values, err := model.JSONPathValues(expression, body)
+			if err != nil || len(values) == 0 {
+				return "", err
+			}
+			return values[0], nil
+		},
+		"toJson": toJSON,
+	}
+}
+
+// templateString converts a template value, such as a claim or a query parameter, to a string.
+// Numbers are written without an exponent, so that a timestamp stays readable.
+func templateString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatDate formats a date-time with a Go layout or one of RFC3339, RFC1123 (as used in HTTP headers), unix and unixMilli.
+// The value is read like the expected value of a date-time matcher, so it may be "now" or "now+1d".
+func formatDate(layout string, value any) (string, error) {
+	t, err := model.ParseDateTime(templateString(value), time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	switch layout {
+	case "RFC3339":
+		return t.Format(time.RFC3339), nil
+	case "RFC1123":
+		return t.UTC().Format(http.TimeFormat), nil
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case "unixMilli":
+		return strconv.FormatInt(t.UnixMilli(), 10), nil
+	default:
+		return t.Format(layout), nil
+	}
+}
+
+// dateAdd moves a date-time by offsets such as +1d or -1M+2h and returns it in RFC 3339.
+func dateAdd(offsets string, value any) (string, error) {
+	t, err := model.ParseDateTime(templateString(value), time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	if t, err = model.AddDateTimeOffsets(t, offsets); err != nil {
+		return "", err
+	}
+	return t.Format(time.RFC3339Nano), nil
+}
+
+// randomInt returns a random integer between min and max, both included.
+func randomInt(min, max int) (int, error) {
+	if max < min {
+		return 0, fmt.Errorf("randomInt: max %d is less than min %d", max, min)
+	}
+	return min + rand.IntN(max-min+1), nil
+}
+
+// randomString returns a random string of letters and digits.
+func randomString(length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = randomStringChars[rand.IntN(len(randomStringChars))]
+	}
+	return string(b)
+}
+
+func base64Decode(s any) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(templateString(s))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// arithmetic turns an operation into a template function that accepts numbers and numeric strings.
+// A whole result is returned as an integer, so that add 1 "2" writes 3 rather than 3.0.
+func arithmetic(op func(a, b float64) (float64, error)) func(a, b any) (any, error) {
+	return func(a, b any) (any, error) {
+		x, err := strconv.ParseFloat(strings.TrimSpace(templateString(a)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number: %v", a)
+		}
+		y, err := strconv.ParseFloat(strings.TrimSpace(templateString(b)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number: %v", b)
+		}
+		r, err := op(x, y)
+		if err != nil {
+			return nil, err
+		}
+		if r == math.Trunc(r) && math.Abs(r) < 1<<53 {
+			return int64(r), nil
+		}
+		return r, nil
+	}
+}
+
+// title upper-cases the first letter of each word.
+func title(s any) string {
+	var b strings.Builder
+	start := true
+	for _, r := range templateString(s) {
+		if start {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(r)
+		}
+		start = !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+	return b.String()
+}
+
+// defaultValue returns value, or def if value is missing, an empty string or an empty collection.
+func defaultValue(def, value any) any {
+	if value == nil {
+		return def
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if v.Len() == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+// toJSON encodes a value as compact JSON, without escaping HTML characters.
+func toJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,225 @@
+package usecase_test
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-shimada/gostubby/internal/domain/model"
+	"github.com/dev-shimada/gostubby/internal/usecase"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEndpointUsecase_ResponseCreatorFuncs(t *testing.T) {
+	data := usecase.TemplateData{
+		Query:  map[string]string{"page": "2", "q": "hello world", "empty": ""},
+		Claims: map[string]any{"exp": float64(1767225600), "roles": []any{"admin", "dev"}},
+	}
+	body := `{"user": {"name": "Alice", "tags": ["a", "b"]}}`
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		pattern  string // used instead of want for random and time dependent output
+		wantErr  bool
+	}{
+		{name: "uuid", template: `{{uuid}}`, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{name: "現在時刻", template: `{{now}}`, pattern: `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`},
+		{name: "日付のフォーマット", template: `{{formatDate "2006-01-02" "2026-01-31T10:00:00Z"}}`, want: "2026-01-31"},
+		{name: "Unix時間のフォーマット", template: `{{.Claims.exp | formatDate "RFC3339"}}`, want: "2026-01-01T00:00:00Z"},
+		{name: "HTTPの日付", template: `{{formatDate "RFC1123" "2026-01-31"}}`, want: "Sat, 31 Jan 2026 00:00:00 GMT"},
+		{name: "Unix時間への変換", template: `{{formatDate "unix" "2026-01-01T00:00:00Z"}}`, want: "1767225600"},
+		{name: "相対時刻", template: `{{formatDate "2006" "now+1y"}}`, want: time.Now().UTC().AddDate(1, 0, 0).Format("2006")},
+		{name: "日付の加算", template: `{{"2026-01-31T10:00:00Z" | dateAdd "+1M-2h" | formatDate "2006-01-02 15:04"}}`, want: "2026-03-03 08:00"},
+		{name: "不正なオフセット", template: `{{dateAdd "tomorrow" "2026-01-31"}}`, wantErr: true},
+		{name: "不正な日付", template: `{{formatDate "2006" "yesterday"}}`, wantErr: true},
+		{name: "乱数", template: `{{randomInt 1 3}}`, pattern: `^[123]$`},
+		{name: "乱数の範囲が逆", template: `{{randomInt 3 1}}`, wantErr: true},
+		{name: "ランダムな文字列", template: `{{randomString 12}}`, pattern: `^[a-zA-Z0-9]{12}$`},
+		{name: "Base64", template: `{{base64Encode "user:pass"}} {{base64Decode "dXNlcjpwYXNz"}}`, want: "dXNlcjpwYXNz user:pass"},
+		{name: "URLエンコード", template: `{{urlEncode .Query.q}} {{urlDecode "a%2Fb"}}`, want: "hello+world a/b"},
+		{name: "計算", template: `{{add .Query.page 1}} {{sub 10 .Query.page}} {{mul .Query.page 2.5}} {{div 7 2}} {{mod 7 .Query.page}}`, want: "3 8 5 3.5 1"},
+		{name: "ゼロ除算", template: `{{div 1 0}}`, wantErr: true},
+		{name: "数値でない値の計算", template: `{{add .Query.q 1}}`, wantErr: true},
+		{name: "大文字と小文字", template: `{{upper .Query.q}} {{lower "ABC"}} {{title .Query.q}}`, want: "HELLO WORLD abc Hello World"},
+		{name: "文字列の置換", template: `{{.Query.q | replace " " "-" | trim}}`, want: "hello-world"},
+		{name: "デフォルト値", template: `{{.Query.empty | default "1"}} {{.Query.missing | default "1"}} {{.Query.page | default "1"}}`, want: "1 1 2"},
+		{name: "JSONPath", template: `{{jsonPath "$.user.name"}} {{jsonPath "$.user.tags"}} [{{jsonPath "$.user.age"}}]`, want: `Alice ["a","b"] []`},
+		{name: "不正なJSONPath", template: `{{jsonPath "user["}}`, wantErr: true},
+		{name: "JSONへの変換", template: `{{toJson .Claims.roles}} {{toJson "<a & b>"}}`, want: `["admin","dev"] "<a & b>"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arg usecase.ResponseCreatorArgs
+			arg.Request.Body = body
+			arg.Endpoint.Response.Headers = map[string]model.ResponseHeader{"X-Test": {tt.template}}
+			arg.ResponseBody = "{{.Query.page | add 1}}"
+
+			eu := usecase.NewEndpointUsecase(&mockConfigRepository{}, &mockMappingRepository{}, newMockScenarioRepository())
+			got, err := eu.ResponseCreator(arg)
+			if err != nil {
+				t.Fatalf("EndpointUsecase.ResponseCreator() error = %v", err)
+			}
+			var b strings.Builder
+			if err := got.Template.Execute(&b, data); err != nil || b.String() != "3" {
+				t.Errorf("Body template = %q, %v, want %q", b.String(), err, "3")
+			}
+			// header templates get the same functions as the body
+			b.Reset()
+			err = got.Headers["X-Test"][0].Execute(&b, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.pattern != "" {
+				if !regexp.MustCompile(tt.pattern).MatchString(b.String()) {
+					t.Errorf("Execute() = %q, want a match of %s", b.String(), tt.pattern)
+				}
+				return
+			}
+			if b.String() != tt.want {
+				t.Errorf("Execute() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointUsecase_EndpointMatcherTemplateRequest(t *testing.T) {
+	cr := &mockConfigRepository{
+		endpoints: []model.Endpoint{
+			{
+				ID:       "echo",
+				Request:  model.Request{URLPath: "/echo"},
+				Response: model.Response{Status: 200, Body: "{{.Request.Body}}"},
+			},
+		},
+	}
+	receivedAt := time.Date(2026, 1, 31, 19, 0, 0, 500000000, time.FixedZone("JST", 9*60*60))
+	tests := []struct {
+		name    string
+		headers map[string][]string
+		body    string
+		want    usecase.TemplateRequest
+	}{
+		{
+			name: "JSONのボディとクッキー",
+			headers: map[string][]string{
+				"Content-Type": {"application/json"},
+				"Cookie":       {"session=abc; theme=dark"},
+				"X-Tag":        {"a", "b"},
+			},
+			body: `{"user": {"id": 12345678901234567890, "name": "Alice"}}`,
+			want: usecase.TemplateRequest{
+				JSON: map[string]any{"user": map[string]any{"id": json.Number("12345678901234567890"), "name": "Alice"}},
+				Header: map[string]string{
+					"Content-Type": "application/json",
+					"Cookie":       "session=abc; theme=dark",
+					"X-Tag":        "a",
+				},
+				Cookies: map[string]string{"session": "abc", "theme": "dark"},
+			},
+		},
+		{
+			name:    "フォームのボディ",
+			headers: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}},
+			body:    "name=Alice&tag=a&tag=b",
+			want: usecase.TemplateRequest{
+				Header:  map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Cookies: map[string]string{},
+				Form:    map[string]string{"name": "Alice", "tag": "a"},
+			},
+		},
+		{
+			name:    "JSONでもフォームでもないボディ",
+			headers: map[string][]string{"Content-Type": {"text/plain"}},
+			body:    `{"user": 1} trailing`,
+			want: usecase.TemplateRequest{
+				Header:  map[string]string{"Content-Type": "text/plain"},
+				Cookies: map[string]string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eu := usecase.NewEndpointUsecase(cr, &mockMappingRepository{}, newMockScenarioRepository())
+			got, err := eu.EndpointMatcher(usecase.EndpointMatcherArgs{
+				Request: usecase.MatcherRequest{
+					UrlPath:    "/echo",
+					Body:       io.NopCloser(strings.NewReader(tt.body)),
+					Method:     "POST",
+					Headers:    tt.headers,
+					Scheme:     "https",
+					Host:       "example.com",
+					Port:       "8443",
+					URL:        "https://example.com:8443/echo?x=1",
+					RemoteAddr: "192.0.2.1:51234",
+					ReceivedAt: receivedAt,
+				},
+			})
+			if err != nil {
+				t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v", err)
+			}
+			want := tt.want
+			want.Method = "POST"
+			want.URL = "https://example.com:8443/echo?x=1"
+			want.Path = "/echo"
+			want.Scheme = "https"
+			want.Host = "example.com"
+			want.Port = "8443"
+			want.RemoteAddr = "192.0.2.1:51234"
+			want.Headers = tt.headers
+			want.Body = tt.body
+			want.Time = "2026-01-31T10:00:00.5Z"
+			if diff := cmp.Diff(want, got.Data.Request); diff != "" {
+				t.Errorf("EndpointUsecase.EndpointMatcher() request mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEndpointUsecase_TemplateRequestHeader(t *testing.T) {
+	cr := &mockConfigRepository{
+		endpoints: []model.Endpoint{
+			{
+				ID:      "echo",
+				Request: model.Request{URLPath: "/echo"},
+				// a name with a hyphen is not a valid field name, so it is looked up with index
+				Response: model.Response{Status: 200, Body: `{{index .Request.Header "X-Request-Id"}} {{.Request.Header.Authorization}}`},
+			},
+		},
+	}
+	eu := usecase.NewEndpointUsecase(cr, &mockMappingRepository{}, newMockScenarioRepository())
+	got, err := eu.EndpointMatcher(usecase.EndpointMatcherArgs{
+		Request: usecase.MatcherRequest{
+			UrlPath: "/echo",
+			Body:    io.NopCloser(strings.NewReader("")),
+			Method:  "GET",
+			Headers: map[string][]string{
+				"Authorization": {"Bearer token"},
+				"X-Request-Id":  {"abc-123"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("EndpointUsecase.EndpointMatcher() error = %v", err)
+	}
+	rc, err := eu.ResponseCreator(usecase.ResponseCreatorArgs{
+		Endpoint:     got.Endpoint,
+		ResponseBody: got.ResponseBody,
+	})
+	if err != nil {
+		t.Fatalf("EndpointUsecase.ResponseCreator() error = %v", err)
+	}
+	var b strings.Builder
+	if err := rc.Template.Execute(&b, got.Data); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if want := "abc-123 Bearer token"; b.String() != want {
+		t.Errorf("Execute() = %q, want %q", b.String(), want)
+	}
+}
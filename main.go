@@ -7,13 +7,22 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/dev-shimada/gostubby/internal/handler"
 	"github.com/dev-shimada/gostubby/internal/infrastructure/config"
+	"github.com/dev-shimada/gostubby/internal/infrastructure/journal"
+	"github.com/dev-shimada/gostubby/internal/infrastructure/mapping"
+	"github.com/dev-shimada/gostubby/internal/infrastructure/openapi"
+	"github.com/dev-shimada/gostubby/internal/infrastructure/proxy"
+	"github.com/dev-shimada/gostubby/internal/infrastructure/scenario"
 	"github.com/dev-shimada/gostubby/internal/usecase"
 )
 
@@ -26,11 +35,17 @@ func main() {
 	slog.SetDefault(slog.New(slog.NewJSONHandler(log.Writer(), nil)))
 
 	var (
-		host      string
-		port      int
-		httpsPort int
-		certFile  string
-		keyFile   string
+		host        string
+		port        int
+		httpsPort   int
+		certFile    string
+		keyFile     string
+		journalSize int
+		nearMiss    string
+		delay       time.Duration
+		record      usecase.RecordOptions
+		recordHdrs  string
+		openAPI     usecase.OpenAPIOptions
 		// configPath string
 	)
 	// Host configuration
@@ -52,25 +67,102 @@ func main() {
 	// General configuration
 	configPath = *flag.String("config", "configs", "Path to configuration directory or file")
 	flag.StringVar(&configPath, "c", "configs", "Path to configuration directory or file")
+	flag.StringVar(&nearMiss, "near-miss-format", handler.NearMissFormatAuto, "Format of the 404 response when no stub matches: auto, json, text or none")
+	flag.DurationVar(&delay, "delay", 0, "Default response delay for stubs that do not define one, e.g. 200ms")
+	flag.IntVar(&journalSize, "journal-size", 1000, "Maximum number of requests kept in the request journal (0 disables it)")
+
+	// Record mode configuration
+	flag.StringVar(&record.TargetBaseURL, "record-target", "", "Base URL of the server unmatched requests are forwarded to and recorded from (enables record mode)")
+	flag.StringVar(&record.StubDir, "record-dir", "", "Directory recorded stubs are written to (default: the config directory)")
+	flag.StringVar(&record.BodyDir, "record-body-dir", "body", "Directory large recorded response bodies are written to")
+	flag.StringVar(&recordHdrs, "record-headers", "", "Comma-separated request headers that become matchers of recorded stubs")
+	flag.BoolVar(&record.Dedupe, "record-dedupe", true, "Record repeated identical requests only once")
+	flag.IntVar(&record.BodyFileThreshold, "record-body-threshold", 1024, "Recorded response bodies larger than this many bytes are stored via bodyFileName")
+
+	// OpenAPI validation configuration
+	flag.StringVar(&openAPI.Path, "openapi", "", "Path to an OpenAPI 3 document (JSON or YAML) that requests are validated against")
+	flag.BoolVar(&openAPI.ValidateResponses, "openapi-validate-responses", false, "Also validate the responses rendered from stubs against the OpenAPI document and log violations")
 	flag.Parse()
 
+	if err := handler.ValidateNearMissFormat(nearMiss); err != nil {
+		slog.Error(fmt.Sprintf("Invalid --near-miss-format: %v", err))
+		os.Exit(1)
+	}
+
+	if record.TargetBaseURL != "" {
+		if record.StubDir == "" {
+			record.StubDir = configPath
+			if info, err := os.Stat(configPath); err == nil && !info.IsDir() {
+				record.StubDir = filepath.Dir(configPath)
+				slog.Warn(fmt.Sprintf("Config path %s is a file, recorded stubs in %s will not be loaded", configPath, record.StubDir))
+			}
+		}
+		for h := range strings.SplitSeq(recordHdrs, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				record.MatchHeaders = append(record.MatchHeaders, h)
+			}
+		}
+		slog.Info(fmt.Sprintf("Recording unmatched requests from %s into %s", record.TargetBaseURL, record.StubDir))
+	}
+
+	or := openapi.NewOpenAPIRepository()
+	if openAPI.Path != "" {
+		// fail fast on a broken document instead of rejecting every request
+		if _, err := or.Load(openAPI.Path); err != nil {
+			slog.Error(fmt.Sprintf("Failed to load OpenAPI document: %v", err))
+			os.Exit(1)
+		}
+		slog.Info(fmt.Sprintf("Validating requests against %s", openAPI.Path))
+	}
+
 	mux := http.NewServeMux()
 
 	// Dependency injection
 	cr := config.NewConfigRepository()
-	eu := usecase.NewEndpointUsecase(cr)
-	eh := handler.NewEndpointHandler(configPath, eu)
+	mr := mapping.NewMappingRepository()
+	jr := journal.NewJournalRepository(journalSize)
+	pr := proxy.NewProxyRepository()
+	sr := scenario.NewScenarioRepository()
+	eu := usecase.NewEndpointUsecase(cr, mr, sr)
+	ju := usecase.NewJournalUsecase(jr)
+	pu := usecase.NewProxyUsecase(pr, cr, record)
+	ou := usecase.NewOpenAPIUsecase(or, openAPI)
+	eh := handler.NewEndpointHandler(configPath, nearMiss, delay, eu, ju, pu, ou)
+	mu := usecase.NewMappingUsecase(mr)
+	mh := handler.NewMappingHandler(mu)
+	jh := handler.NewJournalHandler(ju)
+	su := usecase.NewScenarioUsecase(cr, mr, sr)
+	sh := handler.NewScenarioHandler(configPath, su)
 
 	mux.HandleFunc("/", eh.Handle)
 
+	// Admin API
+	mux.HandleFunc("GET /__admin/mappings", mh.List)
+	mux.HandleFunc("POST /__admin/mappings", mh.Create)
+	mux.HandleFunc("POST /__admin/mappings/reset", mh.Reset)
+	mux.HandleFunc("GET /__admin/mappings/{id}", mh.Get)
+	mux.HandleFunc("PUT /__admin/mappings/{id}", mh.Update)
+	mux.HandleFunc("DELETE /__admin/mappings/{id}", mh.Delete)
+	mux.HandleFunc("GET /__admin/requests", jh.List)
+	mux.HandleFunc("DELETE /__admin/requests", jh.Clear)
+	mux.HandleFunc("POST /__admin/requests/find", jh.Find)
+	mux.HandleFunc("POST /__admin/requests/count", jh.Count)
+	mux.HandleFunc("POST /__admin/requests/verify", jh.Verify)
+	mux.HandleFunc("GET /__admin/scenarios", sh.List)
+	mux.HandleFunc("POST /__admin/scenarios/reset", sh.Reset)
+	mux.HandleFunc("PUT /__admin/scenarios/{name}/state", sh.SetState)
+
 	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	// defer stop()
 
 	// Create HTTP server
 	httpAddr := fmt.Sprintf("%s:%d", host, port)
+	// request contexts are derived from ctx, so that delayed responses are cancelled on shutdown
+	baseContext := func(net.Listener) context.Context { return ctx }
 	httpSrv := &http.Server{
-		Addr:    httpAddr,
-		Handler: mux,
+		Addr:        httpAddr,
+		Handler:     mux,
+		BaseContext: baseContext,
 	}
 
 	// Create HTTPS server if certificate and key files are provided
@@ -78,8 +170,9 @@ func main() {
 	if certFile != "" && keyFile != "" {
 		httpsAddr := fmt.Sprintf("%s:%d", host, httpsPort)
 		httpsSrv = &http.Server{
-			Addr:    httpsAddr,
-			Handler: mux,
+			Addr:        httpsAddr,
+			Handler:     mux,
+			BaseContext: baseContext,
 			TLSConfig: &tls.Config{
 				MinVersion: tls.VersionTLS12,
 			},